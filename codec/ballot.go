@@ -6,6 +6,7 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -24,6 +25,29 @@ func (o Ballot) Kind() tezos.OpType {
 	return tezos.OpTypeBallot
 }
 
+// WithSource sets the voting delegate.
+func (o *Ballot) WithSource(addr tezos.Address) {
+	o.Source = addr
+}
+
+// Validate checks that the ballot has a valid source, voting period, proposal
+// and vote.
+func (o Ballot) Validate() error {
+	if !o.Source.IsValid() {
+		return fmt.Errorf("tezos: missing ballot source")
+	}
+	if o.Period < 0 {
+		return fmt.Errorf("tezos: invalid voting period %d", o.Period)
+	}
+	if !o.Proposal.IsValid() {
+		return fmt.Errorf("tezos: missing ballot proposal")
+	}
+	if !o.Ballot.IsValid() {
+		return fmt.Errorf("tezos: invalid ballot vote")
+	}
+	return nil
+}
+
 func (o Ballot) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
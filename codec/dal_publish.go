@@ -6,11 +6,17 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
 )
 
+const (
+	DalCommitmentSize      = 48 // size of a DAL slot commitment in bytes
+	DalCommitmentProofSize = 48 // size of a DAL slot commitment proof in bytes
+)
+
 // DalPublishSlotHeader represents "Dal_publish_slot_header" operation
 type DalPublishSlotHeader struct {
 	Manager
@@ -20,6 +26,23 @@ type DalPublishSlotHeader struct {
 	Proof      tezos.HexBytes `json:"commitment_proof"`
 }
 
+// NewDalPublishSlotHeader creates a DAL slot header publication for slot index
+// at level and checks commitment and proof sizes.
+func NewDalPublishSlotHeader(level int32, index byte, commitment, proof []byte) (*DalPublishSlotHeader, error) {
+	if l := len(commitment); l != DalCommitmentSize {
+		return nil, fmt.Errorf("tezos: invalid DAL commitment length %d, expected %d", l, DalCommitmentSize)
+	}
+	if l := len(proof); l != DalCommitmentProofSize {
+		return nil, fmt.Errorf("tezos: invalid DAL commitment proof length %d, expected %d", l, DalCommitmentProofSize)
+	}
+	return &DalPublishSlotHeader{
+		Level:      level,
+		Index:      index,
+		Commitment: tezos.HexBytes(commitment),
+		Proof:      tezos.HexBytes(proof),
+	}, nil
+}
+
 func (o DalPublishSlotHeader) Kind() tezos.OpType {
 	return tezos.OpTypeDalPublishSlotHeader
 }
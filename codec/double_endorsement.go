@@ -6,6 +6,7 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -101,6 +102,27 @@ func (o TenderbakeDoubleEndorsementEvidence) Kind() tezos.OpType {
 	return tezos.OpTypeDoubleEndorsementEvidence
 }
 
+// Normalize checks both endorsements refer to the same level and round and
+// orders them by operation hash as required by the protocol. Params are used
+// to select the correct operation tags, nil defaults to the latest protocol.
+func (o *TenderbakeDoubleEndorsementEvidence) Normalize(p *tezos.Params) error {
+	e1, e2 := o.Op1.Endorsement, o.Op2.Endorsement
+	if e1.Level != e2.Level {
+		return fmt.Errorf("tezos: double endorsement level mismatch %d != %d", e1.Level, e2.Level)
+	}
+	if e1.Round != e2.Round {
+		return fmt.Errorf("tezos: double endorsement round mismatch %d != %d", e1.Round, e2.Round)
+	}
+	h1, h2 := o.Op1.Hash(p), o.Op2.Hash(p)
+	switch bytes.Compare(h1[:], h2[:]) {
+	case 0:
+		return fmt.Errorf("tezos: double endorsement with identical operations")
+	case 1:
+		o.Op1, o.Op2 = o.Op2, o.Op1
+	}
+	return nil
+}
+
 func (o TenderbakeDoubleEndorsementEvidence) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
@@ -6,6 +6,8 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -78,12 +80,42 @@ func (o InlinedEndorsement) MarshalJSON() ([]byte, error) {
 	} else {
 		buf.Write(b)
 	}
-	buf.WriteString(`,"signature":`)
-	buf.WriteString(strconv.Quote(o.Signature.String()))
+	if o.Signature.IsValid() {
+		buf.WriteString(`,"signature":`)
+		buf.WriteString(strconv.Quote(o.Signature.String()))
+	}
 	buf.WriteByte('}')
 	return buf.Bytes(), nil
 }
 
+func (o *InlinedEndorsement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Branch      tezos.BlockHash `json:"branch"`
+		Endorsement struct {
+			Kind  tezos.OpType `json:"kind"`
+			Level int32        `json:"level"`
+		} `json:"operations"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if k := v.Endorsement.Kind; k != tezos.OpTypeEndorsement {
+		return fmt.Errorf("tezos: invalid inlined endorsement kind %q", k)
+	}
+	o.Branch = v.Branch
+	o.Endorsement.Level = v.Endorsement.Level
+	o.Signature = tezos.InvalidSignature
+	if v.Signature != "" {
+		sig, err := tezos.ParseSignature(v.Signature)
+		if err != nil {
+			return err
+		}
+		o.Signature = sig
+	}
+	return nil
+}
+
 func (o InlinedEndorsement) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) error {
 	buf.Write(o.Branch.Bytes())
 	o.Endorsement.EncodeBuffer(buf, p)
@@ -116,13 +148,38 @@ func (o EndorsementWithSlot) Kind() tezos.OpType {
 	return tezos.OpTypeEndorsementWithSlot
 }
 
+// Branch returns the branch of the wrapped endorsement.
+func (o EndorsementWithSlot) Branch() tezos.BlockHash {
+	return o.Endorsement.Branch
+}
+
+// Level returns the endorsed level.
+func (o EndorsementWithSlot) Level() int32 {
+	return o.Endorsement.Endorsement.Level
+}
+
+// Signature returns the signature of the wrapped endorsement.
+func (o EndorsementWithSlot) Signature() tezos.Signature {
+	return o.Endorsement.Signature
+}
+
+// SyncBranch sets the branch of the wrapped endorsement. The protocol requires
+// it to match the outer operation branch, so use this whenever the operation's
+// branch is changed, e.g. before simulation.
+func (o *EndorsementWithSlot) SyncBranch(hash tezos.BlockHash) {
+	o.Endorsement.Branch = hash.Clone()
+}
+
 func (o EndorsementWithSlot) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
 	buf.WriteString(`"kind":`)
 	buf.WriteString(strconv.Quote(o.Kind().String()))
 	buf.WriteString(`,"endorsement":`)
-	b, _ := o.Endorsement.MarshalJSON()
+	b, err := o.Endorsement.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
 	buf.Write(b)
 	buf.WriteString(`,"slot":`)
 	buf.WriteString(strconv.Itoa(int(o.Slot)))
@@ -130,6 +187,23 @@ func (o EndorsementWithSlot) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func (o *EndorsementWithSlot) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind        tezos.OpType       `json:"kind"`
+		Endorsement InlinedEndorsement `json:"endorsement"`
+		Slot        int16              `json:"slot"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Kind != o.Kind() {
+		return fmt.Errorf("tezos: invalid kind %q for %s", v.Kind, o.Kind())
+	}
+	o.Endorsement = v.Endorsement
+	o.Slot = v.Slot
+	return nil
+}
+
 func (o EndorsementWithSlot) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) error {
 	buf.WriteByte(o.Kind().TagVersion(p.OperationTagsVersion))
 	b2 := bytes.NewBuffer(nil)
@@ -263,6 +337,19 @@ func (o TenderbakeInlinedEndorsement) EncodeBuffer(buf *bytes.Buffer, p *tezos.P
 	return nil
 }
 
+// Hash returns the operation hash of the inlined endorsement as it was
+// originally injected, i.e. the blake2b hash over branch, contents and signature.
+func (o TenderbakeInlinedEndorsement) Hash(p *tezos.Params) (h tezos.OpHash) {
+	if p == nil {
+		p = tezos.DefaultParams
+	}
+	buf := bytes.NewBuffer(nil)
+	_ = o.EncodeBuffer(buf, p)
+	d := tezos.Digest(buf.Bytes())
+	copy(h[:], d[:])
+	return
+}
+
 func (o *TenderbakeInlinedEndorsement) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (err error) {
 	err = o.Branch.UnmarshalBinary(buf.Next(tezos.HashTypeBlock.Len))
 	if err != nil {
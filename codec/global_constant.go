@@ -54,7 +54,7 @@ func (o *RegisterGlobalConstant) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params
 		return err
 	}
 	_ = buf.Next(4)
-	if err = o.Value.DecodeBuffer(buf); err != nil {
+	if err = o.Value.DecodeBufferWithOptions(buf, DecodeOptions); err != nil {
 		return err
 	}
 	return nil
@@ -5,6 +5,7 @@ package codec
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -21,6 +22,35 @@ func (o IncreasePaidStorage) Kind() tezos.OpType {
 	return tezos.OpTypeIncreasePaidStorage
 }
 
+// StorageCost returns the amount of mutez burned for prepaying Amount bytes of
+// storage under params p. Since the cost is deterministic no simulation is
+// required. Nil params use tezos.DefaultParams.
+func (o IncreasePaidStorage) StorageCost(p *tezos.Params) tezos.Z {
+	if p == nil {
+		p = tezos.DefaultParams
+	}
+	return o.Amount.Mul64(p.CostPerByte)
+}
+
+// Validate checks that the operation prepays a positive amount of storage
+// for a smart contract and that the amount fits into the hard storage limit
+// per operation under params p. Nil params use tezos.DefaultParams.
+func (o IncreasePaidStorage) Validate(p *tezos.Params) error {
+	if p == nil {
+		p = tezos.DefaultParams
+	}
+	if !o.Destination.IsContract() {
+		return fmt.Errorf("tezos: increase paid storage destination %s is not a contract", o.Destination)
+	}
+	if o.Amount.IsNeg() || o.Amount.IsZero() {
+		return fmt.Errorf("tezos: invalid paid storage amount %s", o.Amount)
+	}
+	if limit := tezos.NewZ(p.HardStorageLimitPerOperation); limit.IsLess(o.Amount) {
+		return fmt.Errorf("tezos: paid storage amount %s exceeds storage limit %s", o.Amount, limit)
+	}
+	return nil
+}
+
 func (o IncreasePaidStorage) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
@@ -92,6 +92,10 @@ func (o *Manager) WithSource(addr tezos.Address) {
 	o.Source = addr
 }
 
+func (o Manager) GetSource() tezos.Address {
+	return o.Source
+}
+
 func (o *Manager) WithCounter(c int64) {
 	o.Counter.SetInt64(c)
 }
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"blockwatch.cc/tzgo/micheline"
 	"blockwatch.cc/tzgo/tezos"
@@ -50,13 +51,14 @@ type Operation interface {
 // operations, but is agnostic to the order/lifecycle in which data is added
 // or updated.
 type Op struct {
-	Branch    tezos.BlockHash    `json:"branch"`    // used for TTL handling
-	Contents  []Operation        `json:"contents"`  // non-zero list of transactions
-	Signature tezos.Signature    `json:"signature"` // added during the lifecycle
-	ChainId   *tezos.ChainIdHash `json:"-"`         // optional, used for remote signing only
-	TTL       int64              `json:"-"`         // optional, specify TTL in blocks
-	Params    *tezos.Params      `json:"-"`         // optional, define protocol to encode for
-	Source    tezos.Address      `json:"-"`         // optional, used as manager/sender
+	Branch     tezos.BlockHash    `json:"branch"`    // used for TTL handling
+	Contents   []Operation        `json:"contents"`  // non-zero list of transactions
+	Signature  tezos.Signature    `json:"signature"` // added during the lifecycle
+	Signatures []tezos.Signature  `json:"-"`         // optional, additional signatures for multi-signed ops
+	ChainId    *tezos.ChainIdHash `json:"-"`         // optional, used for remote signing only
+	TTL        int64              `json:"-"`         // optional, specify TTL in blocks
+	Params     *tezos.Params      `json:"-"`         // optional, define protocol to encode for
+	Source     tezos.Address      `json:"-"`         // optional, used as manager/sender
 }
 
 // NewOp creates a new empty operation that uses default params and a
@@ -180,6 +182,26 @@ func (o *Op) WithOriginationExt(script micheline.Script, baker tezos.Address, am
 	return o
 }
 
+// WithOriginationScript adds a contract origination with initial storage taken
+// from script, an initial balance and an optional delegate to the contents list.
+// The initial storage is checked against the script's storage type in Validate.
+// Source must be defined via WithSource() before calling this function.
+func (o *Op) WithOriginationScript(script *micheline.Script, balance int64, delegate *tezos.Address) *Op {
+	orig := &Origination{
+		Manager: Manager{
+			Source:  o.Source,
+			Counter: 0,
+		},
+		Balance: tezos.N(balance),
+		Script:  *script,
+	}
+	if delegate != nil {
+		orig.Delegate = *delegate
+	}
+	o.Contents = append(o.Contents, orig)
+	return o
+}
+
 // WithDelegation adds a delegation transaction to the contents list.
 // Source must be defined via WithSource() before calling this function.
 func (o *Op) WithDelegation(to tezos.Address) *Op {
@@ -210,12 +232,23 @@ func (o *Op) WithUndelegation() *Op {
 // as baker to the contents list.
 // Source must be defined via WithSource() before calling this function.
 func (o *Op) WithRegisterBaker() *Op {
+	return o.WithDelegationSelf(o.Source)
+}
+
+// WithDelegationSelf adds a delegation from source to itself which registers
+// source as baker (or re-activates a deactivated baker) to the contents list.
+// This is the standard way of becoming a baker. Source also becomes the
+// operation source when none is defined yet.
+func (o *Op) WithDelegationSelf(source tezos.Address) *Op {
+	if !o.Source.IsValid() {
+		o.Source = source
+	}
 	o.Contents = append(o.Contents, &Delegation{
 		Manager: Manager{
-			Source:  o.Source,
+			Source:  source,
 			Counter: 0,
 		},
-		Delegate: o.Source,
+		Delegate: source,
 	})
 	return o
 }
@@ -306,6 +339,56 @@ func (o *Op) WithRegisterConstant(value micheline.Prim) *Op {
 	return o
 }
 
+// WithBallot adds a ballot on proposal in voting period to the contents list.
+// Source must be defined via WithSource() before calling this function.
+func (o *Op) WithBallot(period int32, proposal tezos.ProtocolHash, vote tezos.BallotVote) *Op {
+	o.Contents = append(o.Contents, &Ballot{
+		Source:   o.Source,
+		Period:   period,
+		Proposal: proposal,
+		Ballot:   vote,
+	})
+	return o
+}
+
+// WithProposals adds a proposals operation that submits or upvotes protocol
+// proposals in voting period to the contents list.
+// Source must be defined via WithSource() before calling this function.
+func (o *Op) WithProposals(period int32, proposals []tezos.ProtocolHash) *Op {
+	o.Contents = append(o.Contents, &Proposals{
+		Source:    o.Source,
+		Period:    period,
+		Proposals: proposals,
+	})
+	return o
+}
+
+// WithSeedNonceRevelation adds a seed nonce revelation for the nonce committed
+// in the block at level to the contents list. Seed nonce revelations are
+// anonymous operations and cannot be batched with manager operations.
+func (o *Op) WithSeedNonceRevelation(level int32, nonce [32]byte) *Op {
+	o.Contents = append(o.Contents, &SeedNonceRevelation{
+		Level: level,
+		Nonce: tezos.HexBytes(nonce[:]),
+	})
+	return o
+}
+
+// WithPreendorsement adds a Tenderbake preendorsement for the block proposal
+// at level and round with the given payload hash to the contents list. Like
+// endorsements, preendorsements are signed with a chain id dependent watermark,
+// so a chain id must be set via WithChainId() before signing. The branch should
+// refer to the predecessor of the block at level.
+func (o *Op) WithPreendorsement(slot int16, level, round int32, payload tezos.PayloadHash) *Op {
+	o.Contents = append(o.Contents, &TenderbakePreendorsement{
+		Slot:             slot,
+		Level:            level,
+		Round:            round,
+		BlockPayloadHash: payload,
+	})
+	return o
+}
+
 // WithTTL sets a time-to-live for the operation in number of blocks. This may be
 // used as a convenience method instead of setting a branch directly, but requires
 // to use an autocomplete handler, wallet or custom function that fetches the hash
@@ -395,6 +478,119 @@ func (o *Op) WithMinFee() *Op {
 	return o
 }
 
+// MinimalFee returns the minimal total fee in mutez at which a node's mempool
+// accepts this operation under the fee filter settings in params. Gas is taken
+// from the operation's current gas limits, so call it after simulation. A
+// missing signature is accounted for. Nil params use the operation's params.
+func (o *Op) MinimalFee(params *tezos.Params) int64 {
+	p := params
+	if p == nil {
+		p = o.Params
+	}
+	if p == nil {
+		p = tezos.DefaultParams
+	}
+	buf := bytes.NewBuffer(nil)
+	buf.Write(o.Branch.Bytes())
+	for _, v := range o.Contents {
+		_ = v.EncodeBuffer(buf, p)
+	}
+	sz := int64(buf.Len())
+	if o.Signature.IsValid() {
+		sz += int64(len(o.Signature.Data))
+		for _, sig := range o.Signatures {
+			sz += int64(len(sig.Data))
+		}
+	} else {
+		sz += 64
+	}
+	return p.DefaultFees().MinFee(sz, o.Limits().GasLimit)
+}
+
+// Validate checks operation ordering, the chain id of consensus operations
+// and validates all contents that implement a Validate method. Contents that
+// depend on protocol constants are validated against the operation's params.
+func (o Op) Validate() error {
+	for i, v := range o.Contents {
+		var err error
+		switch c := v.(type) {
+		case interface{ Validate() error }:
+			err = c.Validate()
+		case interface{ Validate(*tezos.Params) error }:
+			err = c.Validate(o.Params)
+		}
+		if err != nil {
+			return fmt.Errorf("%w (position %d)", err, i)
+		}
+	}
+	if err := o.ValidateChainId(); err != nil {
+		return err
+	}
+	return o.ValidateOrdering()
+}
+
+// RequiresChainId returns true when the operation contains consensus content
+// whose signature commits to a chain id. Use WithChainId to set it before
+// signing such operations.
+func (o Op) RequiresChainId() bool {
+	for _, v := range o.Contents {
+		switch v.Kind() {
+		case tezos.OpTypeEndorsement, tezos.OpTypeEndorsementWithSlot, tezos.OpTypePreendorsement:
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateChainId checks that the operation chain id matches the chain id of
+// the operation's params when both are known.
+func (o Op) ValidateChainId() error {
+	if o.ChainId == nil {
+		return nil
+	}
+	if o.Params != nil && o.Params.ChainId.IsValid() && !o.Params.ChainId.Equal(*o.ChainId) {
+		return fmt.Errorf("tezos: chain id %s does not match network %s", o.ChainId, o.Params.ChainId)
+	}
+	return nil
+}
+
+// ValidateOrdering checks that manager operations are not mixed with other
+// operation kinds, that a reveal precedes all other manager operations from
+// the same source and that counters per source are strictly increasing.
+// Unset (zero) counters and sources are ignored, so the check can run before
+// and after auto-completion.
+func (o Op) ValidateOrdering() error {
+	var nManager int
+	seen := make(map[tezos.Address]int)       // source -> number of ops
+	counters := make(map[tezos.Address]int64) // source -> last counter
+	for i, v := range o.Contents {
+		c := v.GetCounter()
+		if c < 0 {
+			continue
+		}
+		nManager++
+		var src tezos.Address
+		if m, ok := v.(interface{ GetSource() tezos.Address }); ok {
+			src = m.GetSource()
+		}
+		if v.Kind() == tezos.OpTypeReveal && seen[src] > 0 {
+			return fmt.Errorf("tezos: reveal at position %d must precede other manager operations from %s", i, src)
+		}
+		seen[src]++
+		if c == 0 {
+			continue
+		}
+		if last, ok := counters[src]; ok && c <= last {
+			return fmt.Errorf("tezos: counter %d at position %d must be greater than %d", c, i, last)
+		}
+		counters[src] = c
+	}
+	if nManager > 0 && nManager < len(o.Contents) {
+		return fmt.Errorf("tezos: manager operations cannot be batched with other operation kinds")
+	}
+	return nil
+}
+
 // Limits returns the sum of all limits (fee, gas, storage limit) currently
 // set for all contained operations.
 func (o Op) Limits() tezos.Limits {
@@ -427,6 +623,9 @@ func (o *Op) Bytes() []byte {
 	default:
 		if o.Signature.IsValid() {
 			buf.Write(o.Signature.Data) // raw, without type (!)
+			for _, sig := range o.Signatures {
+				buf.Write(sig.Data)
+			}
 		}
 	}
 	return buf.Bytes()
@@ -484,9 +683,25 @@ func (o *Op) WithSignature(sig tezos.Signature) *Op {
 	return o
 }
 
+// WithSignatures adds a list of externally created signatures to operations
+// that require more than one signature. The first signature becomes the
+// operation's primary Signature, all others are appended in order after it
+// in the binary encoding. Use DecodeSignedOp to decode such operations.
+// No signature validation is performed.
+func (o *Op) WithSignatures(sigs []tezos.Signature) *Op {
+	o.Signature = tezos.InvalidSignature
+	o.Signatures = nil
+	if len(sigs) > 0 {
+		o.Signature = sigs[0]
+		o.Signatures = append([]tezos.Signature{}, sigs[1:]...)
+	}
+	return o
+}
+
 // Sign signs the operation using provided private key. If a valid signature
 // already exists this function is a noop. Fails when either branch or contents
-// are empty.
+// are empty, when a consensus operation lacks a chain id or when the chain id
+// does not match the operation's params.
 func (o *Op) Sign(key tezos.PrivateKey) error {
 	if !o.Branch.IsValid() {
 		return fmt.Errorf("tezos: missing branch")
@@ -494,6 +709,12 @@ func (o *Op) Sign(key tezos.PrivateKey) error {
 	if len(o.Contents) == 0 {
 		return fmt.Errorf("tezos: empty operation contents")
 	}
+	if o.ChainId == nil && o.RequiresChainId() {
+		return fmt.Errorf("tezos: missing chain id for %s operation", o.Contents[0].Kind())
+	}
+	if err := o.ValidateChainId(); err != nil {
+		return err
+	}
 	sig, err := key.Sign(o.Digest())
 	if err != nil {
 		return err
@@ -510,9 +731,43 @@ func (o *Op) Hash() (h tezos.OpHash) {
 	return
 }
 
+// InjectCurl returns a curl command that injects the signed operation into
+// the node at nodeURL. Use it to reproduce and debug injection failures
+// outside of the application.
+func (o *Op) InjectCurl(nodeURL string) string {
+	return fmt.Sprintf("curl -X POST -H 'Content-Type: application/json' -d '\"%x\"' '%s/injection/operation'",
+		o.Bytes(), strings.TrimRight(nodeURL, "/"))
+}
+
+// ContractAddress returns the predicted address of the index-th explicit
+// origination in this operation group. The operation must be signed for its
+// hash to be final.
+//
+// The protocol numbers all originations of a group in execution order,
+// including contracts created internally (e.g. by CREATE_CONTRACT in a called
+// contract). Such internal originations are not covered here and shift the
+// index of every later origination. Use tezos.ComputeContractAddress with the
+// execution order index in this case.
+func (o *Op) ContractAddress(index int) (tezos.Address, error) {
+	if !o.Signature.IsValid() {
+		return tezos.Address{}, fmt.Errorf("tezos: operation must be signed")
+	}
+	var n int
+	for _, v := range o.Contents {
+		if v.Kind() == tezos.OpTypeOrigination {
+			n++
+		}
+	}
+	if index < 0 || index >= n {
+		return tezos.Address{}, fmt.Errorf("tezos: origination index %d out of range [0,%d)", index, n)
+	}
+	return tezos.ComputeContractAddress(o.Hash(), index), nil
+}
+
 // MarshalJSON conditionally marshals the JSON format of the operation with checks
 // for required fields. Omits signature for unsigned ops so that the encoding is
-// compatible with remote forging.
+// compatible with remote forging. Additional Signatures are only available in
+// binary encoding since the node's JSON schema knows a single signature.
 func (o *Op) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
@@ -543,9 +798,50 @@ func (o *Op) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DecodeOptions limits the size of Micheline values accepted when decoding
+// binary operations. Operations may come from untrusted sources like the
+// mempool, so by default decoding fails on pathologically nested or huge
+// values. Set to micheline.DecodeOptions{} to disable all limits.
+var DecodeOptions = micheline.DefaultDecodeOptions
+
 // DecodeOp decodes an operation from its binary representation. The encoded
-// data may or may not contain a signature.
+// data may or may not end with a single signature. Use DecodeSignedOp to decode
+// operations carrying more than one signature. Micheline values are checked
+// against DecodeOptions.
 func DecodeOp(data []byte) (*Op, error) {
+	return decodeOp(data, tezos.DefaultParams, -1)
+}
+
+// DecodeSignedOp decodes an operation from its binary representation that ends
+// with exactly n signatures like produced by Bytes() after WithSignatures. The
+// first signature becomes the operation's primary Signature. Micheline values
+// are checked against DecodeOptions.
+func DecodeSignedOp(data []byte, n int) (*Op, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("tezos: invalid signature count %d", n)
+	}
+	return decodeOp(data, tezos.DefaultParams, n)
+}
+
+// opSignatureSize is the size of a signature in binary operation encoding.
+// All signature kinds use 64 bytes on the wire, the protocol carries extra
+// bytes of larger BLS signatures in a separate signature prefix content.
+const opSignatureSize = 64
+
+// decodeOp decodes an operation followed by nsig signatures. When nsig is
+// negative the operation may end with a single optional signature which is
+// detected by a tail of signature size that does not decode as operation.
+func decodeOp(data []byte, p *tezos.Params, nsig int) (*Op, error) {
+	// split off known signatures
+	var sigs []byte
+	if nsig > 0 {
+		n := len(data) - nsig*opSignatureSize
+		if n < 0 {
+			return nil, io.ErrShortBuffer
+		}
+		data, sigs = data[:n], data[n:]
+	}
+
 	// check for shortest message
 	if len(data) < 32+5 {
 		return nil, io.ErrShortBuffer
@@ -555,110 +851,45 @@ func DecodeOp(data []byte) (*Op, error) {
 	buf := bytes.NewBuffer(data)
 	o := &Op{
 		Contents: make([]Operation, 0),
-		Params:   tezos.DefaultParams,
+		Params:   p,
 	}
 	if err := o.Branch.UnmarshalBinary(buf.Next(32)); err != nil {
 		return nil, err
 	}
 	for buf.Len() > 0 {
-		var op Operation
 		tag, _ := buf.ReadByte()
 		buf.UnreadByte()
-		switch tezos.ParseOpTag(tag) {
-		case tezos.OpTypeEndorsement:
-			if o.Params.OperationTagsVersion < 2 {
-				op = new(Endorsement)
-			} else {
-				op = new(TenderbakeEndorsement)
-			}
-		case tezos.OpTypePreendorsement:
-			op = new(TenderbakePreendorsement)
-		case tezos.OpTypeEndorsementWithSlot:
-			op = new(EndorsementWithSlot)
-		case tezos.OpTypeSeedNonceRevelation:
-			op = new(SeedNonceRevelation)
-		case tezos.OpTypeDoubleEndorsementEvidence:
-			if o.Params.OperationTagsVersion < 2 {
-				op = new(DoubleEndorsementEvidence)
-			} else {
-				op = new(TenderbakeDoubleEndorsementEvidence)
-			}
-		case tezos.OpTypeDoublePreendorsementEvidence:
-			op = new(TenderbakeDoublePreendorsementEvidence)
-		case tezos.OpTypeDoubleBakingEvidence:
-			op = new(DoubleBakingEvidence)
-		case tezos.OpTypeActivateAccount:
-			op = new(ActivateAccount)
-		case tezos.OpTypeProposals:
-			op = new(Proposals)
-		case tezos.OpTypeBallot:
-			op = new(Ballot)
-		case tezos.OpTypeReveal:
-			op = new(Reveal)
-		case tezos.OpTypeTransaction:
-			op = new(Transaction)
-		case tezos.OpTypeOrigination:
-			op = new(Origination)
-		case tezos.OpTypeDelegation:
-			op = new(Delegation)
-		case tezos.OpTypeFailingNoop:
-			op = new(FailingNoop)
-		case tezos.OpTypeRegisterConstant:
-			op = new(RegisterGlobalConstant)
-		case tezos.OpTypeSetDepositsLimit:
-			op = new(SetDepositsLimit)
-		case tezos.OpTypeTransferTicket:
-			op = new(TransferTicket)
-		case tezos.OpTypeVdfRevelation:
-			op = new(VdfRevelation)
-		case tezos.OpTypeIncreasePaidStorage:
-			op = new(IncreasePaidStorage)
-		case tezos.OpTypeDrainDelegate:
-			op = new(DrainDelegate)
-		case tezos.OpTypeUpdateConsensusKey:
-			op = new(UpdateConsensusKey)
-		case tezos.OpTypeSmartRollupOriginate:
-			op = new(SmartRollupOriginate)
-		case tezos.OpTypeSmartRollupAddMessages:
-			op = new(SmartRollupAddMessages)
-		case tezos.OpTypeSmartRollupCement:
-			op = new(SmartRollupCement)
-		case tezos.OpTypeSmartRollupPublish:
-			op = new(SmartRollupPublish)
-		// TODO
-		// case tezos.OpTypeSmartRollupRefute:
-		// 	op = new(SmartRollupRefute)
-		case tezos.OpTypeSmartRollupTimeout:
-			op = new(SmartRollupTimeout)
-		case tezos.OpTypeSmartRollupExecuteOutboxMessage:
-			op = new(SmartRollupExecuteOutboxMessage)
-		case tezos.OpTypeSmartRollupRecoverBond:
-			op = new(SmartRollupRecoverBond)
-		case tezos.OpTypeDalAttestation:
-			op = new(DalAttestation)
-		case tezos.OpTypeDalPublishSlotHeader:
-			op = new(DalPublishSlotHeader)
-
-		default:
-			// stop if rest looks like a signature
-			// FIXME: BLS sigs are 96 bytes, but accepting this here will
-			// collide with detecting valid operation types in a batch
-			if buf.Len() == 64 {
+		typ := tezos.ParseOpTag(tag)
+		op := newOperation(typ, o.Params.OperationTagsVersion)
+		if op == nil {
+			// stop at an optional signature, but only after at least
+			// one operation was decoded
+			if nsig < 0 && len(o.Contents) > 0 && buf.Len() == opSignatureSize {
+				sigs = buf.Next(opSignatureSize)
 				break
 			}
 			return nil, fmt.Errorf("tezos: unsupported operation tag %d", tag)
 		}
-		if err := op.DecodeBuffer(buf, tezos.DefaultParams); err != nil {
+		if err := op.DecodeBuffer(buf, o.Params); err != nil {
 			return nil, err
 		}
 		o.Contents = append(o.Contents, op)
 	}
 
-	if buf.Len() > 0 {
-		// FIXME: BLS sigs are 96 byte
-		if err := o.Signature.UnmarshalBinary(buf.Next(64)); err != nil {
+	// each operation in a group is signed at most once
+	if n := len(sigs) / opSignatureSize; n > len(o.Contents) {
+		return nil, fmt.Errorf("tezos: %d signatures for %d operations", n, len(o.Contents))
+	}
+	for i := 0; i < len(sigs); i += opSignatureSize {
+		var sig tezos.Signature
+		if err := sig.UnmarshalBinary(sigs[i : i+opSignatureSize]); err != nil {
 			return nil, err
 		}
+		if i == 0 {
+			o.Signature = sig
+		} else {
+			o.Signatures = append(o.Signatures, sig)
+		}
 	}
 	return o, nil
 }
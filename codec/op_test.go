@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -535,3 +536,988 @@ func TestOp(t *testing.T) {
 		}
 	}
 }
+
+func TestOpSmartRollupRefuteBinary(t *testing.T) {
+	// batch of a refutation start and a move with inbox proof, assembled
+	// field by field following the Octez binary encoding
+	sig := hex.EncodeToString(tezos.MustParseSignature("sigqgQgW5qQCsuHP5HhMhAYR2HjcChUE7zAczsyCdF681rfZXpxnXFHu3E6ycmz4pQahjvu3VLfa7FMCxZXmiMiuZFQS4MHy").Data)
+	data := asHex("d21acd0569ff8e03cd564fdc15baae8e436b141510f4ca966bdadfe092904359" + // branch
+		// start
+		"cc" + // tag 204
+		"0002298c03ed7d454a101eb7022bc95f7e5f41ac78" + // source
+		"e807" + "0a" + "f02e" + "00" + // fee, counter, gas, storage
+		"6b6209e8037138491d8d5d8ee340000d51b91581" + // rollup
+		"005db799bf9b0dc319ba1cf21ab01461a9639043ca" + // opponent
+		"00" + strings.Repeat("aa", 32) + strings.Repeat("cc", 32) + // start with commitments
+		// move
+		"cc" +
+		"0002298c03ed7d454a101eb7022bc95f7e5f41ac78" +
+		"e807" + "0b" + "f02e" + "00" +
+		"6b6209e8037138491d8d5d8ee340000d51b91581" +
+		"005db799bf9b0dc319ba1cf21ab01461a9639043ca" +
+		"01" + "05" + // move, choice
+		"01" + "00000003" + "010203" + // proof, pvm_step
+		"ff" + "00" + "0000002a" + "07" + "00000003" + "040506" + // inbox proof
+		sig)
+
+	o, err := DecodeOp(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Contents) != 2 {
+		t.Fatalf("unexpected contents len %d", len(o.Contents))
+	}
+	start, ok := o.Contents[0].(*SmartRollupRefute)
+	if !ok || start.Refutation.Kind != SmartRollupRefutationStart {
+		t.Fatalf("unexpected first content %#v", o.Contents[0])
+	}
+	if start.Fee != 1000 || start.Counter != 10 || start.GasLimit != 6000 ||
+		!start.Rollup.Equal(tezos.MustParseAddress("sr1Fq8fPi2NjhWUXtcXBggbL6zFjZctGkmso")) ||
+		!start.Opponent.Equal(tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")) ||
+		start.Refutation.PlayerHash[0] != 0xaa || start.Refutation.OpponentHash[0] != 0xcc {
+		t.Errorf("start mismatch %#v", start)
+	}
+	move, ok := o.Contents[1].(*SmartRollupRefute)
+	if !ok || move.Refutation.Kind != SmartRollupRefutationMove || move.Refutation.Step.Proof == nil {
+		t.Fatalf("unexpected second content %#v", o.Contents[1])
+	}
+	proof := move.Refutation.Step.Proof
+	if move.Refutation.Choice.Int64() != 5 || !bytes.Equal(proof.PvmStep, []byte{1, 2, 3}) ||
+		proof.Kind() != SmartRollupInputProofInbox || proof.InputProof.Level != 42 ||
+		proof.InputProof.Counter.Int64() != 7 || !bytes.Equal(proof.InputProof.Proof, []byte{4, 5, 6}) {
+		t.Errorf("move mismatch %#v", proof)
+	}
+	if !bytes.Equal(o.Signature.Data, asHex(sig)) || len(o.Signatures) != 0 {
+		t.Errorf("signature mismatch")
+	}
+	if !bytes.Equal(o.Bytes(), data) {
+		t.Errorf("re-encoding mismatch\nhave %x\nwant %x", o.Bytes(), data)
+	}
+}
+
+func TestOpMultiSignature(t *testing.T) {
+	sigs := []tezos.Signature{
+		tezos.MustParseSignature("sigqgQgW5qQCsuHP5HhMhAYR2HjcChUE7zAczsyCdF681rfZXpxnXFHu3E6ycmz4pQahjvu3VLfa7FMCxZXmiMiuZFQS4MHy"),
+		tezos.MustParseSignature("sigYec9pbutMj4sxHxGhmQeoU62K96Xbdr8MZJE4XG7PkcKmUGsQMKwpegwdubccUXshdCHukUxDodvaCjpQQaDjagW43YeW"),
+		tezos.MustParseSignature("sigotZGfNkiFwpditQfPjQ6DpN5QnAo6gjFjAMTdU9ATCcoUyugBtw2p6dqJmvNSETzqN2hTaKJytZJh2abMJ7S49AhX8n13"),
+	}
+	// a group of three attestations, each may carry one signature
+	branch := "2f50673bab6b20dfb0a88ca93b4a0c72a34c807af5dffbece2cba3d2b509835f"
+	content := "14006000000002000000041f1ebb39759cc957216f88fb4d005abc206fb00a53f8d57ac01be00c084cba97"
+	unsigned := asHex(branch + strings.Repeat(content, 3))
+
+	for n := 1; n <= len(sigs); n++ {
+		o, err := DecodeOp(unsigned)
+		if err != nil {
+			t.Fatalf("decode unsigned: %v", err)
+		}
+		o.WithSignatures(sigs[:n])
+
+		// binary encode
+		buf := o.Bytes()
+		if exp := len(unsigned) + n*64; len(buf) != exp {
+			t.Fatalf("%d sigs: encoded length mismatch: have %d want %d", n, len(buf), exp)
+		}
+		for i, sig := range sigs[:n] {
+			if !bytes.Equal(buf[len(unsigned)+i*64:len(unsigned)+(i+1)*64], sig.Data) {
+				t.Errorf("%d sigs: signature %d not encoded", n, i)
+			}
+		}
+
+		// binary decode with known signature count
+		o2, err := DecodeSignedOp(buf, n)
+		if err != nil {
+			t.Fatalf("%d sigs: decode failed: %v", n, err)
+		}
+		if len(o2.Contents) != 3 {
+			t.Errorf("%d sigs: unexpected contents len %d", n, len(o2.Contents))
+		}
+		if !bytes.Equal(o2.Signature.Data, sigs[0].Data) {
+			t.Errorf("%d sigs: primary signature mismatch", n)
+		}
+		if len(o2.Signatures) != n-1 {
+			t.Fatalf("%d sigs: unexpected signatures len %d", n, len(o2.Signatures))
+		}
+		for i, sig := range o2.Signatures {
+			if !bytes.Equal(sig.Data, sigs[i+1].Data) {
+				t.Errorf("%d sigs: signature %d mismatch", n, i+1)
+			}
+		}
+		if !bytes.Equal(o2.Bytes(), buf) {
+			t.Errorf("%d sigs: re-encoding mismatch", n)
+		}
+
+		// without a count only a single signature is detected
+		if _, err := DecodeOp(buf); (err == nil) != (n == 1) {
+			t.Errorf("%d sigs: unexpected DecodeOp result %v", n, err)
+		}
+
+		// JSON only knows the primary signature
+		js, err := json.Marshal(o2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(js, []byte(`"signatures"`)) {
+			t.Errorf("%d sigs: unexpected signatures in JSON %s", n, js)
+		}
+	}
+
+	// more signatures than operations
+	single := asHex(branch + content)
+	buf := append(append(append([]byte{}, single...), sigs[0].Data...), sigs[1].Data...)
+	if _, err := DecodeSignedOp(buf, 2); err == nil {
+		t.Errorf("expected error for too many signatures")
+	}
+
+	// signature count must match the encoding
+	if o, err := DecodeSignedOp(unsigned, 0); err != nil || o.Signature.IsValid() {
+		t.Errorf("unexpected result for unsigned op: %v", err)
+	}
+	buf = append(append([]byte{}, unsigned...), sigs[0].Data...)
+	if _, err := DecodeSignedOp(buf, 0); err == nil {
+		t.Errorf("expected error for unexpected signature")
+	}
+	if _, err := DecodeSignedOp(buf, 2); err == nil {
+		t.Errorf("expected error for missing signature")
+	}
+
+	// a signature-sized tail without any operation is not a signature
+	buf = append(asHex(branch), sigs[0].Data...)
+	if _, err := DecodeOp(buf); err == nil {
+		t.Errorf("expected error for signature without operations")
+	}
+}
+
+func TestDoubleEndorsementNormalize(t *testing.T) {
+	branch := tezos.MustParseBlockHash("BLockGenesisGenesisGenesisGenesisGenesisf79b5d1CoW2")
+	sig := tezos.MustParseSignature("sigqgQgW5qQCsuHP5HhMhAYR2HjcChUE7zAczsyCdF681rfZXpxnXFHu3E6ycmz4pQahjvu3VLfa7FMCxZXmiMiuZFQS4MHy")
+	e1 := TenderbakeInlinedEndorsement{
+		Branch:      branch,
+		Endorsement: TenderbakeEndorsement{Slot: 1, Level: 100, Round: 0},
+		Signature:   sig,
+	}
+	e2 := e1
+	e2.Endorsement.BlockPayloadHash[0] = 1
+
+	// inlined hash must match the hash of the original signed operation
+	op := NewOp().WithBranch(branch).WithContents(&e1.Endorsement).WithSignature(sig)
+	if h := e1.Hash(nil); !h.Equal(op.Hash()) {
+		t.Errorf("inlined hash mismatch: have %s want %s", h, op.Hash())
+	}
+
+	h1, h2 := e1.Hash(nil), e2.Hash(nil)
+	for _, ev := range []*TenderbakeDoubleEndorsementEvidence{
+		{Op1: e1, Op2: e2},
+		{Op1: e2, Op2: e1},
+	} {
+		if err := ev.Normalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		a, b := ev.Op1.Hash(nil), ev.Op2.Hash(nil)
+		if bytes.Compare(a[:], b[:]) >= 0 {
+			t.Errorf("not ordered: %s >= %s", a, b)
+		}
+		if !(a.Equal(h1) && b.Equal(h2)) && !(a.Equal(h2) && b.Equal(h1)) {
+			t.Errorf("unexpected operations after normalize")
+		}
+	}
+
+	// same operation twice
+	if err := (&TenderbakeDoubleEndorsementEvidence{Op1: e1, Op2: e1}).Normalize(nil); err == nil {
+		t.Errorf("expected error for identical operations")
+	}
+
+	// level and round mismatch
+	e3 := e2
+	e3.Endorsement.Level++
+	if err := (&TenderbakeDoubleEndorsementEvidence{Op1: e1, Op2: e3}).Normalize(nil); err == nil {
+		t.Errorf("expected error for level mismatch")
+	}
+	e3 = e2
+	e3.Endorsement.Round++
+	if err := (&TenderbakeDoubleEndorsementEvidence{Op1: e1, Op2: e3}).Normalize(nil); err == nil {
+		t.Errorf("expected error for round mismatch")
+	}
+}
+
+func TestOperationTypeForProtocol(t *testing.T) {
+	for _, test := range []struct {
+		Proto tezos.ProtocolHash
+		Type  tezos.OpType
+		Want  Operation
+	}{
+		{tezos.PtHangz2, tezos.OpTypeEndorsement, &Endorsement{}},
+		{tezos.Psithaca, tezos.OpTypeEndorsement, &TenderbakeEndorsement{}},
+		{tezos.PtHangz2, tezos.OpTypeDoubleEndorsementEvidence, &DoubleEndorsementEvidence{}},
+		{tezos.Psithaca, tezos.OpTypeDoubleEndorsementEvidence, &TenderbakeDoubleEndorsementEvidence{}},
+		{tezos.PtHangz2, tezos.OpTypeTransaction, &Transaction{}},
+	} {
+		op, err := OperationTypeForProtocol(test.Type, test.Proto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, want := fmt.Sprintf("%T", op), fmt.Sprintf("%T", test.Want); have != want {
+			t.Errorf("%s %s: have %s want %s", test.Proto, test.Type, have, want)
+		}
+	}
+	if _, err := OperationTypeForProtocol(tezos.OpTypeInvalid, tezos.Psithaca); err == nil {
+		t.Errorf("expected error for invalid type")
+	}
+	if v := NewOpForProtocol(tezos.PtHangz2).Params.OperationTagsVersion; v != 1 {
+		t.Errorf("unexpected tags version %d", v)
+	}
+
+	// unknown protocols use the latest encoding and are not registered globally
+	unknown := tezos.NewProtocolHash(bytes.Repeat([]byte{0xfe}, 32))
+	if v := NewOpForProtocol(unknown).Params.OperationTagsVersion; v != 2 {
+		t.Errorf("unexpected tags version %d for unknown protocol", v)
+	}
+	if _, ok := tezos.Versions[unknown]; ok {
+		t.Errorf("unknown protocol registered in global versions")
+	}
+
+	// decoding uses the protocol's encoding
+	buf := NewOpForProtocol(tezos.PtHangz2).
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithContents(&Endorsement{Level: 100}).
+		Bytes()
+	o, err := DecodeOpForProtocol(buf, tezos.PtHangz2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := o.Contents[0].(*Endorsement); !ok || e.Level != 100 {
+		t.Errorf("unexpected legacy endorsement %#v", o.Contents[0])
+	}
+	if _, err := DecodeOp(buf); err == nil {
+		t.Errorf("expected error decoding legacy endorsement with default params")
+	}
+}
+
+func TestOpMinimalFee(t *testing.T) {
+	op := NewOp().
+		WithBranch(tezos.MustParseBlockHash("BLockGenesisGenesisGenesisGenesisGenesisf79b5d1CoW2")).
+		WithSource(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")).
+		WithTransfer(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"), 1000000)
+	op.Contents[0].WithLimits(tezos.Limits{GasLimit: 1001})
+	sz := int64(len(op.Bytes()) + 64) // unsigned
+
+	// defaults: 100 mutez + 1000 nanotez/byte + 100 nanotez/gas
+	want := (100_000 + sz*1000 + 1001*100 + 999) / 1000
+	if have := op.MinimalFee(nil); have != want {
+		t.Errorf("default fee: have %d want %d", have, want)
+	}
+
+	// custom filter settings
+	p := tezos.DefaultParams.Clone()
+	p.MinimalFees = 0
+	p.MinimalNanotezPerByte = 250
+	p.MinimalNanotezPerGasUnit = 1000
+	want = (100_000 + sz*250 + 1001*1000 + 999) / 1000
+	if have := op.MinimalFee(p); have != want {
+		t.Errorf("custom fee: have %d want %d", have, want)
+	}
+
+	// fee limits do not change size once the fee is set
+	op.Contents[0].WithLimits(tezos.Limits{GasLimit: 1001, Fee: want})
+	if have := op.MinimalFee(p); have < want {
+		t.Errorf("fee decreased after setting limits: have %d want >= %d", have, want)
+	}
+}
+
+func TestRevealEstimatedCost(t *testing.T) {
+	key := tezos.MustParseKey("edpkuQqN9HB3jY1FvDzt15WQDVSHR4vQGd1wv6iqJ73wkrKecRtnXh")
+	reveal := &Reveal{
+		Manager:   Manager{Source: key.Address()},
+		PublicKey: key,
+	}
+	buf := bytes.NewBuffer(nil)
+	_ = reveal.EncodeBuffer(buf, tezos.DefaultParams)
+	want := (100_000 + int64(buf.Len())*1000 + DefaultRevealGasLimit*100 + 999) / 1000
+	cost := reveal.EstimatedCost(nil)
+	if cost.Fee != want || cost.GasUsed != DefaultRevealGasLimit {
+		t.Errorf("default cost: have fee=%d gas=%d want fee=%d gas=%d", cost.Fee, cost.GasUsed, want, DefaultRevealGasLimit)
+	}
+
+	// explicit limits take precedence
+	reveal.WithLimits(tezos.Limits{Fee: 1000, GasLimit: 1100})
+	if cost := reveal.EstimatedCost(nil); cost.Fee != 1000 || cost.GasUsed != 1100 {
+		t.Errorf("limits cost: have fee=%d gas=%d", cost.Fee, cost.GasUsed)
+	}
+}
+
+func TestIncreasePaidStorageCost(t *testing.T) {
+	o := IncreasePaidStorage{
+		Amount:      tezos.NewZ(1000),
+		Destination: tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T"),
+	}
+	if have := o.StorageCost(nil); have.Int64() != 250_000 {
+		t.Errorf("default cost: have %s want 250000", have)
+	}
+	p := tezos.DefaultParams.Clone()
+	p.CostPerByte = 100
+	if have := o.StorageCost(p); have.Int64() != 100_000 {
+		t.Errorf("custom cost: have %s want 100000", have)
+	}
+	if err := o.Validate(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, v := range []IncreasePaidStorage{
+		{Amount: tezos.NewZ(0), Destination: o.Destination},
+		{Amount: tezos.NewZ(tezos.DefaultParams.HardStorageLimitPerOperation + 1), Destination: o.Destination},
+		{Amount: o.Amount, Destination: tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")},
+	} {
+		if err := v.Validate(nil); err == nil {
+			t.Errorf("expected error for amount %s to %s", v.Amount, v.Destination)
+		}
+	}
+
+	// the storage limit is taken from params
+	p.HardStorageLimitPerOperation = 999
+	if err := o.Validate(p); err == nil {
+		t.Errorf("expected error for amount above custom storage limit")
+	}
+	op := NewOp().WithParams(p).WithSource(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"))
+	op.WithContents(&o)
+	if err := op.Validate(); err == nil {
+		t.Errorf("expected op validation against op params")
+	}
+}
+
+func TestOpValidateOrdering(t *testing.T) {
+	src := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	reveal := func() *Reveal {
+		return &Reveal{Manager: Manager{Source: src}}
+	}
+	newOp := func() *Op {
+		return NewOp().WithTransfer(src, 1).WithTransfer(src, 2).WithSource(src)
+	}
+
+	// unset counters and reveal in front are fine
+	op := newOp().WithContentsFront(reveal())
+	if err := op.ValidateOrdering(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// increasing counters are fine
+	for i, v := range op.Contents {
+		v.WithCounter(int64(10 + i))
+	}
+	if err := op.ValidateOrdering(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// duplicate counter
+	op.Contents[2].WithCounter(11)
+	if err := op.ValidateOrdering(); err == nil {
+		t.Errorf("expected counter error")
+	}
+
+	// reveal after transfer
+	op = newOp().WithContents(reveal())
+	if err := op.ValidateOrdering(); err == nil {
+		t.Errorf("expected reveal ordering error")
+	}
+
+	// mixed manager and anonymous ops
+	op = newOp().WithContents(&SeedNonceRevelation{})
+	if err := op.ValidateOrdering(); err == nil {
+		t.Errorf("expected mixed batch error")
+	}
+}
+
+func TestOpWithOriginationScript(t *testing.T) {
+	src := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	baker := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	script := micheline.NewScript()
+	err := json.Unmarshal([]byte(`{"code":[{"prim":"parameter","args":[{"prim":"unit"}]},`+
+		`{"prim":"storage","args":[{"prim":"pair","args":[{"prim":"nat"},{"prim":"string"}]}]},`+
+		`{"prim":"code","args":[[{"prim":"CDR"},{"prim":"NIL","args":[{"prim":"operation"}]},{"prim":"PAIR"}]]}],`+
+		`"storage":{"prim":"Pair","args":[{"int":"1"},{"string":"hello"}]}}`), script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := NewOp().WithSource(src).WithOriginationScript(script, 5, &baker)
+	orig, ok := op.Contents[0].(*Origination)
+	if !ok {
+		t.Fatalf("unexpected contents %T", op.Contents[0])
+	}
+	if orig.Balance != 5 || !orig.Delegate.Equal(baker) || !orig.Source.Equal(src) {
+		t.Errorf("unexpected origination %#v", orig)
+	}
+	if err := op.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	op = NewOp().WithSource(src).WithOriginationScript(script, 0, nil)
+	if op.Contents[0].(*Origination).Delegate.IsValid() {
+		t.Errorf("expected no delegate")
+	}
+
+	script.Storage = micheline.NewPair(micheline.NewString("x"), micheline.NewInt64(1))
+	op = NewOp().WithSource(src).WithOriginationScript(script, 0, nil)
+	if err := op.Validate(); err == nil {
+		t.Errorf("expected storage type mismatch")
+	}
+}
+
+func TestOpWithDelegationSelf(t *testing.T) {
+	src := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	op := NewOp().WithDelegationSelf(src)
+	if !op.Source.Equal(src) {
+		t.Errorf("op source: want %s, got %s", src, op.Source)
+	}
+	del, ok := op.Contents[0].(*Delegation)
+	if !ok {
+		t.Fatalf("unexpected contents %T", op.Contents[0])
+	}
+	if !del.Source.Equal(src) || !del.Delegate.Equal(src) {
+		t.Errorf("unexpected delegation %#v", del)
+	}
+
+	// same encoding as baker registration
+	reg := NewOp().WithSource(src).WithRegisterBaker()
+	if !bytes.Equal(op.Bytes(), reg.Bytes()) {
+		t.Errorf("encoding mismatch\nself=%x\nreg =%x", op.Bytes(), reg.Bytes())
+	}
+}
+
+func TestOpContractAddress(t *testing.T) {
+	sk := tezos.MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	script := micheline.NewScript()
+	err := json.Unmarshal([]byte(`{"code":[{"prim":"parameter","args":[{"prim":"unit"}]},`+
+		`{"prim":"storage","args":[{"prim":"unit"}]},`+
+		`{"prim":"code","args":[[{"prim":"CDR"},{"prim":"NIL","args":[{"prim":"operation"}]},{"prim":"PAIR"}]]}],`+
+		`"storage":{"prim":"Unit"}}`), script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithSource(sk.Address()).
+		WithTransfer(tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"), 1).
+		WithOriginationScript(script, 0, nil).
+		WithOriginationScript(script, 0, nil)
+	if _, err := op.ContractAddress(0); err == nil {
+		t.Errorf("expected error for unsigned operation")
+	}
+	if err := op.Sign(sk); err != nil {
+		t.Fatal(err)
+	}
+	// transfers do not count, the second origination has index 1
+	for i := 0; i < 2; i++ {
+		got, err := op.ContractAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tezos.ComputeContractAddress(op.Hash(), i); !got.Equal(want) {
+			t.Errorf("index %d: want %s, got %s", i, want, got)
+		}
+	}
+	if _, err := op.ContractAddress(2); err == nil {
+		t.Errorf("expected out of range error")
+	}
+}
+
+func TestSmartRollupCementEncoding(t *testing.T) {
+	commit := tezos.NewSmartRollupCommitHash(bytes.Repeat([]byte{0xaa}, 32))
+	op := &SmartRollupCement{
+		Manager: Manager{
+			Source: tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"),
+		},
+		Rollup:     tezos.MustParseAddress("sr1Fq8fPi2NjhWUXtcXBggbL6zFjZctGkmso"),
+		Commitment: commit,
+	}
+	mumbai := paramsForProtocol(tezos.PtMumbai)
+	nairobi := paramsForProtocol(tezos.PtNairobi)
+
+	pre := bytes.NewBuffer(nil)
+	_ = op.EncodeBuffer(pre, mumbai)
+	post := bytes.NewBuffer(nil)
+	_ = op.EncodeBuffer(post, nairobi)
+	if have, want := pre.Len()-post.Len(), 32; have != want {
+		t.Fatalf("expected commitment only before v017, size diff %d", have)
+	}
+
+	var dec SmartRollupCement
+	if err := dec.DecodeBuffer(pre, mumbai); err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Commitment.Equal(commit) || !dec.Rollup.Equal(op.Rollup) {
+		t.Errorf("v016 decode mismatch: %#v", dec)
+	}
+	dec = SmartRollupCement{}
+	if err := dec.DecodeBuffer(post, nairobi); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Commitment.IsValid() || !dec.Rollup.Equal(op.Rollup) {
+		t.Errorf("v017 decode mismatch: %#v", dec)
+	}
+}
+
+func TestSmartRollupRefuteEncoding(t *testing.T) {
+	state := tezos.NewSmartRollupStateHash(bytes.Repeat([]byte{0xbb}, 32))
+	base := SmartRollupRefute{
+		Manager: Manager{
+			Source: tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"),
+		},
+		Rollup:   tezos.MustParseAddress("sr1Fq8fPi2NjhWUXtcXBggbL6zFjZctGkmso"),
+		Opponent: tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"),
+	}
+	start := base
+	start.Refutation = SmartRollupRefutation{
+		Kind:         SmartRollupRefutationStart,
+		PlayerHash:   tezos.NewSmartRollupCommitHash(bytes.Repeat([]byte{0xaa}, 32)),
+		OpponentHash: tezos.NewSmartRollupCommitHash(bytes.Repeat([]byte{0xcc}, 32)),
+	}
+	dissection := base
+	dissection.Refutation = SmartRollupRefutation{
+		Kind:   SmartRollupRefutationMove,
+		Choice: tezos.NewZ(1000),
+		Step: SmartRollupRefuteStep{Ticks: []SmartRollupTick{
+			{State: state, Tick: tezos.NewZ(1000)},
+			{Tick: tezos.NewZ(2000)},
+		}},
+	}
+	inbox := base
+	inbox.Refutation = SmartRollupRefutation{
+		Kind:   SmartRollupRefutationMove,
+		Choice: tezos.NewZ(5),
+		Step: SmartRollupRefuteStep{Proof: &SmartRollupProof{
+			PvmStep: []byte{1, 2, 3},
+			InputProof: SmartRollupInputProof{
+				Kind:    SmartRollupInputProofInbox,
+				Level:   42,
+				Counter: tezos.NewZ(7),
+				Proof:   []byte{4, 5, 6},
+			},
+		}},
+	}
+	reveal := inbox
+	reveal.Refutation.Step.Proof = &SmartRollupProof{
+		PvmStep: []byte{1, 2, 3},
+		InputProof: SmartRollupInputProof{
+			Kind: SmartRollupInputProofReveal,
+			Reveal: SmartRollupRevealProof{
+				Kind:    SmartRollupRevealProofRawData,
+				RawData: []byte("preimage"),
+			},
+		},
+	}
+
+	for _, op := range []SmartRollupRefute{start, dissection, inbox, reveal} {
+		if err := op.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		buf, err := op.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dec SmartRollupRefute
+		if err := dec.UnmarshalBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+		have, _ := dec.MarshalJSON()
+		want, _ := op.MarshalJSON()
+		if !bytes.Equal(have, want) {
+			t.Errorf("roundtrip mismatch\nhave %s\nwant %s", have, want)
+		}
+		if !json.Valid(want) {
+			t.Errorf("invalid json %s", want)
+		}
+	}
+
+	if k := reveal.Refutation.Step.Proof.Kind(); k != SmartRollupInputProofReveal {
+		t.Errorf("unexpected proof kind %q", k)
+	}
+	reveal.Refutation.Step.Proof.InputProof.Reveal.RawData = make([]byte, MaxSmartRollupRawDataSize+1)
+	if err := reveal.Validate(); err == nil {
+		t.Errorf("expected error for oversized raw data")
+	}
+	if _, err := reveal.MarshalBinary(); err == nil {
+		t.Errorf("expected encoding error for oversized raw data")
+	}
+}
+
+func TestVdfRevelation(t *testing.T) {
+	result := bytes.Repeat([]byte{0x01}, VdfSolutionPartSize)
+	proof := bytes.Repeat([]byte{0x02}, VdfSolutionPartSize)
+	op, err := NewVdfRevelation(result, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := op.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec VdfRevelation
+	if err := dec.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.Solution, op.Solution) {
+		t.Errorf("solution mismatch after roundtrip")
+	}
+
+	// node JSON encodes the solution as two hex strings
+	js, _ := op.MarshalJSON()
+	var v struct {
+		Solution []tezos.HexBytes `json:"solution"`
+	}
+	if err := json.Unmarshal(js, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Solution) != 2 || !bytes.Equal(v.Solution[0], result) || !bytes.Equal(v.Solution[1], proof) {
+		t.Errorf("unexpected json solution %s", js)
+	}
+
+	// JSON roundtrip
+	var dec2 VdfRevelation
+	if err := json.Unmarshal(js, &dec2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec2.Solution, op.Solution) {
+		t.Errorf("solution mismatch after json roundtrip")
+	}
+
+	// legacy single hex string format
+	legacy := `{"solution":"` + hex.EncodeToString(op.Solution) + `"}`
+	var dec3 VdfRevelation
+	if err := json.Unmarshal([]byte(legacy), &dec3); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec3.Solution, op.Solution) {
+		t.Errorf("solution mismatch after legacy json decoding")
+	}
+
+	// invalid sizes
+	for _, js := range []string{
+		`{"kind":"vdf_revelation","solution":["` + hex.EncodeToString(result) + `"]}`,
+		`{"kind":"vdf_revelation","solution":["` + hex.EncodeToString(result[:99]) + `","` + hex.EncodeToString(proof) + `"]}`,
+		`{"kind":"vdf_revelation","solution":"` + hex.EncodeToString(result) + `"}`,
+		`{"kind":"seed_nonce_revelation","solution":"` + hex.EncodeToString(op.Solution) + `"}`,
+	} {
+		var d VdfRevelation
+		if err := json.Unmarshal([]byte(js), &d); err == nil {
+			t.Errorf("expected error for %s", js)
+		}
+	}
+	if _, err := (VdfRevelation{Solution: result}).MarshalJSON(); err == nil {
+		t.Errorf("expected marshal error for short solution")
+	}
+
+	if _, err := NewVdfRevelation(result[:99], proof); err == nil {
+		t.Errorf("expected error for short result")
+	}
+}
+
+func TestOpWithSeedNonceRevelation(t *testing.T) {
+	var nonce [32]byte
+	copy(nonce[:], bytes.Repeat([]byte{0xab}, 32))
+	op := NewOp().WithSeedNonceRevelation(4096, nonce)
+	if err := op.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	rev, ok := op.Contents[0].(*SeedNonceRevelation)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	buf, err := rev.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec SeedNonceRevelation
+	if err := dec.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Level != 4096 || !bytes.Equal(dec.Nonce, nonce[:]) {
+		t.Errorf("roundtrip mismatch: level=%d nonce=%s", dec.Level, dec.Nonce)
+	}
+
+	if err := NewOp().WithSeedNonceRevelation(0, nonce).Validate(); err == nil {
+		t.Errorf("expected error for zero level")
+	}
+	if err := NewOp().WithSeedNonceRevelation(4096, [32]byte{}).Validate(); err == nil {
+		t.Errorf("expected error for empty nonce")
+	}
+}
+
+func TestDecodeOpLimits(t *testing.T) {
+	nested := micheline.Unit
+	for i := 0; i < 2000; i++ {
+		nested = micheline.NewSeq(nested)
+	}
+	op := NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithSource(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")).
+		WithCall(tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T"), micheline.Parameters{
+			Entrypoint: micheline.DEFAULT,
+			Value:      nested,
+		})
+	buf := op.Bytes()
+	if _, err := DecodeOp(buf); err == nil {
+		t.Fatalf("expected decode error for pathologically nested parameters")
+	}
+
+	defer func(opts micheline.DecodeOptions) { DecodeOptions = opts }(DecodeOptions)
+	DecodeOptions = micheline.DecodeOptions{}
+	if _, err := DecodeOp(buf); err != nil {
+		t.Errorf("decode without limits: %v", err)
+	}
+}
+
+func TestOpWithBallotAndProposals(t *testing.T) {
+	src := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	op := NewOp().
+		WithBallot(95, tezos.ProtoV018, tezos.ParseBallotVote("Yay")).
+		WithSource(src)
+	if err := op.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b := op.Contents[0].(*Ballot)
+	if !b.Source.Equal(src) || b.Ballot != tezos.BallotVoteYay {
+		t.Errorf("unexpected ballot %#v", b)
+	}
+	buf, _ := b.MarshalBinary()
+	var dec Ballot
+	if err := dec.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Period != 95 || !dec.Proposal.Equal(tezos.ProtoV018) || dec.Ballot != tezos.BallotVoteYay {
+		t.Errorf("ballot roundtrip mismatch %#v", dec)
+	}
+	if err := NewOp().WithSource(src).WithBallot(95, tezos.ProtoV018, tezos.BallotVoteInvalid).Validate(); err == nil {
+		t.Errorf("expected error for invalid vote")
+	}
+
+	op = NewOp().
+		WithSource(src).
+		WithProposals(94, []tezos.ProtocolHash{tezos.PtNairobi, tezos.ProtoV018})
+	if err := op.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	buf, _ = op.Contents[0].(*Proposals).MarshalBinary()
+	var decp Proposals
+	if err := decp.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	if decp.Period != 94 || len(decp.Proposals) != 2 || !decp.Source.Equal(src) {
+		t.Errorf("proposals roundtrip mismatch %#v", decp)
+	}
+	dup := NewOp().WithSource(src).WithProposals(94, []tezos.ProtocolHash{tezos.PtNairobi, tezos.PtNairobi})
+	if err := dup.Validate(); err == nil {
+		t.Errorf("expected error for duplicate proposals")
+	}
+	if err := NewOp().WithSource(src).WithProposals(94, nil).Validate(); err == nil {
+		t.Errorf("expected error for empty proposals")
+	}
+}
+
+func TestEndorsementWithSlotJSON(t *testing.T) {
+	head := tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")
+	var e EndorsementWithSlot
+	e.Endorsement.Endorsement.Level = 42
+	e.Slot = 7
+	e.SyncBranch(head)
+	if !e.Branch().Equal(head) || e.Level() != 42 || e.Signature().IsValid() {
+		t.Fatalf("unexpected accessor values %s %d %s", e.Branch(), e.Level(), e.Signature())
+	}
+
+	buf, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec EndorsementWithSlot
+	if err := json.Unmarshal(buf, &dec); err != nil {
+		t.Fatalf("unmarshal %s: %v", buf, err)
+	}
+	if !dec.Branch().Equal(head) || dec.Level() != 42 || dec.Slot != 7 || dec.Signature().IsValid() {
+		t.Errorf("roundtrip mismatch %s", buf)
+	}
+
+	// signed inner endorsement
+	sig := tezos.Signature{Type: tezos.SignatureTypeGeneric, Data: bytes.Repeat([]byte{1}, 64)}
+	e.Endorsement.Signature = sig
+	buf, _ = json.Marshal(e)
+	if err := json.Unmarshal(buf, &dec); err != nil {
+		t.Fatalf("unmarshal %s: %v", buf, err)
+	}
+	if !dec.Signature().Equal(sig) {
+		t.Errorf("signature mismatch %s", dec.Signature())
+	}
+
+	if err := json.Unmarshal([]byte(`{"kind":"endorsement","slot":1}`), &dec); err == nil {
+		t.Errorf("expected error for wrong kind")
+	}
+}
+
+func TestOpInjectCurl(t *testing.T) {
+	op := NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithSource(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")).
+		WithTransfer(tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"), 1000)
+	op.WithSignature(tezos.Signature{Type: tezos.SignatureTypeGeneric, Data: bytes.Repeat([]byte{1}, 64)})
+	want := fmt.Sprintf("curl -X POST -H 'Content-Type: application/json' -d '\"%s\"' 'https://node.example/injection/operation'",
+		hex.EncodeToString(op.Bytes()))
+	if have := op.InjectCurl("https://node.example/"); have != want {
+		t.Errorf("have %s\nwant %s", have, want)
+	}
+}
+
+func TestOpChainId(t *testing.T) {
+	key, err := tezos.GenerateKey(tezos.KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")
+	newEndorsement := func() *Op {
+		op := NewOp().WithBranch(branch).WithParams(tezos.GhostnetParams)
+		op.WithContents(&TenderbakeEndorsement{Slot: 1, Level: 100})
+		return op
+	}
+
+	op := newEndorsement()
+	if !op.RequiresChainId() {
+		t.Fatal("expected endorsement to require chain id")
+	}
+	if err := op.Sign(key); err == nil {
+		t.Errorf("expected error when signing without chain id")
+	}
+	if err := op.WithChainId(tezos.Mainnet).Sign(key); err == nil {
+		t.Errorf("expected error when signing with chain id of wrong network")
+	}
+	if err := op.WithChainId(tezos.Mainnet).Validate(); err == nil {
+		t.Errorf("expected validation error with chain id of wrong network")
+	}
+	if err := op.WithChainId(tezos.Ghostnet).Sign(key); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tx := NewOp().
+		WithBranch(branch).
+		WithSource(key.Address()).
+		WithTransfer(tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"), 1000)
+	if tx.RequiresChainId() {
+		t.Errorf("transaction must not require chain id")
+	}
+	if err := tx.Sign(key); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOpWithPreendorsement(t *testing.T) {
+	key, err := tezos.GenerateKey(tezos.KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")
+	payload := tezos.NewPayloadHash(bytes.Repeat([]byte{0xaa}, 32))
+	op := NewOp().
+		WithParams(tezos.GhostnetParams).
+		WithBranch(branch).
+		WithPreendorsement(3, 100, 1, payload)
+	pe, ok := op.Contents[0].(*TenderbakePreendorsement)
+	if !ok {
+		t.Fatalf("unexpected contents %T", op.Contents[0])
+	}
+	if pe.Slot != 3 || pe.Level != 100 || pe.Round != 1 || !pe.BlockPayloadHash.Equal(payload) {
+		t.Errorf("unexpected preendorsement %#v", pe)
+	}
+	if !op.RequiresChainId() {
+		t.Fatal("expected preendorsement to require chain id")
+	}
+	if err := op.Sign(key); err == nil {
+		t.Errorf("expected error when signing without chain id")
+	}
+	op.WithChainId(tezos.Ghostnet)
+	buf := op.WatermarkedBytes()
+	if buf[0] != TenderbakePreendorsementWatermark || !bytes.Equal(buf[1:5], tezos.Ghostnet.Bytes()) {
+		t.Errorf("unexpected watermark %x", buf[:5])
+	}
+	if err := op.Sign(key); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSmartRollupAddMessagesSplit(t *testing.T) {
+	src := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	o := SmartRollupAddMessages{Manager: Manager{Source: src}}
+	limit := tezos.DefaultParams.MaxOperationDataLength
+	opSize := func(op *SmartRollupAddMessages) int {
+		buf, _ := op.MarshalBinary()
+		return 32 + 64 + len(buf)
+	}
+	base := opSize(&o)
+
+	// fill the first op exactly up to the limit
+	free := limit - base
+	for free >= SmartRollupMaxMessageSize+4 {
+		o.Messages = append(o.Messages, bytes.Repeat([]byte{1}, SmartRollupMaxMessageSize))
+		free -= SmartRollupMaxMessageSize + 4
+	}
+	last := bytes.Repeat([]byte{2}, free-4)
+	ops, err := o.AddMessage(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 || opSize(ops[0]) != limit {
+		t.Fatalf("expected single op at limit, got %d ops", len(ops))
+	}
+	if n := len(o.Messages); n != len(ops[0].Messages)-1 {
+		t.Errorf("receiver modified, have %d messages", n)
+	}
+
+	// one more byte must spill into a second op
+	o.Messages = ops[0].Messages
+	ops, err = o.AddMessage([]byte{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("expected 2 ops, got %d", len(ops))
+	}
+	if opSize(ops[0]) != limit || len(ops[1].Messages) != 1 || !ops[1].Source.Equal(src) {
+		t.Errorf("unexpected split %d %d", opSize(ops[0]), len(ops[1].Messages))
+	}
+
+	if _, err := o.AddMessage(make([]byte, SmartRollupMaxMessageSize+1)); err == nil {
+		t.Errorf("expected error for oversized message")
+	}
+}
+
+func TestSmartRollupAddMessagesDecode(t *testing.T) {
+	// message length prefixes count towards the list size, decoding
+	// used to read past the list when it held more than one message
+	o := SmartRollupAddMessages{
+		Manager: Manager{
+			Source:  tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"),
+			Counter: 1,
+		},
+		Messages: []tezos.HexBytes{{1, 2, 3}, {4}, {5, 6}},
+	}
+	tx := &Transaction{
+		Manager: Manager{
+			Source:  o.Source,
+			Counter: 2,
+		},
+		Amount:      1,
+		Destination: tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"),
+	}
+	op := NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithContents(&o).
+		WithContents(tx)
+	op2, err := DecodeOp(op.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(op2.Contents) != 2 {
+		t.Fatalf("expected 2 contents, got %d", len(op2.Contents))
+	}
+	msgs := op2.Contents[0].(*SmartRollupAddMessages).Messages
+	if len(msgs) != 3 || !bytes.Equal(msgs[2], []byte{5, 6}) {
+		t.Errorf("unexpected messages %v", msgs)
+	}
+	if _, ok := op2.Contents[1].(*Transaction); !ok {
+		t.Errorf("unexpected second content %T", op2.Contents[1])
+	}
+}
@@ -5,6 +5,7 @@ package codec
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/micheline"
@@ -23,6 +24,21 @@ func (o Origination) Kind() tezos.OpType {
 	return tezos.OpTypeOrigination
 }
 
+// Validate checks that the origination contains code and that the initial
+// storage matches the script's storage type.
+func (o Origination) Validate() error {
+	if !o.Script.IsValid() {
+		return fmt.Errorf("tezos: missing origination script")
+	}
+	if !o.Script.Storage.IsValid() {
+		return fmt.Errorf("tezos: missing origination storage")
+	}
+	if !o.Script.Storage.Implements(o.Script.StorageType()) {
+		return fmt.Errorf("tezos: origination storage does not match storage type")
+	}
+	return nil
+}
+
 func (o Origination) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
@@ -80,7 +96,7 @@ func (o *Origination) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (err erro
 		}
 		o.Delegate = addr
 	}
-	if err = o.Script.DecodeBuffer(buf); err != nil {
+	if err = o.Script.DecodeBufferWithOptions(buf, DecodeOptions); err != nil {
 		return err
 	}
 	return nil
@@ -6,6 +6,7 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -23,6 +24,40 @@ func (o Proposals) Kind() tezos.OpType {
 	return tezos.OpTypeProposals
 }
 
+// MaxProposals is the maximum number of proposals a delegate may submit in
+// a single voting period.
+const MaxProposals = 20
+
+// WithSource sets the voting delegate.
+func (o *Proposals) WithSource(addr tezos.Address) {
+	o.Source = addr
+}
+
+// Validate checks that the operation has a valid source and voting period and
+// lists between 1 and MaxProposals distinct valid protocol hashes.
+func (o Proposals) Validate() error {
+	if !o.Source.IsValid() {
+		return fmt.Errorf("tezos: missing proposals source")
+	}
+	if o.Period < 0 {
+		return fmt.Errorf("tezos: invalid voting period %d", o.Period)
+	}
+	if n := len(o.Proposals); n == 0 || n > MaxProposals {
+		return fmt.Errorf("tezos: invalid number of proposals %d", n)
+	}
+	for i, v := range o.Proposals {
+		if !v.IsValid() {
+			return fmt.Errorf("tezos: invalid proposal at position %d", i)
+		}
+		for _, vv := range o.Proposals[:i] {
+			if v.Equal(vv) {
+				return fmt.Errorf("tezos: duplicate proposal %s", v)
+			}
+		}
+	}
+	return nil
+}
+
 func (o Proposals) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
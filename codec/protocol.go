@@ -0,0 +1,142 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package codec
+
+import (
+	"fmt"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// NewOpForProtocol creates a new empty operation that is encoded for protocol
+// proto. Other params like operation TTL are taken from tezos.DefaultParams.
+func NewOpForProtocol(proto tezos.ProtocolHash) *Op {
+	p := paramsForProtocol(proto)
+	return &Op{
+		Params: p,
+		TTL:    p.MaxOperationsTTL - 2,
+	}
+}
+
+// OperationTypeForProtocol returns a new empty operation of type typ using the
+// encoding protocol proto expects, e.g. Tenderbake or legacy endorsements.
+func OperationTypeForProtocol(typ tezos.OpType, proto tezos.ProtocolHash) (Operation, error) {
+	op := newOperation(typ, paramsForProtocol(proto).OperationTagsVersion)
+	if op == nil {
+		return nil, fmt.Errorf("tezos: unsupported operation type %q", typ)
+	}
+	return op, nil
+}
+
+// DecodeOpForProtocol decodes an operation from its binary representation
+// using the operation encoding of protocol proto.
+func DecodeOpForProtocol(data []byte, proto tezos.ProtocolHash) (*Op, error) {
+	return decodeOp(data, paramsForProtocol(proto), -1)
+}
+
+// paramsForProtocol returns default params for protocol proto. Unknown
+// protocols are treated as newer than all known protocols. Unlike
+// Params.WithProtocol the version is resolved locally and the global
+// tezos.Versions map is never modified.
+func paramsForProtocol(proto tezos.ProtocolHash) *tezos.Params {
+	p := tezos.DefaultParams.Clone()
+	p.Protocol = proto
+	v, ok := tezos.Versions[proto]
+	if !ok {
+		for _, n := range tezos.Versions {
+			if n >= v {
+				v = n + 1
+			}
+		}
+	}
+	p.Version = v
+	switch {
+	case v > 11:
+		p.OperationTagsVersion = 2
+	case v > 4:
+		p.OperationTagsVersion = 1
+	default:
+		p.OperationTagsVersion = 0
+	}
+	return p
+}
+
+// newOperation returns a new empty operation of type typ for operation tags
+// version v or nil when the type is unknown.
+func newOperation(typ tezos.OpType, v int) Operation {
+	switch typ {
+	case tezos.OpTypeEndorsement:
+		if v < 2 {
+			return new(Endorsement)
+		}
+		return new(TenderbakeEndorsement)
+	case tezos.OpTypePreendorsement:
+		return new(TenderbakePreendorsement)
+	case tezos.OpTypeEndorsementWithSlot:
+		return new(EndorsementWithSlot)
+	case tezos.OpTypeSeedNonceRevelation:
+		return new(SeedNonceRevelation)
+	case tezos.OpTypeDoubleEndorsementEvidence:
+		if v < 2 {
+			return new(DoubleEndorsementEvidence)
+		}
+		return new(TenderbakeDoubleEndorsementEvidence)
+	case tezos.OpTypeDoublePreendorsementEvidence:
+		return new(TenderbakeDoublePreendorsementEvidence)
+	case tezos.OpTypeDoubleBakingEvidence:
+		return new(DoubleBakingEvidence)
+	case tezos.OpTypeActivateAccount:
+		return new(ActivateAccount)
+	case tezos.OpTypeProposals:
+		return new(Proposals)
+	case tezos.OpTypeBallot:
+		return new(Ballot)
+	case tezos.OpTypeReveal:
+		return new(Reveal)
+	case tezos.OpTypeTransaction:
+		return new(Transaction)
+	case tezos.OpTypeOrigination:
+		return new(Origination)
+	case tezos.OpTypeDelegation:
+		return new(Delegation)
+	case tezos.OpTypeFailingNoop:
+		return new(FailingNoop)
+	case tezos.OpTypeRegisterConstant:
+		return new(RegisterGlobalConstant)
+	case tezos.OpTypeSetDepositsLimit:
+		return new(SetDepositsLimit)
+	case tezos.OpTypeTransferTicket:
+		return new(TransferTicket)
+	case tezos.OpTypeVdfRevelation:
+		return new(VdfRevelation)
+	case tezos.OpTypeIncreasePaidStorage:
+		return new(IncreasePaidStorage)
+	case tezos.OpTypeDrainDelegate:
+		return new(DrainDelegate)
+	case tezos.OpTypeUpdateConsensusKey:
+		return new(UpdateConsensusKey)
+	case tezos.OpTypeSmartRollupOriginate:
+		return new(SmartRollupOriginate)
+	case tezos.OpTypeSmartRollupAddMessages:
+		return new(SmartRollupAddMessages)
+	case tezos.OpTypeSmartRollupCement:
+		return new(SmartRollupCement)
+	case tezos.OpTypeSmartRollupPublish:
+		return new(SmartRollupPublish)
+	case tezos.OpTypeSmartRollupRefute:
+		return new(SmartRollupRefute)
+	case tezos.OpTypeSmartRollupTimeout:
+		return new(SmartRollupTimeout)
+	case tezos.OpTypeSmartRollupExecuteOutboxMessage:
+		return new(SmartRollupExecuteOutboxMessage)
+	case tezos.OpTypeSmartRollupRecoverBond:
+		return new(SmartRollupRecoverBond)
+	case tezos.OpTypeDalAttestation:
+		return new(DalAttestation)
+	case tezos.OpTypeDalPublishSlotHeader:
+		return new(DalPublishSlotHeader)
+	default:
+		return nil
+	}
+}
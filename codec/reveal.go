@@ -10,6 +10,9 @@ import (
 	"blockwatch.cc/tzgo/tezos"
 )
 
+// DefaultRevealGasLimit is the gas a reveal operation consumes.
+const DefaultRevealGasLimit int64 = 1000
+
 // Reveal represents "reveal" operation
 type Reveal struct {
 	Manager
@@ -20,6 +23,25 @@ func (o Reveal) Kind() tezos.OpType {
 	return tezos.OpTypeReveal
 }
 
+// EstimatedCost returns the standard cost of this reveal when sent as part
+// of a batch, i.e. without branch and signature. Limits already set on the
+// reveal take precedence, otherwise the fee is the minimal fee for the
+// standard reveal gas under params p. Nil params use tezos.DefaultParams.
+func (o Reveal) EstimatedCost(p *tezos.Params) tezos.Costs {
+	gas := o.GasLimit.Int64()
+	if gas <= 0 {
+		gas = DefaultRevealGasLimit
+	}
+	fee := o.Fee.Int64()
+	if fee <= 0 {
+		fee = CalculateMinFee(&o, gas, false, p)
+	}
+	return tezos.Costs{
+		Fee:     fee,
+		GasUsed: gas,
+	}
+}
+
 func (o Reveal) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
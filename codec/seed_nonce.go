@@ -6,6 +6,7 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -22,6 +23,21 @@ func (o SeedNonceRevelation) Kind() tezos.OpType {
 	return tezos.OpTypeSeedNonceRevelation
 }
 
+// Validate checks that the revelation refers to a valid block level and
+// contains a non-empty 32 byte nonce.
+func (o SeedNonceRevelation) Validate() error {
+	if o.Level <= 0 {
+		return fmt.Errorf("tezos: invalid seed nonce level %d", o.Level)
+	}
+	if len(o.Nonce) != 32 {
+		return fmt.Errorf("tezos: invalid seed nonce length %d", len(o.Nonce))
+	}
+	if bytes.Equal(o.Nonce, make([]byte, 32)) {
+		return fmt.Errorf("tezos: empty seed nonce")
+	}
+	return nil
+}
+
 func (o SeedNonceRevelation) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
@@ -6,11 +6,15 @@ package codec
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
 )
 
+// SmartRollupMaxMessageSize is the protocol limit for a single rollup inbox message.
+const SmartRollupMaxMessageSize = 4096
+
 // SmartRollupAddMessages represents "smart_rollup_add_messages" operation
 type SmartRollupAddMessages struct {
 	Manager
@@ -42,6 +46,48 @@ func (o SmartRollupAddMessages) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// AddMessage returns the messages of o followed by msg split across as many
+// operations as required for each encoded operation to fit into
+// max_operation_data_length. Each returned operation shares the manager
+// fields of o. The receiver is not modified, send the returned operations
+// instead of o.
+func (o SmartRollupAddMessages) AddMessage(msg []byte) ([]*SmartRollupAddMessages, error) {
+	if len(msg) > SmartRollupMaxMessageSize {
+		return nil, fmt.Errorf("tezos: rollup message size %d exceeds limit %d", len(msg), SmartRollupMaxMessageSize)
+	}
+	msgs := make([]tezos.HexBytes, 0, len(o.Messages)+1)
+	msgs = append(append(msgs, o.Messages...), tezos.HexBytes(msg))
+
+	// fixed size per op: branch, signature and the op without messages
+	p := tezos.DefaultParams
+	buf := bytes.NewBuffer(nil)
+	(&SmartRollupAddMessages{Manager: o.Manager}).EncodeBuffer(buf, p)
+	base := 32 + 64 + buf.Len()
+	if base+SmartRollupMaxMessageSize+4 > p.MaxOperationDataLength {
+		return nil, fmt.Errorf("tezos: rollup message does not fit max operation size %d", p.MaxOperationDataLength)
+	}
+
+	var (
+		ops = make([]*SmartRollupAddMessages, 0, 1)
+		op  = &SmartRollupAddMessages{Manager: o.Manager}
+		sz  = base
+	)
+	for _, v := range msgs {
+		if len(v) > SmartRollupMaxMessageSize {
+			return nil, fmt.Errorf("tezos: rollup message size %d exceeds limit %d", len(v), SmartRollupMaxMessageSize)
+		}
+		if sz+len(v)+4 > p.MaxOperationDataLength {
+			ops = append(ops, op)
+			op = &SmartRollupAddMessages{Manager: o.Manager}
+			sz = base
+		}
+		op.Messages = append(op.Messages, v)
+		sz += len(v) + 4
+	}
+	ops = append(ops, op)
+	return ops, nil
+}
+
 func (o SmartRollupAddMessages) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) error {
 	buf.WriteByte(o.Kind().TagVersion(p.OperationTagsVersion))
 	o.Manager.EncodeBuffer(buf, p)
@@ -75,7 +121,7 @@ func (o *SmartRollupAddMessages) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params
 			return
 		}
 		o.Messages = append(o.Messages, msg)
-		sz -= int32(len(msg))
+		sz -= int32(len(msg) + 4)
 	}
 	return
 }
@@ -10,10 +10,18 @@ import (
 	"blockwatch.cc/tzgo/tezos"
 )
 
-// SmartRollupCement represents "smart_rollup_cement" operation
+// SmartRollupCement represents "smart_rollup_cement" operation. Commitment is
+// only encoded for protocols before v017 (Nairobi) which dropped the field.
 type SmartRollupCement struct {
 	Manager
-	Rollup tezos.Address `json:"rollup"`
+	Rollup     tezos.Address               `json:"rollup"`
+	Commitment tezos.SmartRollupCommitHash `json:"commitment"` // deprecated in v017
+}
+
+// hasCementCommitment returns true when protocol p expects a commitment hash
+// in smart rollup cement operations.
+func hasCementCommitment(p *tezos.Params) bool {
+	return p.Version > 0 && p.Version < 17
 }
 
 func (o SmartRollupCement) Kind() tezos.OpType {
@@ -29,6 +37,10 @@ func (o SmartRollupCement) MarshalJSON() ([]byte, error) {
 	o.Manager.EncodeJSON(buf)
 	buf.WriteString(`,"rollup":`)
 	buf.WriteString(strconv.Quote(o.Rollup.String()))
+	if o.Commitment.IsValid() {
+		buf.WriteString(`,"commitment":`)
+		buf.WriteString(strconv.Quote(o.Commitment.String()))
+	}
 	buf.WriteByte('}')
 	return buf.Bytes(), nil
 }
@@ -37,6 +49,9 @@ func (o SmartRollupCement) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) erro
 	buf.WriteByte(o.Kind().TagVersion(p.OperationTagsVersion))
 	o.Manager.EncodeBuffer(buf, p)
 	buf.Write(o.Rollup.Hash()) // 20 byte only
+	if hasCementCommitment(p) {
+		buf.Write(o.Commitment[:])
+	}
 	return nil
 }
 
@@ -48,6 +63,9 @@ func (o *SmartRollupCement) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (er
 		return
 	}
 	o.Rollup = tezos.NewAddress(tezos.AddressTypeSmartRollup, buf.Next(20))
+	if hasCementCommitment(p) {
+		o.Commitment = tezos.NewSmartRollupCommitHash(buf.Next(32))
+	}
 	return
 }
 
@@ -5,9 +5,9 @@ package codec
 
 import (
 	"bytes"
-	// "encoding/binary"
-	// "io"
-	// "strconv"
+	"encoding/binary"
+	"fmt"
+	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
 )
@@ -136,6 +136,24 @@ type SmartRollupRefute struct {
 	Refutation SmartRollupRefutation `json:"refutation"`
 }
 
+const (
+	SmartRollupRefutationStart = "start"
+	SmartRollupRefutationMove  = "move"
+
+	SmartRollupInputProofInbox      = "inbox_proof"
+	SmartRollupInputProofReveal     = "reveal_proof"
+	SmartRollupInputProofFirstInput = "first_input"
+
+	SmartRollupRevealProofRawData  = "raw_data_proof"
+	SmartRollupRevealProofMetadata = "metadata_proof"
+
+	// max size of proofs using a 30-bit length prefix
+	MaxSmartRollupProofSize = 1<<30 - 1
+
+	// max size of revealed raw data (a single reveal page)
+	MaxSmartRollupRawDataSize = 4096
+)
+
 type SmartRollupRefutation struct {
 	Kind         string                      `json:"refutation_kind"`
 	PlayerHash   tezos.SmartRollupCommitHash `json:"player_commitment_hash"`
@@ -154,32 +172,172 @@ type SmartRollupProof struct {
 	InputProof SmartRollupInputProof `json:"input_proof"`
 }
 
+// Kind returns the kind of input proof or an empty string when the proof
+// consists of a PVM step only.
+func (p SmartRollupProof) Kind() string {
+	return p.InputProof.Kind
+}
+
+// Validate checks that proof kinds are known and proof data fits into
+// the size limits of the binary encoding.
+func (p SmartRollupProof) Validate() error {
+	if l := len(p.PvmStep); l > MaxSmartRollupProofSize {
+		return fmt.Errorf("tezos: smart rollup pvm step too large (%d bytes)", l)
+	}
+	switch p.InputProof.Kind {
+	case "", SmartRollupInputProofFirstInput:
+	case SmartRollupInputProofInbox:
+		if l := len(p.InputProof.Proof); l > MaxSmartRollupProofSize {
+			return fmt.Errorf("tezos: smart rollup inbox proof too large (%d bytes)", l)
+		}
+	case SmartRollupInputProofReveal:
+		switch p.InputProof.Reveal.Kind {
+		case SmartRollupRevealProofMetadata:
+		case SmartRollupRevealProofRawData:
+			if l := len(p.InputProof.Reveal.RawData); l > MaxSmartRollupRawDataSize {
+				return fmt.Errorf("tezos: smart rollup raw data proof too large (%d bytes)", l)
+			}
+		default:
+			return fmt.Errorf("tezos: unsupported smart rollup reveal proof kind %q", p.InputProof.Reveal.Kind)
+		}
+	default:
+		return fmt.Errorf("tezos: unsupported smart rollup input proof kind %q", p.InputProof.Kind)
+	}
+	return nil
+}
+
 type SmartRollupTick struct {
 	State tezos.SmartRollupStateHash `json:"state"`
 	Tick  tezos.Z                    `json:"tick"`
 }
 
 type SmartRollupInputProof struct {
-	Kind    string         `json:"input_proof_kind"`
-	Level   int64          `json:"level"`
-	Counter tezos.Z        `json:"message_counter"`
-	Proof   tezos.HexBytes `json:"serialized_proof"`
+	Kind    string                 `json:"input_proof_kind"`
+	Level   int64                  `json:"level"`
+	Counter tezos.Z                `json:"message_counter"`
+	Proof   tezos.HexBytes         `json:"serialized_proof"`
+	Reveal  SmartRollupRevealProof `json:"reveal_proof"`
+}
+
+type SmartRollupRevealProof struct {
+	Kind    string         `json:"reveal_proof_kind"`
+	RawData tezos.HexBytes `json:"raw_data"`
 }
 
 func (o SmartRollupRefute) Kind() tezos.OpType {
 	return tezos.OpTypeSmartRollupRefute
 }
 
+// Validate checks refutation and proof kinds and proof sizes.
+func (o SmartRollupRefute) Validate() error {
+	r := o.Refutation
+	switch r.Kind {
+	case SmartRollupRefutationStart:
+		return nil
+	case SmartRollupRefutationMove:
+		if r.Step.Proof != nil {
+			return r.Step.Proof.Validate()
+		}
+		return nil
+	default:
+		return fmt.Errorf("tezos: unsupported smart rollup refutation kind %q", r.Kind)
+	}
+}
+
 func (o SmartRollupRefute) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
-	// TODO if needed
+	buf.WriteByte('{')
+	buf.WriteString(`"kind":`)
+	buf.WriteString(strconv.Quote(o.Kind().String()))
+	buf.WriteByte(',')
+	o.Manager.EncodeJSON(buf)
+	buf.WriteString(`,"rollup":`)
+	buf.WriteString(strconv.Quote(o.Rollup.String()))
+	buf.WriteString(`,"opponent":`)
+	buf.WriteString(strconv.Quote(o.Opponent.String()))
+	buf.WriteString(`,"refutation":{"refutation_kind":`)
+	buf.WriteString(strconv.Quote(o.Refutation.Kind))
+	switch o.Refutation.Kind {
+	case SmartRollupRefutationStart:
+		buf.WriteString(`,"player_commitment_hash":`)
+		buf.WriteString(strconv.Quote(o.Refutation.PlayerHash.String()))
+		buf.WriteString(`,"opponent_commitment_hash":`)
+		buf.WriteString(strconv.Quote(o.Refutation.OpponentHash.String()))
+	case SmartRollupRefutationMove:
+		buf.WriteString(`,"choice":`)
+		buf.WriteString(strconv.Quote(o.Refutation.Choice.String()))
+		buf.WriteString(`,"step":`)
+		o.Refutation.Step.encodeJSON(buf)
+	}
+	buf.WriteString("}}")
 	return buf.Bytes(), nil
 }
 
+func (s SmartRollupRefuteStep) encodeJSON(buf *bytes.Buffer) {
+	if s.Proof == nil {
+		buf.WriteByte('[')
+		for i, v := range s.Ticks {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('{')
+			if v.State.IsValid() {
+				buf.WriteString(`"state":`)
+				buf.WriteString(strconv.Quote(v.State.String()))
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`"tick":`)
+			buf.WriteString(strconv.Quote(v.Tick.String()))
+			buf.WriteByte('}')
+		}
+		buf.WriteByte(']')
+		return
+	}
+	buf.WriteString(`{"pvm_step":`)
+	buf.WriteString(strconv.Quote(s.Proof.PvmStep.String()))
+	if in := s.Proof.InputProof; in.Kind != "" {
+		buf.WriteString(`,"input_proof":{"input_proof_kind":`)
+		buf.WriteString(strconv.Quote(in.Kind))
+		switch in.Kind {
+		case SmartRollupInputProofInbox:
+			buf.WriteString(`,"level":`)
+			buf.WriteString(strconv.FormatInt(in.Level, 10))
+			buf.WriteString(`,"message_counter":`)
+			buf.WriteString(strconv.Quote(in.Counter.String()))
+			buf.WriteString(`,"serialized_proof":`)
+			buf.WriteString(strconv.Quote(in.Proof.String()))
+		case SmartRollupInputProofReveal:
+			buf.WriteString(`,"reveal_proof":{"reveal_proof_kind":`)
+			buf.WriteString(strconv.Quote(in.Reveal.Kind))
+			if in.Reveal.Kind == SmartRollupRevealProofRawData {
+				buf.WriteString(`,"raw_data":`)
+				buf.WriteString(strconv.Quote(in.Reveal.RawData.String()))
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+}
+
 func (o SmartRollupRefute) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) error {
 	buf.WriteByte(o.Kind().TagVersion(p.OperationTagsVersion))
 	o.Manager.EncodeBuffer(buf, p)
-	// TODO if needed
+	buf.Write(o.Rollup.Hash()) // 20 byte only
+	buf.Write(o.Opponent.Encode())
+	r := o.Refutation
+	switch r.Kind {
+	case SmartRollupRefutationStart:
+		buf.WriteByte(0)
+		buf.Write(r.PlayerHash[:])
+		buf.Write(r.OpponentHash[:])
+	case SmartRollupRefutationMove:
+		buf.WriteByte(1)
+		tezos.NewN(r.Choice.Int64()).EncodeBuffer(buf)
+		return r.Step.EncodeBuffer(buf)
+	default:
+		return fmt.Errorf("tezos: unsupported smart rollup refutation kind %q", r.Kind)
+	}
 	return nil
 }
 
@@ -190,7 +348,184 @@ func (o *SmartRollupRefute) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (er
 	if err = o.Manager.DecodeBuffer(buf, p); err != nil {
 		return
 	}
-	// TODO if needed
+	o.Rollup = tezos.NewAddress(tezos.AddressTypeSmartRollup, buf.Next(20))
+	if err = o.Opponent.Decode(buf.Next(21)); err != nil {
+		return
+	}
+	var tag byte
+	if tag, err = readByte(buf.Next(1)); err != nil {
+		return
+	}
+	r := &o.Refutation
+	switch tag {
+	case 0:
+		r.Kind = SmartRollupRefutationStart
+		if err = r.PlayerHash.UnmarshalBinary(buf.Next(32)); err != nil {
+			return
+		}
+		err = r.OpponentHash.UnmarshalBinary(buf.Next(32))
+	case 1:
+		r.Kind = SmartRollupRefutationMove
+		var n tezos.N
+		if err = n.DecodeBuffer(buf); err != nil {
+			return
+		}
+		r.Choice = tezos.NewZ(n.Int64())
+		err = r.Step.DecodeBuffer(buf)
+	default:
+		err = fmt.Errorf("tezos: invalid smart rollup refutation tag %d", tag)
+	}
+	return
+}
+
+func (s SmartRollupRefuteStep) EncodeBuffer(buf *bytes.Buffer) error {
+	if s.Proof == nil {
+		// dissection
+		buf.WriteByte(0)
+		b := bytes.NewBuffer(nil)
+		for _, v := range s.Ticks {
+			if v.State.IsValid() {
+				b.WriteByte(0xff)
+				b.Write(v.State[:])
+			} else {
+				b.WriteByte(0)
+			}
+			tezos.NewN(v.Tick.Int64()).EncodeBuffer(b)
+		}
+		return writeBytesWithLen(buf, b.Bytes())
+	}
+	buf.WriteByte(1)
+	if err := writeBytesWithLen(buf, s.Proof.PvmStep); err != nil {
+		return err
+	}
+	in := s.Proof.InputProof
+	if in.Kind == "" {
+		buf.WriteByte(0)
+		return nil
+	}
+	buf.WriteByte(0xff)
+	switch in.Kind {
+	case SmartRollupInputProofInbox:
+		buf.WriteByte(0)
+		binary.Write(buf, enc, int32(in.Level))
+		tezos.NewN(in.Counter.Int64()).EncodeBuffer(buf)
+		return writeBytesWithLen(buf, in.Proof)
+	case SmartRollupInputProofReveal:
+		buf.WriteByte(1)
+		switch in.Reveal.Kind {
+		case SmartRollupRevealProofRawData:
+			if len(in.Reveal.RawData) > MaxSmartRollupRawDataSize {
+				return fmt.Errorf("tezos: smart rollup raw data proof too large (%d bytes)", len(in.Reveal.RawData))
+			}
+			buf.WriteByte(0)
+			binary.Write(buf, enc, uint16(len(in.Reveal.RawData)))
+			buf.Write(in.Reveal.RawData)
+		case SmartRollupRevealProofMetadata:
+			buf.WriteByte(1)
+		default:
+			return fmt.Errorf("tezos: unsupported smart rollup reveal proof kind %q", in.Reveal.Kind)
+		}
+	case SmartRollupInputProofFirstInput:
+		buf.WriteByte(2)
+	default:
+		return fmt.Errorf("tezos: unsupported smart rollup input proof kind %q", in.Kind)
+	}
+	return nil
+}
+
+func (s *SmartRollupRefuteStep) DecodeBuffer(buf *bytes.Buffer) (err error) {
+	var tag byte
+	if tag, err = readByte(buf.Next(1)); err != nil {
+		return
+	}
+	switch tag {
+	case 0:
+		var b tezos.HexBytes
+		if b, err = readBytesWithLen(buf); err != nil {
+			return
+		}
+		sub := bytes.NewBuffer(b)
+		s.Ticks = make([]SmartRollupTick, 0)
+		for sub.Len() > 0 {
+			var (
+				tick SmartRollupTick
+				ok   bool
+				n    tezos.N
+			)
+			if ok, err = readBool(sub.Next(1)); err != nil {
+				return
+			}
+			if ok {
+				if err = tick.State.UnmarshalBinary(sub.Next(32)); err != nil {
+					return
+				}
+			}
+			if err = n.DecodeBuffer(sub); err != nil {
+				return
+			}
+			tick.Tick = tezos.NewZ(n.Int64())
+			s.Ticks = append(s.Ticks, tick)
+		}
+	case 1:
+		s.Proof = &SmartRollupProof{}
+		if s.Proof.PvmStep, err = readBytesWithLen(buf); err != nil {
+			return
+		}
+		var ok bool
+		if ok, err = readBool(buf.Next(1)); err != nil || !ok {
+			return
+		}
+		err = s.Proof.InputProof.DecodeBuffer(buf)
+	default:
+		err = fmt.Errorf("tezos: invalid smart rollup refute step tag %d", tag)
+	}
+	return
+}
+
+func (p *SmartRollupInputProof) DecodeBuffer(buf *bytes.Buffer) (err error) {
+	var tag byte
+	if tag, err = readByte(buf.Next(1)); err != nil {
+		return
+	}
+	switch tag {
+	case 0:
+		p.Kind = SmartRollupInputProofInbox
+		var (
+			level int32
+			n     tezos.N
+		)
+		if level, err = readInt32(buf.Next(4)); err != nil {
+			return
+		}
+		p.Level = int64(level)
+		if err = n.DecodeBuffer(buf); err != nil {
+			return
+		}
+		p.Counter = tezos.NewZ(n.Int64())
+		p.Proof, err = readBytesWithLen(buf)
+	case 1:
+		p.Kind = SmartRollupInputProofReveal
+		if tag, err = readByte(buf.Next(1)); err != nil {
+			return
+		}
+		switch tag {
+		case 0:
+			p.Reveal.Kind = SmartRollupRevealProofRawData
+			var l int16
+			if l, err = readInt16(buf.Next(2)); err != nil {
+				return
+			}
+			err = p.Reveal.RawData.ReadBytes(buf, int(uint16(l)))
+		case 1:
+			p.Reveal.Kind = SmartRollupRevealProofMetadata
+		default:
+			err = fmt.Errorf("tezos: unsupported smart rollup reveal proof tag %d", tag)
+		}
+	case 2:
+		p.Kind = SmartRollupInputProofFirstInput
+	default:
+		err = fmt.Errorf("tezos: invalid smart rollup input proof tag %d", tag)
+	}
 	return
 }
 
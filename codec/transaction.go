@@ -77,7 +77,7 @@ func (o *Transaction) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (err erro
 	}
 	if ok {
 		param := &micheline.Parameters{}
-		if err = param.DecodeBuffer(buf); err != nil {
+		if err = param.DecodeBufferWithOptions(buf, DecodeOptions); err != nil {
 			return err
 		}
 		o.Parameters = param
@@ -8,31 +8,26 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"math"
 
 	"blockwatch.cc/tzgo/micheline"
 	"blockwatch.cc/tzgo/tezos"
 )
 
-// TODO: fetch dynamic from /chains/main/mempool/filter
-const (
-	minFeeFixedNanoTez int64 = 100_000
-	minFeeByteNanoTez  int64 = 1_000
-	minFeeGasNanoTez   int64 = 100
-)
-
 // CalculateMinFee returns the minimum fee at/above which bakers will accept
-// this operation under default config settings. Lower fee operations may not
-// pass the fee filter and may time out in the mempool.
+// this operation under the fee defaults of params p (see tezos.Params.DefaultFees).
+// Lower fee operations may not pass the fee filter and may time out in the
+// mempool. Nil params use tezos.DefaultParams.
 func CalculateMinFee(o Operation, gas int64, withHeader bool, p *tezos.Params) int64 {
+	if p == nil {
+		p = tezos.DefaultParams
+	}
 	buf := bytes.NewBuffer(nil)
 	_ = o.EncodeBuffer(buf, p)
 	sz := int64(buf.Len())
 	if withHeader {
 		sz += 32 + 64 // branch + signature
 	}
-	fee := minFeeFixedNanoTez + sz*minFeeByteNanoTez + gas*minFeeGasNanoTez
-	return int64(math.Ceil(float64(fee) / 1000)) // nano -> micro, round up
+	return p.DefaultFees().MinFee(sz, gas)
 }
 
 // ensureTagAndSize reads the binary operation's tag and matches it against the expected
@@ -143,7 +138,7 @@ func readPrimWithLen(buf *bytes.Buffer) (p micheline.Prim, err error) {
 	if err != nil {
 		return
 	}
-	err = p.UnmarshalBinary(buf.Next(int(l)))
+	err = p.UnmarshalBinaryWithOptions(buf.Next(int(l)), DecodeOptions)
 	return
 }
 
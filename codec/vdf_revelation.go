@@ -5,32 +5,101 @@ package codec
 
 import (
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"blockwatch.cc/tzgo/tezos"
 )
 
+const (
+	// VdfSolutionPartSize is the size of each of the two class group elements
+	// (result and proof) in a VDF solution.
+	VdfSolutionPartSize = 100
+
+	// VdfSolutionSize is the binary size of a VDF solution.
+	VdfSolutionSize = 2 * VdfSolutionPartSize
+)
+
 // VdfRevelation represents "vdf_revelation" operation
 type VdfRevelation struct {
 	Simple
 	Solution tezos.HexBytes `json:"solution"`
 }
 
+// NewVdfRevelation creates a vdf_revelation operation from the VDF result and
+// proof computed for the current cycle's seed.
+func NewVdfRevelation(result, proof []byte) (*VdfRevelation, error) {
+	if len(result) != VdfSolutionPartSize || len(proof) != VdfSolutionPartSize {
+		return nil, fmt.Errorf("tezos: invalid vdf solution size %d/%d, expected %d bytes each",
+			len(result), len(proof), VdfSolutionPartSize)
+	}
+	sol := make([]byte, 0, VdfSolutionSize)
+	sol = append(sol, result...)
+	sol = append(sol, proof...)
+	return &VdfRevelation{Solution: sol}, nil
+}
+
 func (o VdfRevelation) Kind() tezos.OpType {
 	return tezos.OpTypeVdfRevelation
 }
 
 func (o VdfRevelation) MarshalJSON() ([]byte, error) {
+	if l := len(o.Solution); l != VdfSolutionSize {
+		return nil, fmt.Errorf("tezos: invalid vdf solution size %d, expected %d bytes", l, VdfSolutionSize)
+	}
 	buf := bytes.NewBuffer(nil)
 	buf.WriteByte('{')
 	buf.WriteString(`"kind":`)
 	buf.WriteString(strconv.Quote(o.Kind().String()))
-	buf.WriteString(`,"solution":`)
-	buf.WriteString(strconv.Quote(o.Solution.String()))
-	buf.WriteByte('}')
+	buf.WriteString(`,"solution":[`)
+	buf.WriteString(strconv.Quote(hex.EncodeToString(o.Solution[:VdfSolutionPartSize])))
+	buf.WriteByte(',')
+	buf.WriteString(strconv.Quote(hex.EncodeToString(o.Solution[VdfSolutionPartSize:])))
+	buf.WriteString(`]}`)
 	return buf.Bytes(), nil
 }
 
+// UnmarshalJSON reads the node's JSON format where the solution is a pair of
+// hex strings (result and proof). A single hex string as written by earlier
+// versions of this package is accepted as well. Kind is optional.
+func (o *VdfRevelation) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind     tezos.OpType    `json:"kind"`
+		Solution json.RawMessage `json:"solution"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Kind.IsValid() && v.Kind != o.Kind() {
+		return fmt.Errorf("tezos: invalid kind %q for %s", v.Kind, o.Kind())
+	}
+	var sol tezos.HexBytes
+	if len(v.Solution) > 0 && v.Solution[0] == '[' {
+		var parts []tezos.HexBytes
+		if err := json.Unmarshal(v.Solution, &parts); err != nil {
+			return err
+		}
+		if len(parts) != 2 {
+			return fmt.Errorf("tezos: invalid vdf solution with %d parts, expected 2", len(parts))
+		}
+		for _, part := range parts {
+			if l := len(part); l != VdfSolutionPartSize {
+				return fmt.Errorf("tezos: invalid vdf solution part size %d, expected %d bytes", l, VdfSolutionPartSize)
+			}
+			sol = append(sol, part...)
+		}
+	} else if err := json.Unmarshal(v.Solution, &sol); err != nil {
+		return err
+	}
+	if l := len(sol); l != VdfSolutionSize {
+		return fmt.Errorf("tezos: invalid vdf solution size %d, expected %d bytes", l, VdfSolutionSize)
+	}
+	o.Solution = sol
+	return nil
+}
+
 func (o VdfRevelation) EncodeBuffer(buf *bytes.Buffer, p *tezos.Params) error {
 	buf.WriteByte(o.Kind().TagVersion(p.OperationTagsVersion))
 	buf.Write(o.Solution.Bytes())
@@ -41,7 +110,7 @@ func (o *VdfRevelation) DecodeBuffer(buf *bytes.Buffer, p *tezos.Params) (err er
 	if err = ensureTagAndSize(buf, o.Kind(), p.OperationTagsVersion); err != nil {
 		return
 	}
-	return o.Solution.ReadBytes(buf, 200)
+	return o.Solution.ReadBytes(buf, VdfSolutionSize)
 }
 
 func (o VdfRevelation) MarshalBinary() ([]byte, error) {
@@ -106,6 +106,21 @@ func (c *Contract) Resolve(ctx context.Context) error {
 	return nil
 }
 
+// VerifyCodeHash checks that the contract's code matches expected, e.g. a
+// hash pinned at deployment time, to protect against impostor contracts.
+// The contract script is resolved when not yet loaded.
+func (c *Contract) VerifyCodeHash(ctx context.Context, expected tezos.ExprHash) error {
+	if c.script == nil {
+		if err := c.Resolve(ctx); err != nil {
+			return err
+		}
+	}
+	if h := c.script.CodeExprHash(); !h.Equal(expected) {
+		return fmt.Errorf("%s code hash mismatch: have %s want %s", c.addr, h, expected)
+	}
+	return nil
+}
+
 func (c *Contract) Reload(ctx context.Context) error {
 	store, err := c.rpc.GetContractStorage(ctx, c.addr, rpc.Head)
 	if err != nil {
@@ -197,6 +212,94 @@ func (c Contract) IsFA2() bool {
 	return c.script != nil && c.script.Implements(micheline.ITzip12)
 }
 
+// Conforms checks whether the contract implements all entrypoints of interface
+// iface and returns the names of missing or mismatched entrypoints otherwise.
+func (c Contract) Conforms(iface micheline.Interface) (bool, []string) {
+	var eps micheline.Entrypoints
+	if c.script != nil {
+		eps, _ = c.script.Entrypoints(true)
+	}
+	missing := iface.Missing(eps)
+	return len(missing) == 0 && len(micheline.InterfaceSpecs[iface]) > 0, missing
+}
+
+// IsPaused returns the value of a boolean storage field annotated %paused
+// as used by many token contracts to halt transfers. The field may be nested
+// inside pairs and options. An unset option reports the contract as not
+// paused. Contract script and storage are resolved when not yet loaded, call
+// Reload to refresh storage.
+func (c *Contract) IsPaused(ctx context.Context) (bool, error) {
+	if c.script == nil || c.store == nil {
+		if err := c.Resolve(ctx); err != nil {
+			return false, err
+		}
+	}
+	path, ok := findBoolField(c.script.StorageType().Prim, "paused", nil)
+	if !ok {
+		return false, fmt.Errorf("%s: no paused field in storage", c.addr)
+	}
+	val := *c.store
+	typ := c.script.StorageType().Prim
+	for _, i := range path {
+		switch typ.OpCode {
+		case micheline.T_OPTION:
+			if val.OpCode == micheline.D_NONE {
+				return false, nil
+			}
+			if val.OpCode != micheline.D_SOME || len(val.Args) == 0 {
+				return false, fmt.Errorf("%s: unexpected paused field value %s", c.addr, val.Dump())
+			}
+			typ, val = typ.Args[0], val.Args[0]
+		default:
+			typ, val = combArg(typ, i), combArg(val, i)
+		}
+	}
+	switch val.OpCode {
+	case micheline.D_TRUE:
+		return true, nil
+	case micheline.D_FALSE:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: unexpected paused field value %s", c.addr, val.Dump())
+	}
+}
+
+// findBoolField returns the path to a bool type annotated with name. Only
+// pairs and options are searched.
+func findBoolField(typ micheline.Prim, name string, path []int) ([]int, bool) {
+	switch typ.OpCode {
+	case micheline.T_BOOL:
+		return path, typ.MatchesAnno(name)
+	case micheline.T_OPTION:
+		if len(typ.Args) > 0 {
+			return findBoolField(typ.Args[0], name, append(path, 0))
+		}
+	case micheline.T_PAIR:
+		for i := 0; i < 2 && i < len(typ.Args); i++ {
+			if p, ok := findBoolField(combArg(typ, i), name, append(path[:len(path):len(path)], i)); ok {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// combArg returns the left or right element of a pair type or value and
+// handles comb pairs with more than two arguments.
+func combArg(p micheline.Prim, i int) micheline.Prim {
+	if i >= len(p.Args) {
+		return micheline.InvalidPrim
+	}
+	if i == 1 && len(p.Args) > 2 {
+		oc := p.OpCode
+		if p.IsSequence() {
+			oc = micheline.D_PAIR
+		}
+		return micheline.NewCode(oc, p.Args[1:]...)
+	}
+	return p.Args[i]
+}
+
 // func (c *Contract) IsNFT() bool {}
 
 func (c *Contract) AsFA1() *FA1Token {
@@ -353,7 +456,7 @@ func (c *Contract) Call(ctx context.Context, args CallArguments, opts *rpc.CallO
 
 func (c *Contract) CallMulti(ctx context.Context, args []CallArguments, opts *rpc.CallOptions) (*rpc.Receipt, error) {
 	if opts == nil {
-		opts = &rpc.DefaultOptions
+		opts = rpc.NewCallOptions()
 	}
 
 	// assemble batch transaction
@@ -376,7 +479,7 @@ func (c *Contract) Deploy(ctx context.Context, opts *rpc.CallOptions) (*rpc.Rece
 
 func (c *Contract) DeployExt(ctx context.Context, delegate tezos.Address, balance tezos.N, opts *rpc.CallOptions) (*rpc.Receipt, error) {
 	if opts == nil {
-		opts = &rpc.DefaultOptions
+		opts = rpc.NewCallOptions()
 	}
 
 	// assemble origination op
@@ -0,0 +1,76 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestContractIsPaused(t *testing.T) {
+	var (
+		addr  = tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+		admin = micheline.NewAddress(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"))
+		yes   = micheline.NewCode(micheline.D_TRUE)
+		no    = micheline.NewCode(micheline.D_FALSE)
+	)
+	newScript := func(typ micheline.Prim) *micheline.Script {
+		s := micheline.NewScript()
+		s.Code.Storage = micheline.NewCode(micheline.K_STORAGE, typ)
+		return s
+	}
+
+	// USDtz style: pair (big_map %ledger) (pair (address %admin) (pair (bool %paused) (nat %totalSupply)))
+	usdtz := newScript(micheline.NewPairType(
+		micheline.NewMapType(micheline.NewPrim(micheline.T_ADDRESS), micheline.NewPrim(micheline.T_NAT), "%ledger"),
+		micheline.NewPairType(
+			micheline.NewPrim(micheline.T_ADDRESS, "%admin"),
+			micheline.NewPairType(
+				micheline.NewPrim(micheline.T_BOOL, "%paused"),
+				micheline.NewPrim(micheline.T_NAT, "%totalSupply"),
+			),
+		),
+	))
+
+	// admin module inside an option
+	optional := newScript(micheline.NewPairType(
+		micheline.NewOptType(micheline.NewPairType(
+			micheline.NewPrim(micheline.T_ADDRESS, "%admin"),
+			micheline.NewPrim(micheline.T_BOOL, "%paused"),
+		), "%pauseable_admin"),
+		micheline.NewPrim(micheline.T_NAT, "%total"),
+	))
+
+	for i, v := range []struct {
+		script *micheline.Script
+		store  micheline.Prim
+		want   bool
+	}{
+		// nested pairs
+		{usdtz, micheline.NewPair(micheline.NewInt64(17), micheline.NewPair(admin, micheline.NewPair(yes, micheline.NewInt64(100)))), true},
+		// comb pair value
+		{usdtz, micheline.NewCode(micheline.D_PAIR, micheline.NewInt64(17), admin, no, micheline.NewInt64(100)), false},
+		{optional, micheline.NewPair(micheline.NewOption(micheline.NewPair(admin, yes)), micheline.NewInt64(1)), true},
+		{optional, micheline.NewPair(micheline.NewOption(), micheline.NewInt64(1)), false},
+	} {
+		c := NewContract(addr, nil).WithScript(v.script).WithStorage(&v.store)
+		paused, err := c.IsPaused(context.Background())
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if paused != v.want {
+			t.Errorf("%d: have paused=%t want %t", i, paused, v.want)
+		}
+	}
+
+	// no paused field
+	store := micheline.NewInt64(1)
+	c := NewContract(addr, nil).WithScript(newScript(micheline.NewPrim(micheline.T_NAT))).WithStorage(&store)
+	if _, err := c.IsPaused(context.Background()); err == nil {
+		t.Errorf("expected error for missing paused field")
+	}
+}
@@ -41,6 +41,24 @@ func (t FA2Token) ResolveMetadata(ctx context.Context) (*TokenMetadata, error) {
 	return ResolveTokenMetadata(ctx, t.contract, t.TokenId)
 }
 
+// ResolveMetadataBatch resolves metadata for many token ids of this token's
+// contract concurrently. Use it to load metadata for entire collections.
+func (t FA2Token) ResolveMetadataBatch(ctx context.Context, ids []int64) (map[int64]*TokenMetadata, error) {
+	zids := make([]tezos.Z, len(ids))
+	for i, id := range ids {
+		zids[i].SetInt64(id)
+	}
+	metas, err := ResolveTokenMetadataBatch(ctx, t.contract, zids)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]*TokenMetadata, len(metas))
+	for i, id := range ids {
+		res[id] = metas[zids[i].String()]
+	}
+	return res, nil
+}
+
 type FA2BalanceRequest struct {
 	Owner   tezos.Address `json:"owner"`
 	TokenId tezos.Z       `json:"token_id"`
@@ -94,6 +112,30 @@ func (t FA2Token) RemoveOperator(owner, operator tezos.Address) CallArguments {
 		WithDestination(t.Address)
 }
 
+// OperatorUpdate describes an operator permission change requested by owner.
+type OperatorUpdate struct {
+	Owner    tezos.Address
+	Operator tezos.Address
+}
+
+// UpdateOperators returns call arguments that add and remove multiple operators
+// for this token in a single update_operators call. Additions are listed before
+// removals. Since only token owners can update their operators, the call is sent
+// from the owner of the first update.
+func (t FA2Token) UpdateOperators(adds, removes []OperatorUpdate) CallArguments {
+	args := NewFA2ApprovalArgs()
+	for _, v := range adds {
+		args.AddOperator(v.Owner, v.Operator, t.TokenId)
+	}
+	for _, v := range removes {
+		args.RemoveOperator(v.Owner, v.Operator, t.TokenId)
+	}
+	if len(args.Approvals) > 0 {
+		args.WithSource(args.Approvals[0].Owner)
+	}
+	return args.WithDestination(t.Address)
+}
+
 func (t FA2Token) Transfer(from, to tezos.Address, amount tezos.Z) CallArguments {
 	return NewFA2TransferArgs().
 		WithTransfer(from, to, t.TokenId, amount).
@@ -202,7 +244,7 @@ func (p *FA2ApprovalArgs) RemoveOperator(owner, operator tezos.Address, id tezos
 		Owner:    owner.Clone(),
 		Operator: operator.Clone(),
 		TokenId:  id.Clone(),
-		Add:      true,
+		Add:      false,
 	})
 	return p
 }
@@ -0,0 +1,117 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/rpc"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestFA2UpdateOperators(t *testing.T) {
+	var (
+		owner = tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+		op1   = tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+		op2   = tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	)
+	token := FA2Token{Address: tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton")}
+	token.TokenId.SetInt64(7)
+
+	args := token.UpdateOperators(
+		[]OperatorUpdate{{owner, op1}, {owner, op2}},
+		[]OperatorUpdate{{owner, op1}},
+	)
+	tx := args.Encode()
+	if !tx.Source.Equal(owner) || !tx.Destination.Equal(token.Address) {
+		t.Errorf("unexpected source %s or destination %s", tx.Source, tx.Destination)
+	}
+	params := tx.Parameters
+	if params.Entrypoint != "update_operators" {
+		t.Errorf("unexpected entrypoint %q", params.Entrypoint)
+	}
+	if n := len(params.Value.Args); n != 3 {
+		t.Fatalf("expected 3 updates, got %d", n)
+	}
+	for i, branch := range []micheline.OpCode{micheline.D_LEFT, micheline.D_LEFT, micheline.D_RIGHT} {
+		v := params.Value.Args[i]
+		if v.OpCode != branch {
+			t.Errorf("update %d: have %s want %s", i, v.OpCode, branch)
+		}
+		if id := v.Args[0].Args[1].Args[1].Int; id.Int64() != 7 {
+			t.Errorf("update %d: unexpected token id %s", i, id)
+		}
+	}
+}
+
+func TestFA2ResolveMetadataBatch(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton")
+	contractPath := "/chains/main/blocks/head/context/contracts/" + addr.String()
+	bigmapPath := func(id int64) string {
+		key := (micheline.Key{
+			Type:   micheline.NewType(micheline.NewPrim(micheline.T_NAT)),
+			IntKey: big.NewInt(id),
+		}).Hash()
+		return "/chains/main/blocks/head/context/big_maps/3/" + key.String()
+	}
+	tokenInfo := func(id int64, name string) string {
+		return `{"prim":"Pair","args":[{"int":"` + big.NewInt(id).String() + `"},[{"prim":"Elt","args":[{"string":"name"},{"bytes":"` + hex.EncodeToString([]byte(name)) + `"}]}]]}`
+	}
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		contractPath + "/script/normalized": `{"code":[{"prim":"parameter","args":[{"prim":"unit"}]},{"prim":"storage","args":[{"prim":"big_map","args":[{"prim":"nat"},{"prim":"pair","args":[{"prim":"nat"},{"prim":"map","args":[{"prim":"string"},{"prim":"bytes"}]}]}],"annots":["%token_metadata"]}]},{"prim":"code","args":[[]]}],"storage":{"int":"3"}}`,
+		contractPath + "/storage":           `{"int":"3"}`,
+		bigmapPath(1):                       tokenInfo(1, "Alpha"),
+		bigmapPath(2):                       tokenInfo(2, "Beta"),
+		bigmapPath(5):                       `{"int":"5"}`, // malformed token info
+		"/chains/main/blocks/head/context/big_maps/3/*": nodetest.Response{Status: http.StatusNotFound},
+		"/*": func(r *http.Request) any {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			return nil
+		},
+	})
+	cli, err := rpc.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewContract(addr, cli)
+	c.meta = &Tz16{}
+	token := c.AsFA2(1)
+
+	metas, err := token.ResolveMetadataBatch(context.Background(), []int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metas) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(metas))
+	}
+	for id, name := range map[int64]string{1: "Alpha", 2: "Beta"} {
+		if m := metas[id]; m == nil || m.Name != name {
+			t.Errorf("token %d: unexpected metadata %v", id, m)
+		}
+	}
+
+	// empty id list
+	metas, err = token.ResolveMetadataBatch(context.Background(), nil)
+	if err != nil || len(metas) != 0 {
+		t.Errorf("expected empty result, got %v %v", metas, err)
+	}
+
+	// missing ids fail the entire batch
+	metas, err = token.ResolveMetadataBatch(context.Background(), []int64{1, 9, 2})
+	if err == nil || metas != nil {
+		t.Errorf("expected error for missing token id, got %v %v", metas, err)
+	}
+
+	// a single failing token fails the entire batch
+	metas, err = token.ResolveMetadataBatch(context.Background(), []int64{1, 2, 5})
+	if err == nil || metas != nil {
+		t.Errorf("expected error for malformed token metadata, got %v %v", metas, err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/tzgo/codec"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// Permit is a signed TZIP-17 permit that pre-authorizes a call with a known
+// parameter hash. A relayer submits the permit (and usually the permitted
+// call in the same batch) on behalf of the signer who does not need to own
+// any tez.
+type Permit struct {
+	TxArgs
+	Key       tezos.Key       // public key of the signer
+	Signature tezos.Signature // signature over the permit payload
+	Hash      tezos.ExprHash  // blake2b hash of the packed call parameters
+	Counter   tezos.Z         // contract permit counter the permit was signed for
+}
+
+var _ CallArguments = (*Permit)(nil)
+
+func (p *Permit) WithSource(addr tezos.Address) CallArguments {
+	p.Source = addr.Clone()
+	return p
+}
+
+func (p *Permit) WithDestination(addr tezos.Address) CallArguments {
+	p.Destination = addr.Clone()
+	return p
+}
+
+// Parameters returns call parameters for the `permit` entrypoint.
+func (p Permit) Parameters() *micheline.Parameters {
+	return &micheline.Parameters{
+		Entrypoint: "permit",
+		Value: micheline.NewSeq(
+			micheline.NewPair(
+				micheline.NewString(p.Key.String()),
+				micheline.NewPair(
+					micheline.NewString(p.Signature.String()),
+					micheline.NewBytes(p.Hash[:]),
+				),
+			),
+		),
+	}
+}
+
+func (p Permit) Encode() *codec.Transaction {
+	return &codec.Transaction{
+		Manager: codec.Manager{
+			Source: p.Source,
+		},
+		Destination: p.Destination,
+		Parameters:  p.Parameters(),
+	}
+}
+
+// PermitHash returns the TZIP-17 parameter hash of a call, i.e. the blake2b
+// hash over the packed parameter value.
+func PermitHash(params *micheline.Parameters) tezos.ExprHash {
+	h := tezos.Digest(params.Value.Pack())
+	return tezos.NewExprHash(h[:])
+}
+
+// PermitPayload returns the packed TZIP-17 permit payload a signer must sign
+// to authorize a call with parameter hash on contract addr. The counter is the
+// contract's current permit counter.
+func PermitPayload(chain tezos.ChainIdHash, addr tezos.Address, counter tezos.Z, hash tezos.ExprHash) []byte {
+	return micheline.NewPair(
+		micheline.NewPair(
+			micheline.NewBytes(chain.Bytes()),
+			micheline.NewBytes(addr.EncodePadded()),
+		),
+		micheline.NewPair(
+			micheline.NewNat(counter.Big()),
+			micheline.NewBytes(hash[:]),
+		),
+	).Pack()
+}
+
+// SignPermit creates a permit for params on contract addr and signs it with sk.
+func SignPermit(sk tezos.PrivateKey, chain tezos.ChainIdHash, addr tezos.Address, counter tezos.Z, params *micheline.Parameters) (Permit, error) {
+	hash := PermitHash(params)
+	digest := tezos.Digest(PermitPayload(chain, addr, counter, hash))
+	sig, err := sk.Sign(digest[:])
+	if err != nil {
+		return Permit{}, err
+	}
+	p := Permit{
+		Key:       sk.Public(),
+		Signature: sig,
+		Hash:      hash,
+		Counter:   counter.Clone(),
+	}
+	p.WithDestination(addr)
+	return p, nil
+}
+
+// PermitCounter returns the current TZIP-17 permit counter from contract
+// storage. The counter is read from a nat field annotated %counter or
+// %permit_counter. Storage is always fetched at head because the counter
+// increases with every permit the contract accepts.
+func (c *Contract) PermitCounter(ctx context.Context) (tezos.Z, error) {
+	var err error
+	if c.script == nil {
+		err = c.Resolve(ctx)
+	} else {
+		err = c.Reload(ctx)
+	}
+	if err != nil {
+		return tezos.Z{}, err
+	}
+	val := micheline.NewValue(c.script.StorageType(), *c.store)
+	for _, name := range []string{"counter", "permit_counter"} {
+		if z, ok := val.GetZ(name); ok {
+			return *z, nil
+		}
+	}
+	return tezos.Z{}, fmt.Errorf("%s: no counter field in storage", c.addr)
+}
+
+// CreateSignedPermit builds a permit for a gasless transfer of this token.
+// All transfers must be sent from the signer's address. The permit counter
+// is read from contract storage at head.
+// Submit the returned permit together with args from any relayer account.
+func (t FA2Token) CreateSignedPermit(ctx context.Context, sk tezos.PrivateKey, args *FA2TransferArgs) (Permit, error) {
+	owner := sk.Address()
+	if len(args.Transfers) == 0 {
+		return Permit{}, fmt.Errorf("%s: empty permit transfer list", t.Address)
+	}
+	for _, v := range args.Transfers {
+		if !v.From.Equal(owner) {
+			return Permit{}, fmt.Errorf("%s: permit transfer from %s not owned by signer %s", t.Address, v.From, owner)
+		}
+	}
+	counter, err := t.contract.PermitCounter(ctx)
+	if err != nil {
+		return Permit{}, err
+	}
+	chain := t.contract.rpc.ChainId
+	if !chain.IsValid() {
+		chain, err = t.contract.rpc.GetChainId(ctx)
+		if err != nil {
+			return Permit{}, err
+		}
+	}
+	args.WithDestination(t.Address)
+	return SignPermit(sk, chain, t.Address, counter, args.Parameters())
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/rpc"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestFA2CreateSignedPermit(t *testing.T) {
+	var (
+		addr  = tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+		to    = tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+		chain = tezos.MustParseChainIdHash("NetXdQprcVkpaWU")
+		sk    = tezos.MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	)
+
+	// pair (big_map %ledger) (pair (nat %counter) (bool %paused))
+	script := micheline.NewScript()
+	script.Code.Storage = micheline.NewCode(micheline.K_STORAGE, micheline.NewPairType(
+		micheline.Prim{
+			Type:   micheline.PrimBinaryAnno,
+			OpCode: micheline.T_BIG_MAP,
+			Args:   []micheline.Prim{micheline.NewPrim(micheline.T_ADDRESS), micheline.NewPrim(micheline.T_NAT)},
+			Anno:   []string{"%ledger"},
+		},
+		micheline.NewPairType(
+			micheline.NewPrim(micheline.T_NAT, "%counter"),
+			micheline.NewPrim(micheline.T_BOOL, "%paused"),
+		),
+	))
+	// the counter increases with every storage read
+	var counter int64 = 41
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/contracts/" + addr.String() + "/storage": func(*http.Request) any {
+			counter++
+			return micheline.NewCode(micheline.D_PAIR, micheline.NewInt64(3), micheline.NewInt64(counter), micheline.NewCode(micheline.D_FALSE))
+		},
+	})
+	store := micheline.NewCode(micheline.D_PAIR, micheline.NewInt64(3), micheline.NewInt64(1), micheline.NewCode(micheline.D_FALSE))
+
+	cli, err := rpc.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.ChainId = chain
+	tok := NewFA2Token(addr, 1, cli)
+	tok.Contract().WithScript(script).WithStorage(&store)
+
+	args := NewFA2TransferArgs().WithTransfer(sk.Address(), to, tok.TokenId, tezos.NewZ(5))
+	permit, err := tok.CreateSignedPermit(context.Background(), sk, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permit.Counter.Int64() != 42 || !permit.Destination.Equal(addr) || !permit.Key.IsEqual(sk.Public()) {
+		t.Errorf("unexpected permit %#v", permit)
+	}
+	hash := tezos.Digest(args.Parameters().Value.Pack())
+	if !bytes.Equal(permit.Hash[:], hash[:]) {
+		t.Errorf("unexpected param hash %s", permit.Hash)
+	}
+	digest := tezos.Digest(PermitPayload(chain, addr, permit.Counter, permit.Hash))
+	if err := permit.Key.Verify(digest[:], permit.Signature); err != nil {
+		t.Errorf("signature verify failed: %v", err)
+	}
+	params := permit.Parameters()
+	if params.Entrypoint != "permit" || len(params.Value.Args) != 1 {
+		t.Errorf("unexpected permit params %s", params.Value.Dump())
+	}
+
+	// later permits from the same instance use the current counter
+	permit, err = tok.CreateSignedPermit(context.Background(), sk, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permit.Counter.Int64() != 43 {
+		t.Errorf("stale permit counter %d", permit.Counter.Int64())
+	}
+
+	// transfers must be sent from the signer
+	other := NewFA2TransferArgs().WithTransfer(to, sk.Address(), tok.TokenId, tezos.NewZ(5))
+	if _, err := tok.CreateSignedPermit(context.Background(), sk, other); err == nil {
+		t.Errorf("expected error for foreign transfer")
+	}
+}
+
+func TestPermitPayloadVector(t *testing.T) {
+	// expected values are packed and hashed independently from this package
+	params := &micheline.Parameters{
+		Entrypoint: "default",
+		Value:      micheline.NewNat(big.NewInt(42)),
+	}
+	hash := PermitHash(params)
+	if got, want := hash.String(), "exprtdWRKjpzTyKa5LwihWFGT9FgDfK8nHAEF7eBPW1KSUAcKtjWUT"; got != want {
+		t.Errorf("param hash: want %s, got %s", want, got)
+	}
+	payload := PermitPayload(
+		tezos.MustParseChainIdHash("NetXdQprcVkpaWU"),
+		tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T"),
+		tezos.NewZ(7),
+		hash,
+	)
+	want := "05070707070a000000047a06a7700a00000016015c149d65c5ca113bc2bc3c861ef6ea8030d7155300070700070a000000200f0db0ce6f057a8835adb6a2c617fd8a136b8028fac90aab7b4766def688ea0c"
+	if got := hex.EncodeToString(payload); got != want {
+		t.Errorf("payload mismatch\nwant=%s\ngot =%s", want, got)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/rpc"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+const (
+	ROYALTIES     = "royalties"
+	GET_ROYALTIES = "get_royalties"
+)
+
+// RoyaltyShare is a single royalty recipient and its share of a sale price
+// in basis points (1/100th of a percent).
+type RoyaltyShare struct {
+	Recipient   tezos.Address `json:"recipient"`
+	BasisPoints int64         `json:"basis_points"`
+}
+
+// GetRoyalties reads royalty information for token tokenId. Known layouts
+// are tried in order:
+//
+//   - on-chain views `royalties` or `get_royalties` (TZIP-21 style)
+//   - TZIP-16 off-chain view `royalties`
+//   - a `royalties` bigmap keyed by token id (Rarible style)
+//
+// Shares are normalized to basis points. Supported value shapes are lists of
+// (address, nat) pairs in basis points and (pair nat (map address nat))
+// where the first nat defines the number of decimals of all shares.
+// Returns nil without error when the contract publishes no royalties.
+func (c *Contract) GetRoyalties(ctx context.Context, tokenId int64) ([]RoyaltyShare, error) {
+	if c.script == nil {
+		if err := c.Resolve(ctx); err != nil {
+			return nil, err
+		}
+	}
+	id := micheline.NewInt64(tokenId)
+
+	// on-chain views
+	for _, name := range []string{ROYALTIES, GET_ROYALTIES} {
+		if _, ok := c.View(name); !ok {
+			continue
+		}
+		prim, err := c.RunView(ctx, name, id)
+		if err != nil {
+			return nil, err
+		}
+		return decodeRoyalties(prim)
+	}
+
+	// off-chain views
+	if tz16, err := c.ResolveMetadata(ctx); err == nil && tz16.HasView(ROYALTIES) {
+		view := tz16.GetView(ROYALTIES)
+		prim, err := view.Run(ctx, c, id)
+		if err != nil {
+			return nil, err
+		}
+		return decodeRoyalties(prim)
+	}
+
+	// royalties bigmap
+	if bigmap, ok := c.script.Bigmaps()[ROYALTIES]; ok {
+		key := (micheline.Key{
+			Type:   micheline.NewType(micheline.NewPrim(micheline.T_NAT)),
+			IntKey: big.NewInt(tokenId),
+		}).Hash()
+		prim, err := c.rpc.GetActiveBigmapValue(ctx, bigmap, key)
+		if err != nil {
+			if rpc.ErrorStatus(err) == http.StatusNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return decodeRoyalties(prim)
+	}
+	return nil, nil
+}
+
+// decodeRoyalties normalizes known royalty value shapes to basis points.
+func decodeRoyalties(p micheline.Prim) ([]RoyaltyShare, error) {
+	switch {
+	case p.OpCode == micheline.D_NONE:
+		return nil, nil
+	case p.OpCode == micheline.D_SOME && len(p.Args) == 1:
+		return decodeRoyalties(p.Args[0])
+	case p.OpCode == micheline.D_PAIR && len(p.Args) == 2 && p.Args[0].Type == micheline.PrimInt && p.Args[1].IsSequence():
+		// (pair (nat %decimals) (map %shares address nat))
+		if !p.Args[0].Int.IsInt64() || p.Args[0].Int.Int64() > 18 {
+			return nil, fmt.Errorf("contract: invalid royalty decimals %s", p.Args[0].Int)
+		}
+		return decodeRoyaltyShares(p.Args[1].Args, int(p.Args[0].Int.Int64()))
+	case p.IsSequence():
+		// (list (pair address nat)) in basis points
+		return decodeRoyaltyShares(p.Args, 4)
+	default:
+		return nil, fmt.Errorf("contract: unsupported royalties %s", p.Dump())
+	}
+}
+
+func decodeRoyaltyShares(elems []micheline.Prim, decimals int) ([]RoyaltyShare, error) {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	shares := make([]RoyaltyShare, 0, len(elems))
+	for _, v := range elems {
+		if (v.OpCode != micheline.D_PAIR && v.OpCode != micheline.D_ELT) || len(v.Args) != 2 || v.Args[1].Type != micheline.PrimInt {
+			return nil, fmt.Errorf("contract: unsupported royalty share %s", v.Dump())
+		}
+		addr, err := micheline.DecodeAddress(v.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		bp := new(big.Int).Mul(v.Args[1].Int, big.NewInt(10000))
+		bp.Quo(bp, scale)
+		if !bp.IsInt64() {
+			return nil, fmt.Errorf("contract: royalty share %s out of range", v.Args[1].Int)
+		}
+		shares = append(shares, RoyaltyShare{
+			Recipient:   addr,
+			BasisPoints: bp.Int64(),
+		})
+	}
+	return shares, nil
+}
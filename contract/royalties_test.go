@@ -0,0 +1,122 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/rpc"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestDecodeRoyalties(t *testing.T) {
+	var (
+		a1 = tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+		a2 = tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	)
+	cases := []struct {
+		name string
+		prim micheline.Prim
+		want []RoyaltyShare
+	}{
+		{
+			name: "rarible",
+			prim: micheline.NewSeq(
+				micheline.NewPair(micheline.NewString(a1.String()), micheline.NewInt64(500)),
+				micheline.NewPair(micheline.NewAddress(a2), micheline.NewInt64(250)),
+			),
+			want: []RoyaltyShare{{a1, 500}, {a2, 250}},
+		},
+		{
+			name: "decimals",
+			prim: micheline.NewPair(
+				micheline.NewInt64(3),
+				micheline.NewMap(
+					micheline.NewMapElem(micheline.NewString(a1.String()), micheline.NewInt64(100)),
+				),
+			),
+			want: []RoyaltyShare{{a1, 1000}},
+		},
+		{
+			name: "option",
+			prim: micheline.NewOption(micheline.NewSeq(
+				micheline.NewPair(micheline.NewString(a2.String()), micheline.NewInt64(1)),
+			)),
+			want: []RoyaltyShare{{a2, 1}},
+		},
+		{
+			name: "none",
+			prim: micheline.NewOption(),
+		},
+	}
+	for _, c := range cases {
+		shares, err := decodeRoyalties(c.prim)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(shares) != len(c.want) {
+			t.Fatalf("%s: have %d shares want %d", c.name, len(shares), len(c.want))
+		}
+		for i := range shares {
+			if !shares[i].Recipient.Equal(c.want[i].Recipient) || shares[i].BasisPoints != c.want[i].BasisPoints {
+				t.Errorf("%s: share %d have %v want %v", c.name, i, shares[i], c.want[i])
+			}
+		}
+	}
+	if _, err := decodeRoyalties(micheline.NewString("foo")); err == nil {
+		t.Errorf("expected error for unsupported shape")
+	}
+}
+
+func TestGetRoyaltiesBigmap(t *testing.T) {
+	addr := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	key := (micheline.Key{
+		Type:   micheline.NewType(micheline.NewPrim(micheline.T_NAT)),
+		IntKey: big.NewInt(7),
+	}).Hash()
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/big_maps/5/" + key.String(): `[{"prim":"Pair","args":[{"string":"` + addr.String() + `"},{"int":"750"}]}]`,
+		"/chains/main/blocks/head/context/big_maps/5/*":               nodetest.Response{Status: http.StatusNotFound},
+		"/*": func(r *http.Request) any {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			return nil
+		},
+	})
+	cli, err := rpc.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typ := micheline.Prim{
+		Type:   micheline.PrimBinaryAnno,
+		OpCode: micheline.T_BIG_MAP,
+		Args: []micheline.Prim{
+			micheline.NewPrim(micheline.T_NAT),
+			micheline.NewCode(micheline.T_LIST, micheline.NewPairType(micheline.NewPrim(micheline.T_ADDRESS), micheline.NewPrim(micheline.T_NAT))),
+		},
+		Anno: []string{"%royalties"},
+	}
+	script := micheline.NewScript()
+	script.Code.Storage = micheline.NewCode(micheline.K_STORAGE, typ)
+	script.Storage = micheline.NewBigmapRef(5)
+	c := NewContract(tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton"), cli).WithScript(script)
+	c.meta = &Tz16{}
+
+	shares, err := c.GetRoyalties(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 1 || !shares[0].Recipient.Equal(addr) || shares[0].BasisPoints != 750 {
+		t.Errorf("unexpected shares %v", shares)
+	}
+	shares, err = c.GetRoyalties(context.Background(), 8)
+	if err != nil || shares != nil {
+		t.Errorf("expected no royalties for unknown token, got %v %v", shares, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"blockwatch.cc/tzgo/micheline"
@@ -51,6 +52,57 @@ func (k TokenKind) IsValid() bool {
 
 const TOKEN_METADATA = "token_metadata"
 
+// MetadataSource describes where a contract publishes its token metadata.
+type MetadataSource byte
+
+const (
+	MetadataSourceInvalid  MetadataSource = iota
+	MetadataSourceBigmap                  // TZIP-12 token_metadata bigmap in storage
+	MetadataSourceView                    // TZIP-16 off-chain token_metadata view
+	MetadataSourceContract                // separate metadata contract referenced from storage
+)
+
+func (s MetadataSource) String() string {
+	switch s {
+	case MetadataSourceBigmap:
+		return "bigmap"
+	case MetadataSourceView:
+		return "view"
+	case MetadataSourceContract:
+		return "contract"
+	default:
+		return ""
+	}
+}
+
+func (s MetadataSource) IsValid() bool {
+	return s != MetadataSourceInvalid
+}
+
+// TokenMetadataSource detects how token metadata for this contract must be
+// resolved. Off-chain views take precedence over the token_metadata bigmap
+// like in ResolveTokenMetadata. Contracts that store the address of a
+// separate metadata contract in a token_metadata storage field are reported
+// as MetadataSourceContract. An invalid source is returned when the contract
+// publishes no token metadata at all.
+func (c *Contract) TokenMetadataSource(ctx context.Context) (MetadataSource, error) {
+	if c.script == nil {
+		if err := c.Resolve(ctx); err != nil {
+			return MetadataSourceInvalid, err
+		}
+	}
+	if tz16, err := c.ResolveMetadata(ctx); err == nil && tz16.HasView(TOKEN_METADATA) {
+		return MetadataSourceView, nil
+	}
+	if _, ok := c.script.Bigmaps()[TOKEN_METADATA]; ok {
+		return MetadataSourceBigmap, nil
+	}
+	if prim, _, ok := c.script.StorageType().FindByName(TOKEN_METADATA); ok && prim.OpCode == micheline.T_ADDRESS {
+		return MetadataSourceContract, nil
+	}
+	return MetadataSourceInvalid, nil
+}
+
 // Represents Tzip12 token metadata used by FA1 and FA2 tokens
 // mixed with TZip21 metadata for NFTs
 type TokenMetadata struct {
@@ -80,14 +132,22 @@ type TokenMetadata struct {
 	Rights             string          `json:"rights,omitempty"`
 	RightUri           string          `json:"rightUri,omitempty"`
 	ExternalUri        string          `json:"externalUri,omitempty"`
+	BlockLevel         int64           `json:"blockLevel,omitempty"`
 	Formats            []Tz21Format    `json:"formats,omitempty"`
 	Attributes         []Tz21Attribute `json:"attributes,omitempty"`
+	Assets             []TokenMetadata `json:"assets,omitempty"`
 
 	// internal
-	uri string          `json:"-"`
-	raw json.RawMessage `json:"-"`
+	uri      string          `json:"-"`
+	raw      json.RawMessage `json:"-"`
+	contract *Contract       `json:"-"`
 }
 
+// Tzip21Metadata is the rich off-chain TZIP-21 metadata document referenced
+// by the empty key in a token's token_info map. It shares its structure with
+// TokenMetadata.
+type Tzip21Metadata = TokenMetadata
+
 type Tz21Format struct {
 	Uri        string        `json:"uri"`
 	Hash       string        `json:"hash"`
@@ -206,6 +266,26 @@ func (t TokenMetadata) URI() string {
 	return t.uri
 }
 
+// ResolveTzip21 fetches and decodes the TZIP-21 document referenced by the
+// empty key in token_info. URIs are resolved like TZIP-16 URIs, i.e. from
+// ipfs, http(s) and tezos-storage. Only metadata read from a contract with
+// ResolveTokenMetadata can be resolved.
+func (t TokenMetadata) ResolveTzip21(ctx context.Context) (*Tzip21Metadata, error) {
+	if t.uri == "" {
+		return nil, fmt.Errorf("token metadata has no tzip21 uri")
+	}
+	if t.contract == nil {
+		return nil, fmt.Errorf("token metadata has no contract to resolve %q", t.uri)
+	}
+	meta := &Tzip21Metadata{IsTransferable: true}
+	if err := t.contract.ResolveTz16Uri(ctx, t.uri, meta, nil); err != nil {
+		return nil, err
+	}
+	meta.uri = t.uri
+	meta.contract = t.contract
+	return meta, nil
+}
+
 func (t TokenMetadata) Raw() []byte {
 	if t.raw != nil {
 		return t.raw
@@ -215,13 +295,8 @@ func (t TokenMetadata) Raw() []byte {
 }
 
 func ResolveTokenMetadata(ctx context.Context, contract *Contract, tokenid tezos.Z) (*TokenMetadata, error) {
-	var (
-		store micheline.Prim
-		err   error
-	)
-
 	// we need contract script and storage
-	if err = contract.Resolve(ctx); err != nil {
+	if err := contract.Resolve(ctx); err != nil {
 		return nil, err
 	}
 
@@ -232,6 +307,53 @@ func ResolveTokenMetadata(ctx context.Context, contract *Contract, tokenid tezos
 
 	// prefer off-chain view via run_code, but don't fail if not present
 	tz16, _ := contract.ResolveMetadata(ctx)
+	return resolveTokenMetadata(ctx, contract, tz16, tokenid)
+}
+
+// ResolveTokenMetadataBatch resolves metadata for multiple token ids of the same
+// contract. Contract script and metadata are loaded once, token metadata is
+// read concurrently. Fails with the first error encountered.
+func ResolveTokenMetadataBatch(ctx context.Context, contract *Contract, ids []tezos.Z) (map[string]*TokenMetadata, error) {
+	if err := contract.Resolve(ctx); err != nil {
+		return nil, err
+	}
+	res := make(map[string]*TokenMetadata, len(ids))
+	if m, ok := wellKnown[contract.Address().String()]; ok {
+		for _, id := range ids {
+			res[id.String()] = m
+		}
+		return res, nil
+	}
+	tz16, _ := contract.ResolveMetadata(ctx)
+
+	var mu sync.Mutex
+	b := contract.rpc.NewBatch(0)
+	for _, id := range ids {
+		id := id
+		b.Add(func(ctx context.Context) error {
+			meta, err := resolveTokenMetadata(ctx, contract, tz16, id)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			res[id.String()] = meta
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := b.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// resolveTokenMetadata reads metadata for a single token from a resolved
+// contract. It does not modify contract and is safe for concurrent use.
+func resolveTokenMetadata(ctx context.Context, contract *Contract, tz16 *Tz16, tokenid tezos.Z) (*TokenMetadata, error) {
+	var (
+		store micheline.Prim
+		err   error
+	)
 	if tz16 != nil && tz16.HasView(TOKEN_METADATA) {
 		view := tz16.GetView(TOKEN_METADATA)
 		args := micheline.NewNat(tokenid.Big())
@@ -257,7 +379,7 @@ func ResolveTokenMetadata(ctx context.Context, contract *Contract, tokenid tezos
 	}
 
 	// parse storage: (pair (nat %token_id) (map %token_info string bytes))
-	meta := &TokenMetadata{}
+	meta := &TokenMetadata{contract: contract}
 	if err := meta.UnmarshalPrim(store); err != nil {
 		return nil, err
 	}
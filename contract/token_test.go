@@ -0,0 +1,198 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package contract
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/rpc"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestTokenMetadataSource(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton")
+	bigmapType := micheline.Prim{
+		Type:   micheline.PrimBinaryAnno,
+		OpCode: micheline.T_BIG_MAP,
+		Args:   []micheline.Prim{micheline.NewPrim(micheline.T_NAT), micheline.NewPrim(micheline.T_BYTES)},
+		Anno:   []string{"%token_metadata"},
+	}
+	newContract := func(typ, store micheline.Prim, views ...string) *Contract {
+		script := micheline.NewScript()
+		script.Code.Storage = micheline.NewCode(micheline.K_STORAGE, typ)
+		script.Storage = store
+		meta := &Tz16{}
+		for _, v := range views {
+			meta.Views = append(meta.Views, Tz16View{Name: v})
+		}
+		c := NewContract(addr, nil).WithScript(script).WithStorage(&store)
+		c.meta = meta
+		return c
+	}
+
+	cases := []struct {
+		name string
+		c    *Contract
+		want MetadataSource
+	}{
+		{
+			name: "bigmap",
+			c: newContract(
+				micheline.NewPairType(bigmapType, micheline.NewPrim(micheline.T_ADDRESS, "%admin")),
+				micheline.NewPair(micheline.NewBigmapRef(5), micheline.NewString(addr.String())),
+			),
+			want: MetadataSourceBigmap,
+		},
+		{
+			name: "view",
+			c: newContract(
+				micheline.NewPairType(bigmapType, micheline.NewPrim(micheline.T_ADDRESS, "%admin")),
+				micheline.NewPair(micheline.NewBigmapRef(5), micheline.NewString(addr.String())),
+				TOKEN_METADATA,
+			),
+			want: MetadataSourceView,
+		},
+		{
+			name: "contract",
+			c: newContract(
+				micheline.NewPairType(micheline.NewPrim(micheline.T_ADDRESS, "%token_metadata"), micheline.NewPrim(micheline.T_NAT, "%supply")),
+				micheline.NewPair(micheline.NewString(addr.String()), micheline.NewInt64(1)),
+			),
+			want: MetadataSourceContract,
+		},
+		{
+			name: "none",
+			c: newContract(
+				micheline.NewPrim(micheline.T_NAT, "%supply"),
+				micheline.NewInt64(1),
+			),
+			want: MetadataSourceInvalid,
+		},
+	}
+	for _, c := range cases {
+		src, err := c.c.TokenMetadataSource(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if src != c.want {
+			t.Errorf("%s: have %q want %q", c.name, src, c.want)
+		}
+	}
+}
+
+func TestVerifyCodeHash(t *testing.T) {
+	script := micheline.NewScript()
+	script.Code.Param = micheline.NewCode(micheline.K_PARAMETER, micheline.NewPrim(micheline.T_UNIT))
+	script.Code.Storage = micheline.NewCode(micheline.K_STORAGE, micheline.NewPrim(micheline.T_UNIT))
+	script.Code.Code = micheline.NewCode(micheline.K_CODE, micheline.NewSeq(
+		micheline.NewCode(micheline.I_CDR),
+		micheline.NewCode(micheline.I_NIL, micheline.NewPrim(micheline.T_OPERATION)),
+		micheline.NewCode(micheline.I_PAIR),
+	))
+	hash := script.CodeExprHash()
+	if want := tezos.MustParseExprHash("expruat2BS4KCwn9kbopeX1ZwxtrtJbyFhpnpnG6A5KdCBCwHNsdod"); !hash.Equal(want) {
+		t.Fatalf("code hash mismatch: have %s want %s", hash, want)
+	}
+	c := NewContract(tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton"), nil).WithScript(script)
+	if err := c.VerifyCodeHash(context.Background(), hash); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// a different storage type must change the hash
+	other := *script
+	other.Code.Storage = micheline.NewCode(micheline.K_STORAGE, micheline.NewPrim(micheline.T_NAT))
+	if other.CodeExprHash().Equal(hash) {
+		t.Errorf("expected different hash for different code")
+	}
+	c.WithScript(&other)
+	if err := c.VerifyCodeHash(context.Background(), hash); err == nil {
+		t.Errorf("expected code hash mismatch")
+	}
+}
+
+func TestResolveTzip21(t *testing.T) {
+	const doc = `{
+		"name": "Tezzard #1",
+		"decimals": 0,
+		"isBooleanAmount": true,
+		"artifactUri": "ipfs://QmArtifact",
+		"creators": ["tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"],
+		"blockLevel": 3000000,
+		"formats": [{"uri": "ipfs://QmArtifact", "mimeType": "image/png", "dimensions": {"value": "512x512", "unit": "px"}}],
+		"attributes": [{"name": "eyes", "value": "laser"}]
+	}`
+	key := (micheline.Key{
+		Type:   micheline.NewType(micheline.NewPrim(micheline.T_NAT)),
+		IntKey: big.NewInt(1),
+	}).Hash()
+	var fetches int
+	var uri string
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/big_maps/3/" + key.String(): func(*http.Request) any {
+			return `{"prim":"Pair","args":[{"int":"1"},[{"prim":"Elt","args":[{"string":""},{"bytes":"` + hex.EncodeToString([]byte(uri)) + `"}]}]]}`
+		},
+		"/meta.json": func(*http.Request) any {
+			fetches++
+			return doc
+		},
+		"/*": func(r *http.Request) any {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			return nil
+		},
+	})
+	uri = srv.URL + "/meta.json"
+	cli, err := rpc.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typ := micheline.Prim{
+		Type:   micheline.PrimBinaryAnno,
+		OpCode: micheline.T_BIG_MAP,
+		Args: []micheline.Prim{
+			micheline.NewPrim(micheline.T_NAT),
+			micheline.NewPairType(micheline.NewPrim(micheline.T_NAT), micheline.NewMapType(micheline.NewPrim(micheline.T_STRING), micheline.NewPrim(micheline.T_BYTES))),
+		},
+		Anno: []string{"%token_metadata"},
+	}
+	script := micheline.NewScript()
+	script.Code.Storage = micheline.NewCode(micheline.K_STORAGE, typ)
+	script.Storage = micheline.NewBigmapRef(3)
+	c := NewContract(tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton"), cli).WithScript(script)
+
+	meta, err := resolveTokenMetadata(context.Background(), c, nil, tezos.NewZ(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.URI() != uri {
+		t.Errorf("uri: have %q want %q", meta.URI(), uri)
+	}
+	tz21, err := meta.ResolveTzip21(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("expected 2 document fetches, got %d", fetches)
+	}
+	if tz21.Name != "Tezzard #1" || !tz21.IsBooleanAmount || !tz21.IsTransferable || tz21.BlockLevel != 3000000 {
+		t.Errorf("unexpected metadata %#v", tz21)
+	}
+	if len(tz21.Creators) != 1 || len(tz21.Formats) != 1 || tz21.Formats[0].Dimensions.Value != "512x512" {
+		t.Errorf("unexpected creators or formats %#v", tz21)
+	}
+	if len(tz21.Attributes) != 1 || tz21.Attributes[0].Value != "laser" {
+		t.Errorf("unexpected attributes %#v", tz21.Attributes)
+	}
+
+	// metadata not read from a contract cannot be resolved
+	if _, err := (TokenMetadata{uri: uri}).ResolveTzip21(context.Background()); err == nil {
+		t.Errorf("expected error for unbound metadata")
+	}
+}
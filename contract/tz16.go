@@ -301,6 +301,8 @@ func (c *Contract) resolveHttpUri(ctx context.Context, uri string, result interf
 	if err != nil {
 		return err
 	}
+	ctx, cancel := c.rpc.CallContext(ctx)
+	defer cancel()
 	req = req.WithContext(ctx)
 	req.Header.Add("Accept", "text/plain; charset=utf-8")
 	req.Header.Add("User-Agent", c.rpc.UserAgent)
@@ -111,5 +111,14 @@ func blinded() error {
 	fmt.Printf("Address %s\n", addr.String())
 	fmt.Printf("Hash    %x\n", addr.Hash())
 	fmt.Printf("Blinded %s\n", blind.String())
+
+	// optionally verify against a known commitment
+	if flags.NArg() > 2 {
+		commitment, err := tezos.ParseAddress(flags.Arg(2))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Valid   %t\n", tezos.VerifyBlindedAddress(commitment, addr, secret))
+	}
 	return nil
 }
@@ -333,7 +333,7 @@ func info(ctx context.Context, c *rpc.Client, addr string) error {
 	fmt.Printf("FA1.2        %t\n", con.IsFA12())
 	fmt.Printf("FA2          %t\n", con.IsFA2())
 	fmt.Printf("Manager.tz   %t\n", con.IsManagerTz())
-	eps, _ := con.Script().Entrypoints(true)
+	eps := con.Script().EntrypointsWithDefault()
 	fmt.Printf("Entrypoints  %d\n", len(eps))
 	rows := make([][]string, 0, len(eps))
 	for n, ep := range eps {
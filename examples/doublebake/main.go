@@ -19,7 +19,6 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"errors"
@@ -35,7 +34,6 @@ import (
 	"blockwatch.cc/tzgo/signer"
 	"blockwatch.cc/tzgo/tezos"
 	"github.com/echa/log"
-	"golang.org/x/crypto/blake2b"
 )
 
 var (
@@ -105,11 +103,11 @@ func run() error {
 
 // watch for next block with endorsing rights
 func monitorBlocks(ctx context.Context, c *rpc.Client) error {
-	mon := rpc.NewBlockHeaderMonitor()
+	mon := rpc.NewBlockHeaderReconnector(c)
+	mon.WithCallback(func(e rpc.ReconnectEvent) {
+		log.Warnf("Monitor closed: %v, reconnecting in %s", e.Err, e.Delay)
+	})
 	defer mon.Close()
-	if err := c.MonitorBlockHeader(ctx, mon); err != nil {
-		return err
-	}
 
 	ctx2, cancel := context.WithCancel(ctx)
 	stop := make(chan os.Signal, 1)
@@ -172,13 +170,13 @@ func handleBlock(ctx context.Context, c *rpc.Client, b *rpc.BlockHeaderLogEntry)
 }
 
 func fetchEndorsingRights(ctx context.Context, c *rpc.Client, id tezos.BlockHash) (int, bool, error) {
-	u := fmt.Sprintf("chains/main/blocks/%s/helpers/endorsing_rights?delegate=%s", id, sk.Address())
+	u := fmt.Sprintf("chains/main/blocks/%s/helpers/endorsing_rights", id)
 	var rights []struct {
 		Level         int64                `json:"level"`
 		Delegates     []rpc.EndorsingRight `json:"delegates"`
 		EstimatedTime time.Time            `json:"estimated_time"`
 	}
-	if err := c.Get(ctx, u, &rights); err != nil {
+	if err := c.GetWithParams(ctx, u, rpc.NewQuery().WithDelegate(sk.Address()).Values(), &rights); err != nil {
 		return 0, false, err
 	}
 	if len(rights) == 0 {
@@ -189,16 +187,14 @@ func fetchEndorsingRights(ctx context.Context, c *rpc.Client, id tezos.BlockHash
 
 func createDoubleEndorse(c *rpc.Client, b *rpc.BlockHeaderLogEntry, slot int) *codec.TenderbakeDoubleEndorsementEvidence {
 	log.Infof("Creating 2endorse evidence")
-	o1, oh1 := signEndorsement(c, b, slot, false)
-	o2, oh2 := signEndorsement(c, b, slot, true)
-	// FIXME: order endorsements by op hash
-	if bytes.Compare(oh1[:], oh2[:]) > 0 {
-		o1, o2 = o2, o1
+	ev := &codec.TenderbakeDoubleEndorsementEvidence{
+		Op1: signEndorsement(c, b, slot, false),
+		Op2: signEndorsement(c, b, slot, true),
 	}
-	return &codec.TenderbakeDoubleEndorsementEvidence{
-		Op1: o1,
-		Op2: o2,
+	if err := ev.Normalize(c.Params); err != nil {
+		log.Errorf("normalizing 2endorse evidence: %v", err)
 	}
+	return ev
 }
 
 func sendDoubleEndorse(ctx context.Context, c *rpc.Client, b *rpc.BlockHeaderLogEntry, ev *codec.TenderbakeDoubleEndorsementEvidence) error {
@@ -219,7 +215,7 @@ func sendDoubleEndorse(ctx context.Context, c *rpc.Client, b *rpc.BlockHeaderLog
 	return nil
 }
 
-func signEndorsement(c *rpc.Client, b *rpc.BlockHeaderLogEntry, slot int, random bool) (codec.TenderbakeInlinedEndorsement, tezos.OpHash) {
+func signEndorsement(c *rpc.Client, b *rpc.BlockHeaderLogEntry, slot int, random bool) codec.TenderbakeInlinedEndorsement {
 	e := codec.TenderbakeEndorsement{
 		Slot:             int16(slot),
 		Level:            int32(b.Level),
@@ -241,13 +237,5 @@ func signEndorsement(c *rpc.Client, b *rpc.BlockHeaderLogEntry, slot int, random
 		Branch:      b.Hash,
 		Endorsement: e,
 		Signature:   op.Signature,
-	}, ophash(op.Digest())
-}
-
-// FIXME: what's the correct method to calculate op hash from contents?
-func ophash(buf []byte) (oh tezos.OpHash) {
-	h, _ := blake2b.New(32, nil)
-	h.Write(buf)
-	copy(oh[:], h.Sum(nil))
-	return
+	}
 }
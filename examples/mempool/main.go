@@ -142,30 +142,25 @@ func info(ctx context.Context, c *rpc.Client) error {
 }
 
 func stream(ctx context.Context, c *rpc.Client, flt string) error {
-	var mon *rpc.MempoolMonitor
-	for {
-		if mon == nil {
-			fmt.Println("Connecting monitor")
-			mon = rpc.NewMempoolMonitor()
-			if err := c.MonitorMempool(ctx, mon); err != nil {
-				mon.Close()
-				return err
-			}
+	mon := rpc.NewMempoolReconnector(c)
+	mon.WithCallback(func(e rpc.ReconnectEvent) {
+		if e.Err != io.EOF {
+			fmt.Printf("Monitor failed: %v, reconnecting in %s\n", e.Err, e.Delay)
+			return
+		}
+		head, err := c.GetTipHeader(ctx)
+		if err == nil {
+			fmt.Println("Monitor closed on new block", head.Level, head.Hash)
+		} else {
+			fmt.Println("Monitor closed:", err)
 		}
-		ops, err := mon.Recv(ctx)
+	})
+	defer mon.Close()
+	for {
+		// skip ops the node re-sends after each reconnect
+		ops, err := mon.RecvNew(ctx)
 		if err != nil {
-			if err != io.EOF {
-				fmt.Println("Monitor failed")
-				return err
-			}
-			head, err := c.GetTipHeader(ctx)
-			if err == nil {
-				fmt.Println("Monitor closed on new block", head.Level, head.Hash)
-			} else {
-				fmt.Println("Monitor closed:", err)
-			}
-			mon = nil
-			continue
+			return err
 		}
 		for _, op := range ops {
 			fmt.Println(op.Hash, op.Contents[0].Kind())
@@ -26,7 +26,7 @@ func init() {
 	flags.BoolVar(&verbose, "v", false, "be verbose")
 	flags.StringVar(&node, "node", "https://rpc.tzpro.io", "node url")
 	flags.StringVar(&proto, "proto", "", "simulate with protocol")
-	flags.StringVar(&net, "net", "", "simulate with network")
+	flags.StringVar(&net, "net", "", "simulate with network (name or chain id)")
 }
 
 func main() {
@@ -79,10 +79,12 @@ func run() error {
 		}
 	}
 	if net != "" {
-		p.ChainId, err = tezos.ParseChainIdHash(net)
+		np, err := tezos.ParamsForNetwork(net)
 		if err != nil {
 			return err
 		}
+		p.ChainId = np.ChainId
+		p.Network = np.Network
 	}
 	fmt.Printf("Using protocol %s on %s\n", tezos.Short(p.Protocol.String())[:8], p.Network)
 
@@ -203,13 +203,16 @@ func printHead(h *rpc.BlockHeader) {
 func printBlock(b *rpc.Block) {
 	fmt.Printf("Height   %d (%d)\n", b.GetLevel(), b.GetCycle())
 	fmt.Printf("Block    %s\n", b.Hash)
-	if b.Header.PayloadHash.IsValid() {
-		fmt.Printf("Round    %d\n", b.Header.PayloadRound)
-		fmt.Printf("Payload  %s\n", b.Header.PayloadHash)
+	if b.PayloadHash().IsValid() {
+		fmt.Printf("Round    %d\n", b.Round())
+		fmt.Printf("Payload  %s\n", b.PayloadHash())
 	} else {
-		fmt.Printf("Priority %d\n", b.Header.Priority)
+		fmt.Printf("Priority %d\n", b.Round())
 		fmt.Printf("Payload  %s\n", b.Header.OperationsHash)
 	}
+	fmt.Printf("Proposer %s\n", b.Proposer())
+	fmt.Printf("Baker    %s\n", b.Baker())
+	fmt.Printf("Gas      %d\n", b.ConsumedGas())
 	fmt.Printf("Parent   %s\n", b.Header.Predecessor)
 	fmt.Printf("Time     %s\n", b.Header.Timestamp)
 
@@ -562,14 +565,18 @@ func showOpInfo(ctx context.Context, c *rpc.Client, bh rpc.BlockID, list, pos in
 	for i, o := range op.Contents {
 		fmt.Printf("Part   %d\n", i+1)
 		fmt.Printf("  Type       %s\n", o.Kind())
+		if src := o.GetSource(); src.IsValid() {
+			fmt.Printf("  Source     %s\n", src)
+		}
+		fmt.Printf("  Status     %s\n", o.Result().Status)
+		fmt.Printf("  Fee        %d\n", o.GetFee())
+		fmt.Printf("  Gas        %d/%d\n", o.Costs().GasUsed, o.Limits().GasLimit)
 		switch o.Kind() {
 		case tezos.OpTypeTransaction:
 			tx := o.(*rpc.Transaction)
 			fmt.Printf("  Dest       %s\n", tx.Destination)
-			fmt.Printf("  Fee        %d\n", tx.Fee)
 			fmt.Printf("  Counter    %d\n", tx.Counter)
 			fmt.Printf("  Amount     %d\n", tx.Amount)
-			fmt.Printf("  Gas        %d/%d\n", tx.Metadata.Result.ConsumedGas, tx.GasLimit)
 			fmt.Printf("  Storage    %d/%d\n", tx.Metadata.Result.PaidStorageSizeDiff, tx.StorageLimit)
 			fmt.Printf("  Internal   %d (not shown)\n", len(tx.Metadata.InternalResults))
 			var script *micheline.Script
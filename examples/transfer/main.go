@@ -126,10 +126,19 @@ func transfer(ctx context.Context, c *rpc.Client) error {
 	fmt.Println("Costs")
 	fmt.Printf("  Total             %d\n", total.Fee+total.StorageBurn+total.AllocationBurn)
 	fmt.Printf("    Baker Fee       %d\n", total.Fee)
+	if reveal, ok := op.Contents[0].(*codec.Reveal); ok {
+		fmt.Printf("      of which Reveal %d\n", reveal.EstimatedCost(op.Params).Fee)
+	}
 	fmt.Printf("    Storage burn    %d\n", total.StorageBurn)
 	fmt.Printf("    Allocation burn %d\n", total.AllocationBurn)
 	fmt.Printf("  Gas used          %d\n", total.GasUsed)
 	fmt.Printf("  Storage bytes     %d\n", total.StorageUsed)
+	for i, v := range op.Contents {
+		if rcpt.HasAllocation(i) {
+			fmt.Printf("  Note: transfer %d allocated new account %s (allocation burn)\n",
+				i, v.(*codec.Transaction).Destination)
+		}
+	}
 
 	if !rcpt.IsSuccess() {
 		return fmt.Errorf("Transfer failed: %v", rcpt.Error())
@@ -200,82 +200,9 @@ func run() error {
 }
 
 func makeOp(c *rpc.Client, t, data string) (codec.Operation, error) {
-	var o codec.Operation
-	switch tezos.ParseOpType(t) {
-	case tezos.OpTypeActivateAccount:
-		o = new(codec.ActivateAccount)
-	case tezos.OpTypeDoubleBakingEvidence:
-		o = new(codec.DoubleBakingEvidence)
-	case tezos.OpTypeDoubleEndorsementEvidence:
-		if c.Params.OperationTagsVersion < 2 {
-			o = new(codec.DoubleEndorsementEvidence)
-		} else {
-			o = new(codec.TenderbakeDoubleEndorsementEvidence)
-		}
-	case tezos.OpTypeDoublePreendorsementEvidence:
-		o = new(codec.TenderbakeDoublePreendorsementEvidence)
-	case tezos.OpTypeSeedNonceRevelation:
-		o = new(codec.SeedNonceRevelation)
-	case tezos.OpTypeTransaction:
-		o = new(codec.Transaction)
-	case tezos.OpTypeOrigination:
-		o = new(codec.Origination)
-	case tezos.OpTypeDelegation:
-		o = new(codec.Delegation)
-	case tezos.OpTypeReveal:
-		o = new(codec.Reveal)
-	case tezos.OpTypePreendorsement:
-		o = new(codec.TenderbakePreendorsement)
-	case tezos.OpTypeEndorsement:
-		if c.Params.OperationTagsVersion < 2 {
-			o = new(codec.Endorsement)
-		} else {
-			o = new(codec.TenderbakeEndorsement)
-		}
-	case tezos.OpTypeEndorsementWithSlot:
-		o = new(codec.EndorsementWithSlot)
-	case tezos.OpTypeProposals:
-		o = new(codec.Proposals)
-	case tezos.OpTypeBallot:
-		o = new(codec.Ballot)
-	case tezos.OpTypeFailingNoop:
-		o = new(codec.FailingNoop)
-	case tezos.OpTypeRegisterConstant:
-		o = new(codec.RegisterGlobalConstant)
-	case tezos.OpTypeSetDepositsLimit:
-		o = new(codec.SetDepositsLimit)
-	case tezos.OpTypeTransferTicket:
-		o = new(codec.TransferTicket)
-	case tezos.OpTypeVdfRevelation:
-		o = new(codec.VdfRevelation)
-	case tezos.OpTypeIncreasePaidStorage:
-		o = new(codec.IncreasePaidStorage)
-	case tezos.OpTypeDrainDelegate:
-		o = new(codec.DrainDelegate)
-	case tezos.OpTypeUpdateConsensusKey:
-		o = new(codec.UpdateConsensusKey)
-	case tezos.OpTypeSmartRollupOriginate:
-		o = new(codec.SmartRollupOriginate)
-	case tezos.OpTypeSmartRollupAddMessages:
-		o = new(codec.SmartRollupAddMessages)
-	case tezos.OpTypeSmartRollupCement:
-		o = new(codec.SmartRollupCement)
-	case tezos.OpTypeSmartRollupPublish:
-		o = new(codec.SmartRollupPublish)
-	case tezos.OpTypeSmartRollupRefute:
-		o = new(codec.SmartRollupRefute)
-	case tezos.OpTypeSmartRollupTimeout:
-		o = new(codec.SmartRollupTimeout)
-	case tezos.OpTypeSmartRollupExecuteOutboxMessage:
-		o = new(codec.SmartRollupExecuteOutboxMessage)
-	case tezos.OpTypeSmartRollupRecoverBond:
-		o = new(codec.SmartRollupRecoverBond)
-	case tezos.OpTypeDalAttestation:
-		o = new(codec.DalAttestation)
-	case tezos.OpTypeDalPublishSlotHeader:
-		o = new(codec.DalPublishSlotHeader)
-	default:
-		return nil, fmt.Errorf("Unsupported op type %q", t)
+	o, err := codec.OperationTypeForProtocol(tezos.ParseOpType(t), c.Params.Protocol)
+	if err != nil {
+		return nil, err
 	}
 	if err := json.Unmarshal([]byte(data), &o); err != nil {
 		return nil, err
@@ -510,7 +437,7 @@ func simulate(ctx context.Context, c *rpc.Client, msg string) error {
 	if es, ok := op.Contents[0].(*codec.EndorsementWithSlot); ok {
 		head, _ := c.GetBlockHash(ctx, rpc.Head)
 		fmt.Println("Setting block hash", head)
-		es.Endorsement.Branch = head
+		es.SyncBranch(head)
 		op.WithBranch(head)
 	}
 	res, err := c.Simulate(ctx, op, nil)
@@ -544,6 +471,9 @@ func broadcast(ctx context.Context, c *rpc.Client, msg, sig string) error {
 		return err
 	}
 	op.WithSignature(s)
+	if verbose {
+		fmt.Println(op.InjectCurl(node))
+	}
 	hash, err := c.Broadcast(ctx, op)
 	if err != nil {
 		return err
@@ -28,7 +28,12 @@ func run() error {
 		if p, err = p.UnpackAll(); err != nil {
 			return err
 		}
-		fmt.Println(p.Dump())
+		typ := p.InferType()
+		buf, err := m.NewValue(typ, p).MarshalJSON()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Type  %s\nValue %s\n", typ.Typedef("").String(), string(buf))
 	} else {
 		var p micheline.Prim
 		if err := p.UnmarshalBinary(buf); err != nil {
@@ -41,7 +41,9 @@ func (e *Engine) Run(ctx compose.Context, fname string) error {
 			return err
 		}
 		ctx.Log.Infof("Running pipeline %s", p.Name)
-		for i, task := range p.Tasks[ctx.Cache().Get():] {
+		// task indexes are absolute, also when resuming a pipeline
+		for i := ctx.Cache().Get(); i < len(p.Tasks); i++ {
+			task := p.Tasks[i]
 			ctx.SwitchLogger(fmt.Sprintf("%s[%d/%d]", p.Name, i+1, p.Len()), task.Log)
 			if task.Skip {
 				if err := ctx.Cache().Update(i); err != nil {
@@ -80,7 +82,18 @@ func (e *Engine) Run(ctx compose.Context, fname string) error {
 				}
 			} else {
 				// log receipt
-				ctx.Log.Infof("%s SUCCESS block=%d hash=%s", t.Type(), rcpt.Height, rcpt.Op.Hash)
+				costs := rcpt.TotalCosts()
+				ctx.Log.Infof("%s SUCCESS block=%d hash=%s gas=%d storage=%d fee=%d burn=%d",
+					t.Type(), rcpt.Height, rcpt.Op.Hash, costs.GasUsed, costs.StorageUsed, costs.Fee, costs.Burn)
+				ctx.Cache().AddCosts(costs)
+				ctx.AddResult(compose.Result{
+					Pipeline: p.Name,
+					Index:    i,
+					Type:     t.Type(),
+					Height:   rcpt.Height,
+					Hash:     rcpt.Op.Hash,
+					Costs:    costs,
+				})
 			}
 
 			// handle receipt
@@ -96,6 +109,8 @@ func (e *Engine) Run(ctx compose.Context, fname string) error {
 			}
 		}
 		ctx.RestoreLogger()
+		c := ctx.Cache().Costs()
+		ctx.Log.Infof("Pipeline %s costs fee=%d burn=%d gas=%d storage=%d", p.Name, c.Fee, c.Burn, c.GasUsed, c.StorageUsed)
 	}
 	return nil
 }
@@ -3,7 +3,6 @@
 package task
 
 import (
-	"bytes"
 	"crypto/rand"
 	"fmt"
 
@@ -72,21 +71,20 @@ func (t *DoubleEndorseTask) Build(ctx compose.Context, task alpha.Task) (*codec.
 	}
 
 	// produce random endorsements
-	e1, h1 := t.randomEndorsement(ctx, head, slot)
-	e2, h2 := t.randomEndorsement(ctx, head, slot)
+	ev := &codec.TenderbakeDoubleEndorsementEvidence{
+		Op1: t.randomEndorsement(ctx, head, slot),
+		Op2: t.randomEndorsement(ctx, head, slot),
+	}
 
 	// order endorsements by op hash
-	if bytes.Compare(h1[:], h2[:]) > 0 {
-		e1, e2 = e2, e1
+	if err := ev.Normalize(ctx.Params()); err != nil {
+		return nil, nil, err
 	}
 
 	// pack into evidence op
 	op := codec.NewOp().
 		WithSource(t.Source). // required for compose simulation mode
-		WithContents(&codec.TenderbakeDoubleEndorsementEvidence{
-			Op1: e1,
-			Op2: e2,
-		})
+		WithContents(ev)
 
 	// wait one block for sending
 	ctx.Log.Debug("Wait next block")
@@ -115,7 +113,7 @@ func (t *DoubleEndorseTask) parse(ctx compose.Context, task alpha.Task) (err err
 	return
 }
 
-func (t *DoubleEndorseTask) randomEndorsement(ctx compose.Context, head *rpc.BlockHeaderLogEntry, slot int) (codec.TenderbakeInlinedEndorsement, tezos.OpHash) {
+func (t *DoubleEndorseTask) randomEndorsement(ctx compose.Context, head *rpc.BlockHeaderLogEntry, slot int) codec.TenderbakeInlinedEndorsement {
 	// generate a random endorsement for the latest block
 	e := codec.TenderbakeEndorsement{
 		Slot:  int16(slot),
@@ -143,7 +141,7 @@ func (t *DoubleEndorseTask) randomEndorsement(ctx compose.Context, head *rpc.Blo
 		Endorsement: e,
 		Signature:   op.Signature,
 	}
-	return ed, op.Hash()
+	return ed
 }
 
 func (t *DoubleEndorseTask) fetchEndorsingRights(ctx compose.Context, addr tezos.Address, id tezos.BlockHash) (int, bool, error) {
@@ -4,10 +4,13 @@
 package compose
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+
+	"blockwatch.cc/tzgo/tezos"
 )
 
 var (
@@ -23,8 +26,15 @@ func init() {
 }
 
 type PipelineCache struct {
-	hash uint64
-	last int
+	hash  uint64
+	last  int
+	costs tezos.Costs
+}
+
+// pipelineState is the persisted resume state of a pipeline.
+type pipelineState struct {
+	Last  int         `json:"last"`
+	Costs tezos.Costs `json:"costs"`
 }
 
 func NewCache() *PipelineCache {
@@ -36,15 +46,32 @@ func NewCache() *PipelineCache {
 
 func (c *PipelineCache) Update(idx int) error {
 	c.last = idx
-	return writeFile(c.hash, []byte(strconv.Itoa(c.last)))
+	buf, err := json.Marshal(pipelineState{Last: c.last, Costs: c.costs})
+	if err != nil {
+		return err
+	}
+	return writeFile(c.hash, buf)
 }
 
 func (c PipelineCache) Get() int {
 	return c.last
 }
 
+// AddCosts adds costs of an executed task to the pipeline's cumulative costs
+// which are persisted on next Update.
+func (c *PipelineCache) AddCosts(costs tezos.Costs) {
+	c.costs = c.costs.Add(costs)
+}
+
+// Costs returns cumulative costs of all executed tasks including tasks
+// run before a resume.
+func (c PipelineCache) Costs() tezos.Costs {
+	return c.costs
+}
+
 func (c *PipelineCache) Load(hash uint64, reset bool) error {
 	c.hash = hash
+	c.costs = tezos.Costs{}
 	if reset {
 		return c.Update(0)
 	}
@@ -55,12 +82,16 @@ func (c *PipelineCache) Load(hash uint64, reset bool) error {
 		}
 		c.last = -1
 
+	} else if num, err := strconv.Atoi(string(buf)); err == nil {
+		// legacy state without costs
+		c.last = num
 	} else {
-		num, err := strconv.Atoi(string(buf))
-		if err != nil {
+		var state pipelineState
+		if err := json.Unmarshal(buf, &state); err != nil {
 			return fmt.Errorf("parsing cache file %016x: %v", hash, err)
 		}
-		c.last = num
+		c.last = state.Last
+		c.costs = state.Costs
 	}
 	return nil
 }
@@ -45,6 +45,7 @@ type Context struct {
 	resume       bool        // continue pipeline execution were we left off
 	mode         RunMode     // selected engine run mode
 	cache        *PipelineCache
+	summary      *Summary
 	savedLoggers [2]log.Logger
 }
 
@@ -57,6 +58,7 @@ func NewContext(ctx context.Context) Context {
 		Variables: make(map[string]string),
 		Log:       log.Disabled,
 		cache:     NewCache(),
+		summary:   &Summary{},
 	}
 }
 
@@ -122,6 +124,16 @@ func (c *Context) Cache() *PipelineCache {
 	return c.cache
 }
 
+// Summary returns task results and total costs collected during execution.
+func (c *Context) Summary() *Summary {
+	return c.summary
+}
+
+// AddResult records costs of an executed task in the context summary.
+func (c *Context) AddResult(r Result) {
+	c.summary.Add(r)
+}
+
 func (c *Context) Init() (err error) {
 	if !c.BaseAccount.PrivateKey.IsValid() {
 		err = ErrNoBaseKey
@@ -0,0 +1,34 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc, abdul@blockwatch.cc
+
+package compose
+
+import (
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// Result reports resource usage of a single executed pipeline task.
+type Result struct {
+	Pipeline string
+	Index    int
+	Type     string
+	Height   int64
+	Hash     tezos.OpHash
+	Costs    tezos.Costs
+}
+
+// Summary collects task results across all pipelines run in a context.
+type Summary struct {
+	Results []Result
+	Total   tezos.Costs
+}
+
+func (s *Summary) Add(r Result) {
+	s.Results = append(s.Results, r)
+	s.Total = s.Total.Add(r.Costs)
+}
+
+func logCosts(ctx Context, prefix string, c tezos.Costs) {
+	ctx.Log.Infof("%s fee=%d burn=%d gas=%d storage=%d storage_burn=%d allocation_burn=%d",
+		prefix, c.Fee, c.Burn, c.GasUsed, c.StorageUsed, c.StorageBurn, c.AllocationBurn)
+}
@@ -36,6 +36,7 @@ func Run(ctx Context, fpath string, mode RunMode) error {
 	if err != nil {
 		return err
 	}
+	defer logSummary(ctx, mode)
 	if finfo.IsDir() {
 		isFirst := true
 		return filepath.WalkDir(fpath, func(path string, d fs.DirEntry, err error) error {
@@ -94,3 +95,15 @@ func handleFile(ctx Context, fpath string, mode RunMode) error {
 	}
 	return nil
 }
+
+func logSummary(ctx Context, mode RunMode) {
+	sum := ctx.Summary()
+	if mode == RunModeValidate || len(sum.Results) == 0 {
+		return
+	}
+	ctx.Log.Infof("Summary: %d tasks executed", len(sum.Results))
+	for _, r := range sum.Results {
+		logCosts(ctx, fmt.Sprintf("  %s[%d] %s", r.Pipeline, r.Index+1, r.Type), r.Costs)
+	}
+	logCosts(ctx, "Total", sum.Total)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package nodetest provides a stub Tezos node for use in tests.
+package nodetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Routes maps request paths to stub responses. Paths ending in '*' match any
+// request path with this prefix, exact paths take precedence and among
+// prefixes the longest match wins. Supported values are
+//
+//   - string or []byte: raw JSON body
+//   - Response: body sent with a custom status code
+//   - http.Handler or func(http.ResponseWriter, *http.Request): custom handler
+//   - func(*http.Request) any: value computed per request, nil replies 404
+//   - any other value: JSON encoded body
+//
+// Requests to unknown paths reply 404.
+type Routes map[string]any
+
+// Response is a stub response with an explicit HTTP status code.
+type Response struct {
+	Status int
+	Body   any
+}
+
+// NewServer starts a stub node serving routes. The server is closed when
+// the test ends.
+func NewServer(t testing.TB, routes Routes) *httptest.Server {
+	srv := httptest.NewServer(Handler(routes))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Handler returns a http.Handler serving routes.
+func Handler(routes Routes) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := routes.match(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, r, v)
+	})
+}
+
+func (m Routes) match(path string) (any, bool) {
+	if v, ok := m[path]; ok {
+		return v, true
+	}
+	var (
+		best string
+		val  any
+		ok   bool
+	)
+	for k, v := range m {
+		if !strings.HasSuffix(k, "*") {
+			continue
+		}
+		prefix := strings.TrimSuffix(k, "*")
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if !ok || len(prefix) > len(best) {
+			best, val, ok = prefix, v, true
+		}
+	}
+	return val, ok
+}
+
+func serve(w http.ResponseWriter, r *http.Request, v any) {
+	switch val := v.(type) {
+	case http.Handler:
+		val.ServeHTTP(w, r)
+	case func(http.ResponseWriter, *http.Request):
+		val(w, r)
+	case func(*http.Request) any:
+		res := val(r)
+		if res == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, r, res)
+	case Response:
+		write(w, val.Status, val.Body)
+	default:
+		write(w, http.StatusOK, val)
+	}
+}
+
+func write(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	switch val := v.(type) {
+	case string:
+		_, _ = w.Write([]byte(val))
+	case []byte:
+		_, _ = w.Write(val)
+	default:
+		_ = json.NewEncoder(w).Encode(val)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"fmt"
+	"strings"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// DecodeAddress decodes an address, contract or key_hash value from its
+// readable string or optimized bytes encoding. Entrypoint suffixes as used
+// in contract values are stripped.
+func DecodeAddress(p Prim) (tezos.Address, error) {
+	var a tezos.Address
+	switch p.Type {
+	case PrimString:
+		s, _, _ := strings.Cut(p.String, "%")
+		addr, err := tezos.ParseAddress(s)
+		if err != nil {
+			return tezos.InvalidAddress, fmt.Errorf("micheline: invalid address string %q: %w", p.String, err)
+		}
+		a = addr
+	case PrimBytes:
+		if err := a.Decode(p.Bytes); err != nil {
+			return tezos.InvalidAddress, fmt.Errorf("micheline: invalid address bytes %x: %w", p.Bytes, err)
+		}
+	default:
+		return tezos.InvalidAddress, fmt.Errorf("micheline: unexpected %s prim for address", p.Type)
+	}
+	if !a.IsValid() {
+		return tezos.InvalidAddress, fmt.Errorf("micheline: invalid address %s", p.Dump())
+	}
+	return a, nil
+}
+
+// DecodeKeyHash decodes a key_hash value from its readable string or optimized
+// bytes encoding. Only implicit account addresses are accepted.
+func DecodeKeyHash(p Prim) (tezos.Address, error) {
+	a, err := DecodeAddress(p)
+	if err != nil {
+		return a, err
+	}
+	if !a.IsEOA() {
+		return tezos.InvalidAddress, fmt.Errorf("micheline: %s is not a key hash", a)
+	}
+	return a, nil
+}
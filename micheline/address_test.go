@@ -0,0 +1,53 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"testing"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	tz1 := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	kt1 := tezos.MustParseAddress("KT1VgXHLXRgh6J5iGw4zkk7vUfjLoPhRnt9L")
+	tests := []struct {
+		Name    string
+		Prim    Prim
+		Want    tezos.Address
+		KeyHash bool
+	}{
+		{"tz1_string", NewString(tz1.String()), tz1, true},
+		{"tz1_bytes_22", NewBytes(tz1.EncodePadded()), tz1, true},
+		{"tz1_bytes_21", NewBytes(tz1.Encode()), tz1, true},
+		{"kt1_string", NewString(kt1.String()), kt1, false},
+		{"kt1_string_entrypoint", NewString(kt1.String() + "%transfer"), kt1, false},
+		{"kt1_bytes", NewBytes(kt1.Encode()), kt1, false},
+		{"kt1_bytes_entrypoint", NewBytes(append(kt1.Encode(), []byte("transfer")...)), kt1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			a, err := DecodeAddress(test.Prim)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !a.Equal(test.Want) {
+				t.Errorf("address mismatch: have %s want %s", a, test.Want)
+			}
+			a, err = DecodeKeyHash(test.Prim)
+			if test.KeyHash {
+				if err != nil || !a.Equal(test.Want) {
+					t.Errorf("key hash mismatch: have %s (%v) want %s", a, err, test.Want)
+				}
+			} else if err == nil {
+				t.Errorf("expected key hash error for %s", a)
+			}
+		})
+	}
+	for _, p := range []Prim{NewString("tz1"), NewBytes([]byte{0, 1}), NewInt64(1)} {
+		if _, err := DecodeAddress(p); err == nil {
+			t.Errorf("expected error for %s", p.Dump())
+		}
+	}
+}
@@ -6,6 +6,7 @@ package micheline
 import (
 	"math/big"
 	"sort"
+	"time"
 
 	"blockwatch.cc/tzgo/tezos"
 )
@@ -54,6 +55,12 @@ func NewMutez(n tezos.N) Prim {
 	return NewBig(big.NewInt(int64(n)))
 }
 
+// NewTimestamp returns a timestamp in its optimized form as seconds since
+// the UNIX epoch.
+func NewTimestamp(t time.Time) Prim {
+	return NewInt64(t.Unix())
+}
+
 func NewBig(i *big.Int) Prim {
 	return Prim{Type: PrimInt, Int: i}
 }
@@ -112,6 +119,14 @@ func NewMapElem(k, v Prim) Prim {
 	return Prim{Type: PrimBinary, OpCode: D_ELT, Args: []Prim{k, v}}
 }
 
+// NewSet returns a set value with elements sorted in Michelson order.
+func NewSet(elts ...Prim) Prim {
+	sort.Slice(elts, func(i, j int) bool {
+		return elts[i].Compare(elts[j]) < 0
+	})
+	return Prim{Type: PrimSequence, Args: elts}
+}
+
 func NewSetType(e Prim, anno ...string) Prim {
 	typ := PrimUnary
 	if len(anno) > 0 {
@@ -159,6 +174,161 @@ func NewUnion(path []int, prim Prim) Prim {
 	return NewCode(oc, NewUnion(path[1:], prim))
 }
 
+// Builder is a fluent helper to construct nested Micheline values, e.g.
+//
+//	Build().Pair(Build().Address(a), Build().Nat(5)).Done()
+//
+// Each method sets the builder's value and returns the builder so that
+// calls can be nested. Call Done to obtain the resulting Prim.
+type Builder struct {
+	prim Prim
+}
+
+// Build returns a new empty builder.
+func Build() *Builder {
+	return &Builder{}
+}
+
+// Done returns the constructed value.
+func (b *Builder) Done() Prim {
+	return b.prim
+}
+
+// Prim sets a raw primitive as value.
+func (b *Builder) Prim(p Prim) *Builder {
+	b.prim = p
+	return b
+}
+
+func (b *Builder) Int(i int64) *Builder {
+	b.prim = NewInt64(i)
+	return b
+}
+
+func (b *Builder) Nat(i int64) *Builder {
+	b.prim = NewNat(big.NewInt(i))
+	return b
+}
+
+func (b *Builder) Big(i *big.Int) *Builder {
+	b.prim = NewBig(i)
+	return b
+}
+
+func (b *Builder) Mutez(n tezos.N) *Builder {
+	b.prim = NewMutez(n)
+	return b
+}
+
+func (b *Builder) String(s string) *Builder {
+	b.prim = NewString(s)
+	return b
+}
+
+func (b *Builder) Bytes(buf []byte) *Builder {
+	b.prim = NewBytes(buf)
+	return b
+}
+
+func (b *Builder) Address(a tezos.Address) *Builder {
+	b.prim = NewAddress(a)
+	return b
+}
+
+func (b *Builder) KeyHash(a tezos.Address) *Builder {
+	b.prim = NewKeyHash(a)
+	return b
+}
+
+func (b *Builder) Timestamp(t time.Time) *Builder {
+	b.prim = NewTimestamp(t)
+	return b
+}
+
+func (b *Builder) Bool(v bool) *Builder {
+	if v {
+		b.prim = NewCode(D_TRUE)
+	} else {
+		b.prim = NewCode(D_FALSE)
+	}
+	return b
+}
+
+func (b *Builder) Unit() *Builder {
+	b.prim = NewCode(D_UNIT)
+	return b
+}
+
+// Pair sets a pair of at least two values. More than two values are
+// nested as right comb, i.e. Pair(a, b, c) equals Pair(a, Pair(b, c)).
+func (b *Builder) Pair(args ...*Builder) *Builder {
+	b.prim = buildComb(args)
+	return b
+}
+
+func buildComb(args []*Builder) Prim {
+	switch len(args) {
+	case 0:
+		return InvalidPrim
+	case 1:
+		return args[0].Done()
+	default:
+		return NewPair(args[0].Done(), buildComb(args[1:]))
+	}
+}
+
+func (b *Builder) Left(v *Builder) *Builder {
+	b.prim = NewCode(D_LEFT, v.Done())
+	return b
+}
+
+func (b *Builder) Right(v *Builder) *Builder {
+	b.prim = NewCode(D_RIGHT, v.Done())
+	return b
+}
+
+func (b *Builder) Some(v *Builder) *Builder {
+	b.prim = NewOption(v.Done())
+	return b
+}
+
+func (b *Builder) None() *Builder {
+	b.prim = NewOption()
+	return b
+}
+
+// Elt sets a single map element. Use it as argument to Map.
+func (b *Builder) Elt(k, v *Builder) *Builder {
+	b.prim = NewMapElem(k.Done(), v.Done())
+	return b
+}
+
+// Seq sets a list of values. Values are kept in order, use Set for sets.
+func (b *Builder) Seq(args ...*Builder) *Builder {
+	b.prim = NewSeq(buildArgs(args)...)
+	return b
+}
+
+// Set sets a set of values. Values are sorted.
+func (b *Builder) Set(args ...*Builder) *Builder {
+	b.prim = NewSet(buildArgs(args)...)
+	return b
+}
+
+// Map sets a map from elements created with Elt. Elements are sorted by key.
+func (b *Builder) Map(elts ...*Builder) *Builder {
+	b.prim = NewMap(buildArgs(elts)...)
+	return b
+}
+
+func buildArgs(args []*Builder) []Prim {
+	prims := make([]Prim, len(args))
+	for i, v := range args {
+		prims[i] = v.Done()
+	}
+	return prims
+}
+
 func (p Prim) WithAnno(anno string) Prim {
 	p.Anno = append(p.Anno, anno)
 	return p
@@ -0,0 +1,68 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"math/big"
+	"testing"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestBuilder(t *testing.T) {
+	from := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	to := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+
+	// FA2 transfer list
+	have := Build().Seq(
+		Build().Pair(
+			Build().Address(from),
+			Build().Seq(Build().Pair(Build().Address(to), Build().Nat(0), Build().Nat(5))),
+		),
+	).Done()
+	want := NewSeq(
+		NewPair(
+			NewAddress(from),
+			NewSeq(NewPair(NewAddress(to), NewPair(NewNat(big.NewInt(0)), NewNat(big.NewInt(5))))),
+		),
+	)
+	if !have.IsEqual(want) {
+		t.Errorf("transfer mismatch\nhave %s\nwant %s", have.Dump(), want.Dump())
+	}
+
+	for _, v := range []struct {
+		have, want Prim
+	}{
+		{Build().Left(Build().Unit()).Done(), NewCode(D_LEFT, Unit)},
+		{Build().Right(Build().String("x")).Done(), NewCode(D_RIGHT, NewString("x"))},
+		{Build().Some(Build().Bool(true)).Done(), NewOption(NewCode(D_TRUE))},
+		{Build().None().Done(), NewOption()},
+		{
+			Build().Map(
+				Build().Elt(Build().Int(2), Build().Bytes([]byte{2})),
+				Build().Elt(Build().Int(1), Build().Bytes([]byte{1})),
+			).Done(),
+			NewSeq(
+				NewMapElem(NewInt64(1), NewBytes([]byte{1})),
+				NewMapElem(NewInt64(2), NewBytes([]byte{2})),
+			),
+		},
+		{
+			Build().Seq(Build().Int(2), Build().Int(1)).Done(),
+			NewSeq(NewInt64(2), NewInt64(1)),
+		},
+		{
+			Build().Set(Build().String("b"), Build().String("c"), Build().String("a")).Done(),
+			NewSeq(NewString("a"), NewString("b"), NewString("c")),
+		},
+		{
+			Build().Set(Build().Int(10), Build().Int(-1), Build().Int(2)).Done(),
+			NewSeq(NewInt64(-1), NewInt64(2), NewInt64(10)),
+		},
+	} {
+		if !v.have.IsEqual(v.want) {
+			t.Errorf("mismatch\nhave %s\nwant %s", v.have.Dump(), v.want.Dump())
+		}
+	}
+}
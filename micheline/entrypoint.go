@@ -97,6 +97,54 @@ func resolveEntrypointPath(name, branch string, node Prim) string {
 	return ""
 }
 
+// EntrypointPath returns the path of Left (0) and Right (1) branches through
+// the parameter union that leads to entrypoint name. Names are resolved from
+// annotations first and then from the generated names Entrypoints assigns
+// to anonymous branches, e.g. for contracts without entrypoint annotations.
+// An empty path is returned for entrypoints at the root. Use WrapEntrypoint
+// to construct the full parameter value from a path.
+func (t Type) EntrypointPath(name string) ([]int, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("micheline: invalid parameter type")
+	}
+	branch := t.ResolveEntrypointPath(name)
+	if branch == "" {
+		eps, err := t.Entrypoints(false)
+		if err != nil {
+			return nil, err
+		}
+		ep, ok := eps[name]
+		if !ok {
+			return nil, fmt.Errorf("micheline: missing entrypoint %q", name)
+		}
+		branch = ep.Branch
+	}
+	path := make([]int, 0, len(branch)/2)
+	for _, v := range strings.Split(strings.Trim(branch, "/"), "/") {
+		switch v {
+		case "L":
+			path = append(path, 0)
+		case "R":
+			path = append(path, 1)
+		}
+	}
+	return path, nil
+}
+
+// WrapEntrypoint wraps an entrypoint argument value into Left and Right
+// constructors along path so that it can be sent to the contract's default
+// entrypoint.
+func WrapEntrypoint(path []int, val Prim) Prim {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == 0 {
+			val = NewCode(D_LEFT, val)
+		} else {
+			val = NewCode(D_RIGHT, val)
+		}
+	}
+	return val
+}
+
 // Explicit list of prefixes for detecting entrypoints.
 //
 // This is necessary to resolve ambiguities in contract designs that
@@ -148,9 +196,14 @@ func listEntrypoints(e Entrypoints, branch string, node Prim) error {
 	}
 
 	// process non-T_OR branches
+	e[name] = newEntrypoint(len(e), name, branch, node)
+	return nil
+}
+
+func newEntrypoint(id int, name, branch string, node Prim) Entrypoint {
 	cp := node.Clone()
 	ep := Entrypoint{
-		Id:     len(e),
+		Id:     id,
 		Branch: branch,
 		Name:   name,
 		Prim:   &cp,
@@ -170,7 +223,5 @@ func listEntrypoints(e Entrypoints, branch string, node Prim) error {
 			ep.Typedef = td.Args
 		}
 	}
-
-	e[name] = ep
-	return nil
+	return ep
 }
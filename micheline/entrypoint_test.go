@@ -234,3 +234,125 @@ func TestEntrypointRendering(t *testing.T) {
 		})
 	}
 }
+
+func TestEntrypointPath(t *testing.T) {
+	var typ Prim
+	spec := `{"prim":"or","args":[{"prim":"or","args":[{"prim":"nat","annots":["%a"]},{"prim":"or","args":[{"prim":"unit","annots":["%b"]},{"prim":"string"}]}]},{"prim":"int","annots":["%c"]}]}`
+	if err := typ.UnmarshalJSON([]byte(spec)); err != nil {
+		t.Fatal(err)
+	}
+	eps, _ := NewType(typ).Entrypoints(false)
+	var anon string
+	for n, ep := range eps {
+		if ep.Branch == "/L/R/R" {
+			anon = n
+		}
+	}
+	for name, want := range map[string][]int{
+		"a":  {0, 0},
+		"b":  {0, 1, 0},
+		"c":  {1},
+		anon: {0, 1, 1},
+	} {
+		path, err := NewType(typ).EntrypointPath(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(path) != len(want) {
+			t.Fatalf("%s: have path %v want %v", name, path, want)
+		}
+		for i := range path {
+			if path[i] != want[i] {
+				t.Errorf("%s: have path %v want %v", name, path, want)
+				break
+			}
+		}
+	}
+	if _, err := NewType(typ).EntrypointPath("missing"); err == nil {
+		t.Errorf("expected error for missing entrypoint")
+	}
+
+	// wrapped value must map back to the entrypoint
+	path, _ := NewType(typ).EntrypointPath("b")
+	params := Parameters{Entrypoint: DEFAULT, Value: WrapEntrypoint(path, NewCode(D_UNIT))}
+	ep, prim, err := params.MapEntrypoint(NewType(typ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Name != "b" || prim.OpCode != D_UNIT {
+		t.Errorf("unexpected entrypoint %s with value %s", ep.Name, prim.Dump())
+	}
+}
+
+func TestEntrypointsWithDefault(t *testing.T) {
+	type want struct {
+		Id     int
+		Branch string
+	}
+	for _, test := range []struct {
+		Name string
+		Spec string
+		Want map[string]want
+	}{
+		{
+			Name: "no_or",
+			Spec: `{"prim":"parameter","args":[{"prim":"nat"}]}`,
+			Want: map[string]want{
+				"default": {0, ""},
+			},
+		},
+		{
+			Name: "explicit_default",
+			Spec: `{"prim":"parameter","args":[{"prim":"or","args":[{"prim":"nat","annots":["%default"]},{"prim":"string","annots":["%foo"]}]}]}`,
+			Want: map[string]want{
+				"default": {0, "/L"},
+				"foo":     {1, "/R"},
+			},
+		},
+		{
+			Name: "named_and_anonymous",
+			Spec: `{"prim":"parameter","args":[{"prim":"or","args":[{"prim":"nat","annots":["%mint"]},{"prim":"string"}]}]}`,
+			Want: map[string]want{
+				"mint":          {0, "/L"},
+				"@entrypoint_1": {1, "/R"},
+				"default":       {2, ""},
+			},
+		},
+		{
+			Name: "anonymous_default_renamed",
+			Spec: `{"prim":"parameter","args":[{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]}`,
+			Want: map[string]want{
+				"@entrypoint_0": {0, "/L"},
+				"@entrypoint_1": {1, "/R"},
+				"default":       {2, ""},
+			},
+		},
+	} {
+		t.Run(test.Name, func(T *testing.T) {
+			script := NewScript()
+			if err := script.Code.Param.UnmarshalJSON([]byte(test.Spec)); err != nil {
+				T.Fatalf("unmarshal: %v", err)
+			}
+			eps := script.EntrypointsWithDefault()
+			if have, want := len(eps), len(test.Want); have != want {
+				T.Errorf("mismatched entrypoint count have=%d want=%d", have, want)
+			}
+			for name, w := range test.Want {
+				ep, ok := eps[name]
+				if !ok {
+					T.Errorf("missing entrypoint %s", name)
+					continue
+				}
+				if ep.Name != name {
+					T.Errorf("%s: mismatched name %s", name, ep.Name)
+				}
+				if ep.Id != w.Id || ep.Branch != w.Branch {
+					T.Errorf("%s: have id=%d branch=%q want id=%d branch=%q", name, ep.Id, ep.Branch, w.Id, w.Branch)
+				}
+			}
+			if ep := eps["default"]; ep.Branch == "" && !ep.Prim.IsEqual(script.ParamType().Prim) {
+				T.Errorf("root default entrypoint type mismatch")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package micheline
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -126,6 +127,21 @@ func (m Interface) MatchesStrict(e Entrypoints) bool {
 	return true
 }
 
+// Missing returns the names of all interface entrypoints which are either
+// not present in e or have a different argument type. Like Matches, argument
+// labels are ignored.
+func (m Interface) Missing(e Entrypoints) []string {
+	var missing []string
+	for _, spec := range InterfaceSpecs[m] {
+		name := spec.GetVarAnnoAny()
+		ep, ok := e[name]
+		if !ok || ep.Prim == nil || !NewType(spec).Typedef("").Equal(NewType(*ep.Prim).Typedef("")) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
 func (m Interface) Contains(e Entrypoint) bool {
 	epType := NewType(*e.Prim).Typedef("")
 	for _, spec := range InterfaceSpecs[m] {
@@ -248,6 +264,26 @@ var (
 	}
 )
 
+// RegisterInterface adds a custom interface defined by its entrypoint types.
+// Each spec must carry the entrypoint name as field annotation. Registered
+// interfaces are detected by Script.Interfaces like standard interfaces.
+// Registration is not safe for concurrent use and should happen during init.
+func RegisterInterface(i Interface, specs ...Prim) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("micheline: empty spec for interface %s", i)
+	}
+	for _, spec := range specs {
+		if spec.GetVarAnnoAny() == "" {
+			return fmt.Errorf("micheline: interface %s spec %s without entrypoint name", i, spec.Dump())
+		}
+	}
+	if _, ok := InterfaceSpecs[i]; !ok {
+		WellKnownInterfaces = append(WellKnownInterfaces, i)
+	}
+	InterfaceSpecs[i] = specs
+	return nil
+}
+
 // WellKnownInterfaces contains entrypoint types for standard call interfaces and other
 // known contracts.
 var InterfaceSpecs = map[Interface][]Prim{
@@ -0,0 +1,642 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// maxViewSteps limits the number of instructions a local view execution
+// may run to protect against non-terminating loops.
+const maxViewSteps = 1 << 20
+
+// RunView evaluates the code of a pure on-chain view locally without calling
+// a node. Like on-chain, execution starts with a single `pair input storage`
+// on the stack and the view result is the single element left on the stack
+// when code finishes.
+//
+// The interpreter is untyped and supports a minimal subset of Michelson:
+//
+//   - stack: DROP, DUP, SWAP, DIG, DUG, DIP, PUSH, UNIT
+//   - pairs: PAIR, UNPAIR, CAR, CDR, GET n
+//   - options and unions: SOME, NONE, LEFT, RIGHT, IF_NONE, IF_LEFT
+//   - lists, sets and maps: NIL, CONS, IF_CONS, EMPTY_SET, EMPTY_MAP, GET, MEM, SIZE
+//   - arithmetic: ADD, SUB, MUL, EDIV, NEG, ABS, INT, ISNAT
+//   - comparison: COMPARE, EQ, NEQ, LT, GT, LE, GE
+//   - logic: AND, OR, XOR, NOT
+//   - control: IF, LOOP, FAILWITH, CONCAT
+//
+// Views that access chain context (BALANCE, NOW, LEVEL, etc), big maps or
+// other contracts are not supported and fail with an error.
+func RunView(code, storage, input Prim) (Prim, error) {
+	vm := &interpreter{}
+	vm.stack.Push(NewPair(input, storage))
+	if err := vm.run(code); err != nil {
+		return InvalidPrim, err
+	}
+	if n := vm.stack.Len(); n != 1 {
+		return InvalidPrim, fmt.Errorf("micheline: view left %d stack elements, expected 1", n)
+	}
+	return vm.stack.Pop(), nil
+}
+
+type interpreter struct {
+	stack Stack
+	steps int
+}
+
+func (vm *interpreter) pop(op OpCode) (Prim, error) {
+	if vm.stack.Empty() {
+		return InvalidPrim, fmt.Errorf("micheline: %s on empty stack", op)
+	}
+	return vm.stack.Pop(), nil
+}
+
+func (vm *interpreter) popInt(op OpCode) (*big.Int, error) {
+	p, err := vm.pop(op)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != PrimInt {
+		return nil, fmt.Errorf("micheline: %s expects int, got %s", op, p.Type)
+	}
+	return p.Int, nil
+}
+
+func (vm *interpreter) popBool(op OpCode) (bool, error) {
+	p, err := vm.pop(op)
+	if err != nil {
+		return false, err
+	}
+	switch p.OpCode {
+	case D_TRUE:
+		return true, nil
+	case D_FALSE:
+		return false, nil
+	}
+	return false, fmt.Errorf("micheline: %s expects bool, got %s", op, p.Dump())
+}
+
+// argN returns the optional numeric argument of instructions like DROP n.
+func argN(p Prim, def int) (int, error) {
+	if len(p.Args) == 0 {
+		return def, nil
+	}
+	if p.Args[0].Type != PrimInt || !p.Args[0].Int.IsInt64() || p.Args[0].Int.Sign() < 0 {
+		return 0, fmt.Errorf("micheline: invalid argument for %s", p.OpCode)
+	}
+	return int(p.Args[0].Int.Int64()), nil
+}
+
+func (vm *interpreter) run(code Prim) error {
+	if code.Type == PrimSequence {
+		for _, v := range code.Args {
+			if err := vm.run(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if vm.steps++; vm.steps > maxViewSteps {
+		return fmt.Errorf("micheline: view exceeded %d steps", maxViewSteps)
+	}
+	op := code.OpCode
+	switch op {
+	case I_DROP:
+		n, err := argN(code, 1)
+		if err != nil {
+			return err
+		}
+		for ; n > 0; n-- {
+			if _, err := vm.pop(op); err != nil {
+				return err
+			}
+		}
+
+	case I_DUP:
+		n, err := argN(code, 1)
+		if err != nil {
+			return err
+		}
+		l := vm.stack.Len()
+		if n < 1 || n > l {
+			return fmt.Errorf("micheline: DUP %d on stack of size %d", n, l)
+		}
+		vm.stack.Push(vm.stack[l-n].Clone())
+
+	case I_SWAP:
+		l := vm.stack.Len()
+		if l < 2 {
+			return fmt.Errorf("micheline: SWAP on stack of size %d", l)
+		}
+		vm.stack[l-1], vm.stack[l-2] = vm.stack[l-2], vm.stack[l-1]
+
+	case I_DIG, I_DUG:
+		n, err := argN(code, 0)
+		if err != nil {
+			return err
+		}
+		l := vm.stack.Len()
+		if n >= l {
+			return fmt.Errorf("micheline: %s %d on stack of size %d", op, n, l)
+		}
+		if op == I_DIG {
+			p := vm.stack[l-1-n]
+			copy(vm.stack[l-1-n:], vm.stack[l-n:])
+			vm.stack[l-1] = p
+		} else {
+			p := vm.stack[l-1]
+			copy(vm.stack[l-n:], vm.stack[l-1-n:l-1])
+			vm.stack[l-1-n] = p
+		}
+
+	case I_DIP:
+		n, body := 1, InvalidPrim
+		switch len(code.Args) {
+		case 1:
+			body = code.Args[0]
+		case 2:
+			var err error
+			if n, err = argN(code, 1); err != nil {
+				return err
+			}
+			body = code.Args[1]
+		default:
+			return fmt.Errorf("micheline: invalid DIP arguments")
+		}
+		l := vm.stack.Len()
+		if n > l {
+			return fmt.Errorf("micheline: DIP %d on stack of size %d", n, l)
+		}
+		saved := make([]Prim, n)
+		copy(saved, vm.stack[l-n:])
+		vm.stack = vm.stack[:l-n]
+		if err := vm.run(body); err != nil {
+			return err
+		}
+		vm.stack = append(vm.stack, saved...)
+
+	case I_PUSH:
+		if len(code.Args) != 2 {
+			return fmt.Errorf("micheline: invalid PUSH arguments")
+		}
+		vm.stack.Push(code.Args[1].Clone())
+
+	case I_UNIT:
+		vm.stack.Push(NewCode(D_UNIT))
+
+	case I_NONE:
+		vm.stack.Push(NewOption())
+
+	case I_SOME, I_LEFT, I_RIGHT:
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		c := map[OpCode]OpCode{I_SOME: D_SOME, I_LEFT: D_LEFT, I_RIGHT: D_RIGHT}[op]
+		vm.stack.Push(NewCode(c, p))
+
+	case I_NIL, I_EMPTY_SET, I_EMPTY_MAP:
+		vm.stack.Push(NewSeq())
+
+	case I_CONS:
+		x, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		l, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		if l.Type != PrimSequence {
+			return fmt.Errorf("micheline: CONS expects list, got %s", l.Type)
+		}
+		vm.stack.Push(NewSeq(append([]Prim{x}, l.Args...)...))
+
+	case I_PAIR:
+		n, err := argN(code, 2)
+		if err != nil {
+			return err
+		}
+		if n < 2 || n > vm.stack.Len() {
+			return fmt.Errorf("micheline: PAIR %d on stack of size %d", n, vm.stack.Len())
+		}
+		args := make([]Prim, n)
+		for i := range args {
+			args[i], _ = vm.pop(op)
+		}
+		p := args[n-1]
+		for i := n - 2; i >= 0; i-- {
+			p = NewPair(args[i], p)
+		}
+		vm.stack.Push(p)
+
+	case I_UNPAIR:
+		n, err := argN(code, 2)
+		if err != nil {
+			return err
+		}
+		if n < 2 {
+			return fmt.Errorf("micheline: invalid UNPAIR %d", n)
+		}
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		vals := make([]Prim, 0, n)
+		for i := 0; i < n-1; i++ {
+			l, r, err := unpair(p)
+			if err != nil {
+				return err
+			}
+			vals = append(vals, l)
+			p = r
+		}
+		vals = append(vals, p)
+		vm.stack.Push(vals...)
+
+	case I_CAR, I_CDR:
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		l, r, err := unpair(p)
+		if err != nil {
+			return err
+		}
+		if op == I_CAR {
+			vm.stack.Push(l)
+		} else {
+			vm.stack.Push(r)
+		}
+
+	case I_GET:
+		if len(code.Args) > 0 {
+			n, err := argN(code, 0)
+			if err != nil {
+				return err
+			}
+			p, err := vm.pop(op)
+			if err != nil {
+				return err
+			}
+			for ; n > 1; n -= 2 {
+				if _, p, err = unpair(p); err != nil {
+					return err
+				}
+			}
+			if n == 1 {
+				if p, _, err = unpair(p); err != nil {
+					return err
+				}
+			}
+			vm.stack.Push(p)
+			break
+		}
+		k, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		m, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		if m.Type != PrimSequence {
+			return fmt.Errorf("micheline: GET on %s is not supported", m.Type)
+		}
+		res := NewOption()
+		for _, v := range m.Args {
+			if v.OpCode != D_ELT || len(v.Args) != 2 {
+				return fmt.Errorf("micheline: GET expects map")
+			}
+			if c, err := compare(k, v.Args[0]); err != nil {
+				return err
+			} else if c == 0 {
+				res = NewOption(v.Args[1])
+				break
+			}
+		}
+		vm.stack.Push(res)
+
+	case I_MEM:
+		k, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		m, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		if m.Type != PrimSequence {
+			return fmt.Errorf("micheline: MEM on %s is not supported", m.Type)
+		}
+		var found bool
+		for _, v := range m.Args {
+			if v.OpCode == D_ELT && len(v.Args) == 2 {
+				v = v.Args[0]
+			}
+			if c, err := compare(k, v); err != nil {
+				return err
+			} else if c == 0 {
+				found = true
+				break
+			}
+		}
+		vm.stack.Push(newBool(found))
+
+	case I_SIZE:
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		var n int
+		switch p.Type {
+		case PrimSequence:
+			n = len(p.Args)
+		case PrimString:
+			n = len(p.String)
+		case PrimBytes:
+			n = len(p.Bytes)
+		default:
+			return fmt.Errorf("micheline: SIZE on %s is not supported", p.Type)
+		}
+		vm.stack.Push(NewInt64(int64(n)))
+
+	case I_CONCAT:
+		a, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		b, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		switch {
+		case a.Type == PrimString && b.Type == PrimString:
+			vm.stack.Push(NewString(a.String + b.String))
+		case a.Type == PrimBytes && b.Type == PrimBytes:
+			vm.stack.Push(NewBytes(append(append([]byte{}, a.Bytes...), b.Bytes...)))
+		default:
+			return fmt.Errorf("micheline: CONCAT on %s and %s is not supported", a.Type, b.Type)
+		}
+
+	case I_ADD, I_SUB, I_MUL, I_EDIV:
+		x, err := vm.popInt(op)
+		if err != nil {
+			return err
+		}
+		y, err := vm.popInt(op)
+		if err != nil {
+			return err
+		}
+		z := new(big.Int)
+		switch op {
+		case I_ADD:
+			vm.stack.Push(NewBig(z.Add(x, y)))
+		case I_SUB:
+			vm.stack.Push(NewBig(z.Sub(x, y)))
+		case I_MUL:
+			vm.stack.Push(NewBig(z.Mul(x, y)))
+		case I_EDIV:
+			if y.Sign() == 0 {
+				vm.stack.Push(NewOption())
+				break
+			}
+			m := new(big.Int)
+			z.DivMod(x, y, m)
+			vm.stack.Push(NewOption(NewPair(NewBig(z), NewBig(m))))
+		}
+
+	case I_NEG, I_ABS, I_INT, I_ISNAT:
+		x, err := vm.popInt(op)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case I_NEG:
+			vm.stack.Push(NewBig(new(big.Int).Neg(x)))
+		case I_ABS:
+			vm.stack.Push(NewBig(new(big.Int).Abs(x)))
+		case I_INT:
+			vm.stack.Push(NewBig(x))
+		case I_ISNAT:
+			if x.Sign() < 0 {
+				vm.stack.Push(NewOption())
+			} else {
+				vm.stack.Push(NewOption(NewBig(x)))
+			}
+		}
+
+	case I_COMPARE:
+		a, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		b, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		c, err := compare(a, b)
+		if err != nil {
+			return err
+		}
+		vm.stack.Push(NewInt64(int64(c)))
+
+	case I_EQ, I_NEQ, I_LT, I_GT, I_LE, I_GE:
+		x, err := vm.popInt(op)
+		if err != nil {
+			return err
+		}
+		c := x.Sign()
+		vm.stack.Push(newBool(map[OpCode]bool{
+			I_EQ:  c == 0,
+			I_NEQ: c != 0,
+			I_LT:  c < 0,
+			I_GT:  c > 0,
+			I_LE:  c <= 0,
+			I_GE:  c >= 0,
+		}[op]))
+
+	case I_AND, I_OR, I_XOR:
+		a, err := vm.popBool(op)
+		if err != nil {
+			return err
+		}
+		b, err := vm.popBool(op)
+		if err != nil {
+			return err
+		}
+		vm.stack.Push(newBool(map[OpCode]bool{
+			I_AND: a && b,
+			I_OR:  a || b,
+			I_XOR: a != b,
+		}[op]))
+
+	case I_NOT:
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		switch {
+		case p.Type == PrimInt:
+			vm.stack.Push(NewBig(new(big.Int).Not(p.Int)))
+		case p.OpCode == D_TRUE || p.OpCode == D_FALSE:
+			vm.stack.Push(newBool(p.OpCode == D_FALSE))
+		default:
+			return fmt.Errorf("micheline: NOT on %s is not supported", p.Dump())
+		}
+
+	case I_IF:
+		if len(code.Args) != 2 {
+			return fmt.Errorf("micheline: invalid IF arguments")
+		}
+		b, err := vm.popBool(op)
+		if err != nil {
+			return err
+		}
+		if b {
+			return vm.run(code.Args[0])
+		}
+		return vm.run(code.Args[1])
+
+	case I_IF_NONE, I_IF_LEFT, I_IF_CONS:
+		if len(code.Args) != 2 {
+			return fmt.Errorf("micheline: invalid %s arguments", op)
+		}
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		if (p.OpCode == D_SOME || p.OpCode == D_LEFT || p.OpCode == D_RIGHT) && len(p.Args) != 1 {
+			return fmt.Errorf("micheline: %s on malformed value %s", op, p.Dump())
+		}
+		switch {
+		case op == I_IF_NONE && p.OpCode == D_NONE:
+			return vm.run(code.Args[0])
+		case op == I_IF_NONE && p.OpCode == D_SOME:
+			vm.stack.Push(p.Args[0])
+			return vm.run(code.Args[1])
+		case op == I_IF_LEFT && p.OpCode == D_LEFT:
+			vm.stack.Push(p.Args[0])
+			return vm.run(code.Args[0])
+		case op == I_IF_LEFT && p.OpCode == D_RIGHT:
+			vm.stack.Push(p.Args[0])
+			return vm.run(code.Args[1])
+		case op == I_IF_CONS && p.Type == PrimSequence:
+			if len(p.Args) == 0 {
+				return vm.run(code.Args[1])
+			}
+			vm.stack.Push(p.Args[0], NewSeq(p.Args[1:]...))
+			return vm.run(code.Args[0])
+		}
+		return fmt.Errorf("micheline: %s on unexpected value %s", op, p.Dump())
+
+	case I_LOOP:
+		if len(code.Args) != 1 {
+			return fmt.Errorf("micheline: invalid LOOP arguments")
+		}
+		for {
+			b, err := vm.popBool(op)
+			if err != nil {
+				return err
+			}
+			if !b {
+				break
+			}
+			if err := vm.run(code.Args[0]); err != nil {
+				return err
+			}
+		}
+
+	case I_FAILWITH:
+		p, err := vm.pop(op)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("micheline: view failed with %s", p.Dump())
+
+	default:
+		return fmt.Errorf("micheline: unsupported instruction %s in view", op)
+	}
+	return nil
+}
+
+// unpair splits a pair into left and right, treating pairs with more than
+// two arguments as right combs.
+func unpair(p Prim) (Prim, Prim, error) {
+	if p.OpCode != D_PAIR || len(p.Args) < 2 {
+		return InvalidPrim, InvalidPrim, fmt.Errorf("micheline: expected pair, got %s", p.Dump())
+	}
+	if len(p.Args) == 2 {
+		return p.Args[0], p.Args[1], nil
+	}
+	return p.Args[0], NewCode(D_PAIR, p.Args[1:]...), nil
+}
+
+func newBool(b bool) Prim {
+	if b {
+		return NewCode(D_TRUE)
+	}
+	return NewCode(D_FALSE)
+}
+
+// compare implements Michelson COMPARE semantics for untyped values.
+func compare(a, b Prim) (int, error) {
+	switch {
+	case a.Type == PrimInt && b.Type == PrimInt:
+		return a.Int.Cmp(b.Int), nil
+	case a.Type == PrimString && b.Type == PrimString:
+		return strings.Compare(a.String, b.String), nil
+	case a.Type == PrimBytes && b.Type == PrimBytes:
+		return bytes.Compare(a.Bytes, b.Bytes), nil
+	case a.Type == PrimSequence || b.Type == PrimSequence:
+		return 0, fmt.Errorf("micheline: values are not comparable")
+	}
+	rank := func(c OpCode) int {
+		switch c {
+		case D_FALSE, D_NONE, D_LEFT:
+			return 0
+		case D_TRUE, D_SOME, D_RIGHT:
+			return 1
+		}
+		return -1
+	}
+	switch a.OpCode {
+	case D_UNIT:
+		if b.OpCode == D_UNIT {
+			return 0, nil
+		}
+	case D_PAIR:
+		if b.OpCode == D_PAIR {
+			al, ar, err := unpair(a)
+			if err != nil {
+				return 0, err
+			}
+			bl, br, err := unpair(b)
+			if err != nil {
+				return 0, err
+			}
+			if c, err := compare(al, bl); err != nil || c != 0 {
+				return c, err
+			}
+			return compare(ar, br)
+		}
+	case D_FALSE, D_TRUE, D_NONE, D_SOME, D_LEFT, D_RIGHT:
+		ra, rb := rank(a.OpCode), rank(b.OpCode)
+		if rb < 0 || (a.OpCode == D_FALSE || a.OpCode == D_TRUE) != (b.OpCode == D_FALSE || b.OpCode == D_TRUE) {
+			break
+		}
+		if ra != rb {
+			return ra - rb, nil
+		}
+		if len(a.Args) == 1 && len(b.Args) == 1 {
+			return compare(a.Args[0], b.Args[0])
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("micheline: cannot compare %s and %s", a.Dump(), b.Dump())
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"testing"
+)
+
+func TestRunView(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		storage string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "add",
+			code:    `[{"prim":"UNPAIR"},{"prim":"ADD"}]`,
+			storage: `{"int":"3"}`,
+			input:   `{"int":"2"}`,
+			want:    `{"int":"5"}`,
+		},
+		{
+			name:    "ediv",
+			code:    `[{"prim":"UNPAIR"},{"prim":"EDIV"},{"prim":"IF_NONE","args":[[{"prim":"PUSH","args":[{"prim":"int"},{"int":"0"}]}],[{"prim":"CDR"}]]}]`,
+			storage: `{"int":"2"}`,
+			input:   `{"int":"7"}`,
+			want:    `{"int":"1"}`,
+		},
+		{
+			name:    "map_get",
+			code:    `[{"prim":"UNPAIR"},{"prim":"GET"},{"prim":"IF_NONE","args":[[{"prim":"PUSH","args":[{"prim":"int"},{"int":"0"}]}],[]]}]`,
+			storage: `[{"prim":"Elt","args":[{"string":"a"},{"int":"1"}]},{"prim":"Elt","args":[{"string":"b"},{"int":"2"}]}]`,
+			input:   `{"string":"b"}`,
+			want:    `{"int":"2"}`,
+		},
+		{
+			name:    "map_get_missing",
+			code:    `[{"prim":"UNPAIR"},{"prim":"GET"},{"prim":"IF_NONE","args":[[{"prim":"PUSH","args":[{"prim":"int"},{"int":"0"}]}],[]]}]`,
+			storage: `[{"prim":"Elt","args":[{"string":"a"},{"int":"1"}]}]`,
+			input:   `{"string":"c"}`,
+			want:    `{"int":"0"}`,
+		},
+		{
+			name:    "compare_pair",
+			code:    `[{"prim":"DUP"},{"prim":"CDR"},{"prim":"CAR"},{"prim":"SWAP"},{"prim":"GET","args":[{"int":"4"}]},{"prim":"COMPARE"},{"prim":"GT"}]`,
+			storage: `{"prim":"Pair","args":[{"int":"1"},{"int":"9"}]}`,
+			input:   `{"int":"0"}`,
+			want:    `{"prim":"True"}`,
+		},
+		{
+			name:    "dig_dug",
+			code:    `[{"prim":"UNPAIR","args":[{"int":"3"}]},{"prim":"DIG","args":[{"int":"2"}]},{"prim":"SUB"},{"prim":"DUG","args":[{"int":"1"}]},{"prim":"DROP"}]`,
+			storage: `{"prim":"Pair","args":[{"int":"4"},{"int":"10"}]}`,
+			input:   `{"int":"1"}`,
+			want:    `{"int":"9"}`,
+		},
+		{
+			name:    "failwith",
+			code:    `[{"prim":"CDR"},{"prim":"FAILWITH"}]`,
+			storage: `{"string":"error"}`,
+			input:   `{"prim":"Unit"}`,
+			wantErr: true,
+		},
+		{
+			name:    "if_none_malformed_some",
+			code:    `[{"prim":"CDR"},{"prim":"IF_NONE","args":[[{"prim":"UNIT"}],[]]}]`,
+			storage: `{"prim":"Some"}`,
+			input:   `{"prim":"Unit"}`,
+			wantErr: true,
+		},
+		{
+			name:    "if_left_malformed_left",
+			code:    `[{"prim":"CDR"},{"prim":"IF_LEFT","args":[[],[]]}]`,
+			storage: `{"prim":"Left"}`,
+			input:   `{"prim":"Unit"}`,
+			wantErr: true,
+		},
+		{
+			name:    "if_left_malformed_right",
+			code:    `[{"prim":"CDR"},{"prim":"IF_LEFT","args":[[],[]]}]`,
+			storage: `{"prim":"Right","args":[{"int":"1"},{"int":"2"}]}`,
+			input:   `{"prim":"Unit"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported",
+			code:    `[{"prim":"DROP"},{"prim":"BALANCE"}]`,
+			storage: `{"prim":"Unit"}`,
+			input:   `{"prim":"Unit"}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		var code, storage, input, want Prim
+		for _, v := range []struct {
+			p *Prim
+			s string
+		}{{&code, test.code}, {&storage, test.storage}, {&input, test.input}} {
+			if err := v.p.UnmarshalJSON([]byte(v.s)); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		res, err := RunView(code, storage, input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", test.name, res.Dump())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if err := want.UnmarshalJSON([]byte(test.want)); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !res.IsEqual(want) {
+			t.Errorf("%s: want %s, got %s", test.name, want.Dump(), res.Dump())
+		}
+	}
+}
@@ -32,7 +32,22 @@ type Key struct {
 	PrimKey      Prim
 }
 
+// isComparableKey checks whether typ can be used as key type. Key types created
+// from opcodes only (see ParseKeyType) have no arguments and are accepted.
+func isComparableKey(typ Type) bool {
+	switch typ.OpCode {
+	case T_PAIR, T_OPTION, T_OR:
+		if len(typ.Args) == 0 {
+			return true
+		}
+	}
+	return !typ.IsValid() || typ.IsComparable()
+}
+
 func NewKey(typ Type, key Prim) (Key, error) {
+	if !isComparableKey(typ) {
+		return Key{}, fmt.Errorf("micheline: type %s is not comparable and cannot be used as key", typ.OpCode)
+	}
 	k := Key{
 		Type: typ,
 	}
@@ -79,19 +94,11 @@ func NewKey(typ Type, key Prim) (Key, error) {
 		}
 	case T_KEY_HASH, T_ADDRESS:
 		// in some cases (originated contract storage) addresses are strings
-		if len(key.Bytes) == 0 && len(key.String) > 0 {
-			a, err := tezos.ParseAddress(strings.Split(key.String, "%")[0])
-			if err != nil {
-				return Key{}, fmt.Errorf("micheline: invalid big_map key for string type address: %w", err)
-			}
-			k.AddrKey = a
-		} else {
-			a := tezos.Address{}
-			if err := a.Decode(key.Bytes); err != nil {
-				return Key{}, fmt.Errorf("micheline: invalid big_map key for type address: %w", err)
-			}
-			k.AddrKey = a
+		a, err := DecodeAddress(key)
+		if err != nil {
+			return Key{}, fmt.Errorf("micheline: invalid big_map key for type address: %w", err)
 		}
+		k.AddrKey = a
 	case T_KEY:
 		if len(key.Bytes) == 0 && len(key.String) > 0 {
 			kk, err := tezos.ParseKey(key.String)
@@ -214,13 +221,7 @@ func ParseKey(typ OpCode, val string) (Key, error) {
 	case T_TIMESTAMP:
 		// either RFC3339 or UNIX seconds
 		key.Type.Type = PrimInt
-		if strings.Contains(val, "T") {
-			key.TimeKey, err = time.Parse(time.RFC3339, val)
-		} else {
-			var i int64
-			i, err = strconv.ParseInt(val, 10, 64)
-			key.TimeKey = time.Unix(i, 0).UTC()
-		}
+		key.TimeKey, err = ParseTimestamp(val)
 	case T_KEY_HASH, T_ADDRESS:
 		key.Type.Type = PrimBytes
 		key.AddrKey, err = tezos.ParseAddress(val)
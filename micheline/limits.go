@@ -0,0 +1,38 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"fmt"
+)
+
+// DecodeOptions limits the size of primitive trees accepted by binary decoders.
+// Zero values disable the respective limit.
+type DecodeOptions struct {
+	MaxDepth int // max nesting level, the root primitive is at level 1
+	MaxNodes int // max number of primitives in a tree
+}
+
+// DefaultDecodeOptions are limits suitable for decoding untrusted operations.
+// Operations are limited to 32k bytes, so legit values stay well below.
+var DefaultDecodeOptions = DecodeOptions{
+	MaxDepth: 1024,
+	MaxNodes: 1 << 16,
+}
+
+type decodeState struct {
+	opts  DecodeOptions
+	nodes int
+}
+
+func (d *decodeState) visit(depth int) error {
+	if d.opts.MaxDepth > 0 && depth > d.opts.MaxDepth {
+		return fmt.Errorf("micheline: max nesting depth %d exceeded", d.opts.MaxDepth)
+	}
+	d.nodes++
+	if d.opts.MaxNodes > 0 && d.nodes > d.opts.MaxNodes {
+		return fmt.Errorf("micheline: max number of nodes %d exceeded", d.opts.MaxNodes)
+	}
+	return nil
+}
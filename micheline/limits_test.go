@@ -0,0 +1,69 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"bytes"
+	"testing"
+)
+
+// nestedSeq returns a sequence nested n levels deep with a unit at the bottom.
+func nestedSeq(n int) Prim {
+	p := Unit
+	for i := 0; i < n; i++ {
+		p = NewSeq(p)
+	}
+	return p
+}
+
+func TestDecodeOptions(t *testing.T) {
+	buf, err := nestedSeq(5000).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no limits by default
+	var p Prim
+	if err := p.UnmarshalBinary(buf); err != nil {
+		t.Fatalf("unlimited decode: %v", err)
+	}
+
+	// pathologically nested sequence
+	if err := p.UnmarshalBinaryWithOptions(buf, DefaultDecodeOptions); err == nil {
+		t.Errorf("expected max depth error")
+	}
+
+	// depth limit is inclusive
+	buf, _ = nestedSeq(9).MarshalBinary()
+	if err := p.UnmarshalBinaryWithOptions(buf, DecodeOptions{MaxDepth: 10}); err != nil {
+		t.Errorf("decode at max depth: %v", err)
+	}
+	if err := p.UnmarshalBinaryWithOptions(buf, DecodeOptions{MaxDepth: 9}); err == nil {
+		t.Errorf("expected max depth error")
+	}
+
+	// node limit
+	seq := NewSeq()
+	for i := 0; i < 100; i++ {
+		seq.Args = append(seq.Args, NewInt64(int64(i)))
+	}
+	buf, _ = seq.MarshalBinary()
+	if err := p.UnmarshalBinaryWithOptions(buf, DecodeOptions{MaxNodes: 101}); err != nil {
+		t.Errorf("decode at max nodes: %v", err)
+	}
+	if err := p.UnmarshalBinaryWithOptions(buf, DecodeOptions{MaxNodes: 100}); err == nil {
+		t.Errorf("expected max nodes error")
+	}
+
+	// limits apply to parameters
+	params := Parameters{Entrypoint: DEFAULT, Value: nestedSeq(2000)}
+	buf, _ = params.MarshalBinary()
+	var dec Parameters
+	if err := dec.UnmarshalBinary(buf); err != nil {
+		t.Errorf("unlimited params decode: %v", err)
+	}
+	if err := dec.DecodeBufferWithOptions(bytes.NewBuffer(buf), DefaultDecodeOptions); err == nil {
+		t.Errorf("expected params max depth error")
+	}
+}
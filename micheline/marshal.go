@@ -384,13 +384,7 @@ func ParsePrim(typ Typedef, val string, optimized bool) (p Prim, err error) {
 	case T_TIMESTAMP:
 		// either RFC3339 or UNIX seconds
 		var tm time.Time
-		if strings.Contains(val, "T") {
-			tm, err = time.Parse(time.RFC3339, val)
-		} else {
-			var i int64
-			i, err = strconv.ParseInt(val, 10, 64)
-			tm = time.Unix(i, 0).UTC()
-		}
+		tm, err = ParseTimestamp(val)
 		if optimized {
 			p = NewInt64(tm.Unix())
 		} else {
@@ -5,6 +5,8 @@
 package micheline
 
 import (
+	"math/big"
+	"strconv"
 	"testing"
 	"time"
 
@@ -394,3 +396,57 @@ func TestTypeMarshaling(t *testing.T) {
 		})
 	}
 }
+
+func TestPrimTime(t *testing.T) {
+	want := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+	for _, p := range []Prim{
+		NewTimestamp(want),
+		NewString("2021-06-01T12:30:00Z"),
+		NewString("2021-06-01T14:30:00+02:00"),
+		NewString(strconv.FormatInt(want.Unix(), 10)),
+	} {
+		tm, err := p.Time()
+		if err != nil {
+			t.Fatalf("%s: %v", p.Dump(), err)
+		}
+		if !tm.Equal(want) || tm.Location() != time.UTC {
+			t.Errorf("%s: have %s want %s", p.Dump(), tm, want)
+		}
+	}
+	for _, p := range []Prim{
+		NewString("not a time"),
+		NewBytes([]byte{1}),
+		NewBig(new(big.Int).Lsh(big.NewInt(1), 70)),
+	} {
+		if _, err := p.Time(); err == nil {
+			t.Errorf("%s: expected error", p.Dump())
+		}
+	}
+}
+
+func TestPrimChainId(t *testing.T) {
+	for _, p := range []Prim{
+		NewBytes(tezos.Mainnet.Bytes()),
+		NewString(tezos.Mainnet.String()),
+	} {
+		id, err := p.ChainId()
+		if err != nil {
+			t.Fatalf("%s: %v", p.Dump(), err)
+		}
+		if !id.Equal(tezos.Mainnet) || id.Network() != "Mainnet" {
+			t.Errorf("%s: have %s (%s) want %s", p.Dump(), id, id.Network(), tezos.Mainnet)
+		}
+	}
+	for _, p := range []Prim{
+		NewString("NetXinvalid"),
+		NewBytes([]byte{1, 2}),
+		NewInt64(1),
+	} {
+		if _, err := p.ChainId(); err == nil {
+			t.Errorf("%s: expected error", p.Dump())
+		}
+	}
+	if n := tezos.NewChainIdHash([]byte{1, 2, 3, 4}).Network(); n != "" {
+		t.Errorf("expected unknown network, got %q", n)
+	}
+}
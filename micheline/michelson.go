@@ -0,0 +1,302 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ParseMichelson parses a Michelson value in its textual representation as
+// used in the Michelson documentation and tezos-client, e.g.
+//
+//	Pair "a" (Some 1)
+//	{ Elt "x" 0x00 ; Elt "y" 0x01 }
+//
+// Supported are int, string and bytes constants, data constructors like
+// Pair, Left, Right, Some, None, Elt, Unit, True and False with optional
+// annotations as well as sequences. Primitive names are resolved through
+// the list of known opcodes, so simple type expressions parse as well.
+func ParseMichelson(src string) (Prim, error) {
+	p := &michelsonParser{src: src}
+	if err := p.next(); err != nil {
+		return InvalidPrim, err
+	}
+	if p.tok.typ == tokEOF {
+		return InvalidPrim, fmt.Errorf("micheline: empty michelson expression")
+	}
+	prim, err := p.parseExpr()
+	if err != nil {
+		return InvalidPrim, err
+	}
+	if p.tok.typ != tokEOF {
+		return InvalidPrim, p.errorf("unexpected %s", p.tok)
+	}
+	return prim, nil
+}
+
+type michelsonTokenType byte
+
+const (
+	tokEOF michelsonTokenType = iota
+	tokInt
+	tokString
+	tokBytes
+	tokIdent
+	tokAnno
+	tokLParen
+	tokRParen
+	tokLBrace
+	tokRBrace
+	tokSemi
+)
+
+type michelsonToken struct {
+	typ michelsonTokenType
+	val string
+	pos int
+}
+
+func (t michelsonToken) String() string {
+	if t.typ == tokEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.val)
+}
+
+type michelsonParser struct {
+	src string
+	pos int
+	tok michelsonToken
+}
+
+func (p *michelsonParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("micheline: michelson syntax error at offset %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '%' || c == '@' || c == ':' ||
+		('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// next reads the next token into p.tok, skipping whitespace and comments.
+func (p *michelsonParser) next() error {
+	src := p.src
+	for p.pos < len(src) {
+		switch c := src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '#':
+			for p.pos < len(src) && src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(src[p.pos:], "/*"):
+			end := strings.Index(src[p.pos+2:], "*/")
+			if end < 0 {
+				p.tok.pos = p.pos
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		default:
+			goto token
+		}
+	}
+	p.tok = michelsonToken{typ: tokEOF, pos: p.pos}
+	return nil
+
+token:
+	start := p.pos
+	p.tok = michelsonToken{pos: start}
+	switch c := src[start]; {
+	case c == '(' || c == ')' || c == '{' || c == '}' || c == ';':
+		p.tok.typ = map[byte]michelsonTokenType{
+			'(': tokLParen, ')': tokRParen, '{': tokLBrace, '}': tokRBrace, ';': tokSemi,
+		}[c]
+		p.tok.val = string(c)
+		p.pos++
+
+	case c == '"':
+		p.pos++
+		var b strings.Builder
+		for {
+			if p.pos >= len(src) {
+				return p.errorf("unterminated string")
+			}
+			c := src[p.pos]
+			p.pos++
+			if c == '"' {
+				break
+			}
+			if c == '\\' {
+				if p.pos >= len(src) {
+					return p.errorf("unterminated string")
+				}
+				switch e := src[p.pos]; e {
+				case '"', '\\':
+					b.WriteByte(e)
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'b':
+					b.WriteByte('\b')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					return p.errorf("invalid escape sequence \\%c", e)
+				}
+				p.pos++
+				continue
+			}
+			b.WriteByte(c)
+		}
+		p.tok.typ = tokString
+		p.tok.val = b.String()
+
+	case c == '-' || ('0' <= c && c <= '9'):
+		p.pos++
+		for p.pos < len(src) && isIdentChar(src[p.pos]) {
+			p.pos++
+		}
+		p.tok.val = src[start:p.pos]
+		if strings.HasPrefix(p.tok.val, "0x") {
+			p.tok.typ = tokBytes
+		} else {
+			p.tok.typ = tokInt
+		}
+
+	case c == '%' || c == '@' || c == ':':
+		p.pos++
+		for p.pos < len(src) && isIdentChar(src[p.pos]) {
+			p.pos++
+		}
+		p.tok.typ = tokAnno
+		p.tok.val = src[start:p.pos]
+
+	case isIdentChar(c):
+		for p.pos < len(src) && isIdentChar(src[p.pos]) {
+			p.pos++
+		}
+		p.tok.typ = tokIdent
+		p.tok.val = src[start:p.pos]
+
+	default:
+		return p.errorf("unexpected character %q", c)
+	}
+	return nil
+}
+
+// parseExpr parses a primitive application with arguments or a single atom.
+func (p *michelsonParser) parseExpr() (Prim, error) {
+	if p.tok.typ != tokIdent {
+		return p.parseArg()
+	}
+	op, err := ParseOpCode(p.tok.val)
+	if err != nil {
+		return InvalidPrim, p.errorf("unknown primitive %s", p.tok)
+	}
+	if err := p.next(); err != nil {
+		return InvalidPrim, err
+	}
+	var annos []string
+	for p.tok.typ == tokAnno {
+		annos = append(annos, p.tok.val)
+		if err := p.next(); err != nil {
+			return InvalidPrim, err
+		}
+	}
+	var args []Prim
+	for {
+		switch p.tok.typ {
+		case tokEOF, tokRParen, tokRBrace, tokSemi:
+			return newMichelsonPrim(op, annos, args), nil
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return InvalidPrim, err
+		}
+		args = append(args, arg)
+	}
+}
+
+// parseArg parses a constant, a nullary primitive, a parenthesized
+// expression or a sequence.
+func (p *michelsonParser) parseArg() (prim Prim, err error) {
+	switch p.tok.typ {
+	case tokInt:
+		i, ok := new(big.Int).SetString(p.tok.val, 10)
+		if !ok {
+			return InvalidPrim, p.errorf("invalid int %s", p.tok)
+		}
+		prim = NewBig(i)
+
+	case tokString:
+		prim = NewString(p.tok.val)
+
+	case tokBytes:
+		buf, err := hex.DecodeString(p.tok.val[2:])
+		if err != nil {
+			return InvalidPrim, p.errorf("invalid bytes %s", p.tok)
+		}
+		prim = NewBytes(buf)
+
+	case tokIdent:
+		op, err := ParseOpCode(p.tok.val)
+		if err != nil {
+			return InvalidPrim, p.errorf("unknown primitive %s", p.tok)
+		}
+		prim = NewCode(op)
+
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return InvalidPrim, err
+		}
+		if prim, err = p.parseExpr(); err != nil {
+			return InvalidPrim, err
+		}
+		if p.tok.typ != tokRParen {
+			return InvalidPrim, p.errorf("expected ')', got %s", p.tok)
+		}
+
+	case tokLBrace:
+		if err := p.next(); err != nil {
+			return InvalidPrim, err
+		}
+		prim = NewSeq()
+		for p.tok.typ != tokRBrace {
+			elem, err := p.parseExpr()
+			if err != nil {
+				return InvalidPrim, err
+			}
+			prim.Args = append(prim.Args, elem)
+			switch p.tok.typ {
+			case tokSemi:
+				if err := p.next(); err != nil {
+					return InvalidPrim, err
+				}
+			case tokRBrace:
+			default:
+				return InvalidPrim, p.errorf("expected ';' or '}', got %s", p.tok)
+			}
+		}
+
+	default:
+		return InvalidPrim, p.errorf("unexpected %s", p.tok)
+	}
+	return prim, p.next()
+}
+
+func newMichelsonPrim(op OpCode, annos []string, args []Prim) Prim {
+	prim := NewCode(op, args...)
+	if len(annos) > 0 {
+		prim.Anno = annos
+		if prim.Type != PrimVariadicAnno {
+			prim.Type++
+		}
+	}
+	return prim
+}
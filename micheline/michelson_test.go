@@ -0,0 +1,57 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"testing"
+)
+
+func TestParseMichelson(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{src: `42`, want: `{"int":"42"}`},
+		{src: `-7`, want: `{"int":"-7"}`},
+		{src: `"a \"b\"\n"`, want: `{"string":"a \"b\"\n"}`},
+		{src: `0x00ff`, want: `{"bytes":"00ff"}`},
+		{src: `Unit`, want: `{"prim":"Unit"}`},
+		{src: `Pair "a" 1`, want: `{"prim":"Pair","args":[{"string":"a"},{"int":"1"}]}`},
+		{src: `(Pair "a" (Some (Left True)))`, want: `{"prim":"Pair","args":[{"string":"a"},{"prim":"Some","args":[{"prim":"Left","args":[{"prim":"True"}]}]}]}`},
+		{src: `Pair 1 2 3`, want: `{"prim":"Pair","args":[{"int":"1"},{"int":"2"},{"int":"3"}]}`},
+		{src: `{ Elt "x" None ; Elt "y" 0x01 ; }`, want: `[{"prim":"Elt","args":[{"string":"x"},{"prim":"None"}]},{"prim":"Elt","args":[{"string":"y"},{"bytes":"01"}]}]`},
+		{src: `{}`, want: `[]`},
+		{src: "# comment\n{ 1 ; /* two */ 2 }", want: `[{"int":"1"},{"int":"2"}]`},
+		{src: `pair (int %x) nat`, want: `{"prim":"pair","args":[{"prim":"int","annots":["%x"]},{"prim":"nat"}]}`},
+		{src: ``, wantErr: true},
+		{src: `Pair 1`, want: `{"prim":"Pair","args":[{"int":"1"}]}`},
+		{src: `Foo 1`, wantErr: true},
+		{src: `"abc`, wantErr: true},
+		{src: `{ 1 2 }`, wantErr: true},
+		{src: `(Pair 1 2`, wantErr: true},
+		{src: `1 2`, wantErr: true},
+		{src: `0xzz`, wantErr: true},
+	}
+	for _, test := range tests {
+		prim, err := ParseMichelson(test.src)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %s", test.src, prim.Dump())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		var want Prim
+		if err := want.UnmarshalJSON([]byte(test.want)); err != nil {
+			t.Fatalf("%q: %v", test.src, err)
+		}
+		if !prim.IsEqualWithAnno(want) {
+			t.Errorf("%q: want %s, got %s", test.src, want.Dump(), prim.Dump())
+		}
+	}
+}
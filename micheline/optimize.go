@@ -0,0 +1,102 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+// Optimize returns a copy of p with right-hand nested pairs converted into the
+// most compact comb form for binary encoding. Value combs of four or more
+// elements turn into sequences, shorter combs into nested binary pairs. Type
+// combs turn into variadic pair types when this saves space. Annotated inner
+// pair types are kept as is since flattening would lose their annotations.
+func (p Prim) Optimize() Prim {
+	if p.Type != PrimSequence && len(p.Args) >= 2 {
+		switch {
+		case p.OpCode == D_PAIR && len(p.Anno) == 0:
+			flat := optimizeAll(flattenComb(p))
+			if len(flat) >= 4 {
+				// 5 bytes sequence header vs 2 bytes per nested pair
+				return NewSeq(flat...)
+			}
+			return foldComb(flat, D_PAIR, nil)
+
+		case p.OpCode == T_PAIR:
+			flat := optimizeAll(flattenComb(p))
+			if variadicCombSize(p.Anno) < nestedCombSize(len(flat), p.Anno) {
+				return Prim{
+					Type:   PrimVariadicAnno,
+					OpCode: T_PAIR,
+					Args:   flat,
+					Anno:   p.Anno,
+				}
+			}
+			return foldComb(flat, T_PAIR, p.Anno)
+		}
+	}
+	if len(p.Args) == 0 {
+		return p
+	}
+	p.Args = optimizeAll(p.Args)
+	return p
+}
+
+func optimizeAll(args []Prim) []Prim {
+	res := make([]Prim, len(args))
+	for i, v := range args {
+		res[i] = v.Optimize()
+	}
+	return res
+}
+
+// flattenComb collects all elements of a right-hand pair tree. Sequences and
+// annotated pairs end the tree.
+func flattenComb(p Prim) []Prim {
+	n := len(p.Args)
+	flat := make([]Prim, 0, n)
+	flat = append(flat, p.Args[:n-1]...)
+	last := p.Args[n-1]
+	for last.OpCode == p.OpCode && last.Type != PrimSequence && len(last.Anno) == 0 && len(last.Args) >= 2 {
+		n = len(last.Args)
+		flat = append(flat, last.Args[:n-1]...)
+		last = last.Args[n-1]
+	}
+	return append(flat, last)
+}
+
+// foldComb builds a right-hand tree of binary pairs from comb elements.
+func foldComb(flat []Prim, op OpCode, anno []string) Prim {
+	n := len(flat)
+	p := Prim{Type: PrimBinary, OpCode: op, Args: []Prim{flat[n-2], flat[n-1]}}
+	for i := n - 3; i >= 0; i-- {
+		p = Prim{Type: PrimBinary, OpCode: op, Args: []Prim{flat[i], p}}
+	}
+	if len(anno) > 0 {
+		p.Type = PrimBinaryAnno
+		p.Anno = anno
+	}
+	return p
+}
+
+func annoSize(anno []string) int {
+	if len(anno) == 0 {
+		return 0
+	}
+	sz := len(anno) - 1 // separators
+	for _, v := range anno {
+		sz += len(v)
+	}
+	return sz
+}
+
+// nestedCombSize is the encoding overhead of n elements as nested binary pairs.
+func nestedCombSize(n int, anno []string) int {
+	sz := 2 * (n - 1)
+	if len(anno) > 0 {
+		sz += 4 + annoSize(anno)
+	}
+	return sz
+}
+
+// variadicCombSize is the encoding overhead of a variadic pair.
+func variadicCombSize(anno []string) int {
+	return 10 + annoSize(anno)
+}
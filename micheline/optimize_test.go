@@ -0,0 +1,86 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"testing"
+)
+
+func binarySize(t *testing.T, p Prim) int {
+	t.Helper()
+	buf, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(buf)
+}
+
+func TestOptimizeValue(t *testing.T) {
+	nested := NewPair(NewInt64(1), NewPair(NewInt64(2), NewPair(NewString("x"), NewPair(NewInt64(4), NewSeq(NewInt64(5))))))
+	opt := nested.Optimize()
+	if !opt.IsSequence() || len(opt.Args) != 5 {
+		t.Fatalf("expected comb sequence of 5, got %s", opt.Dump())
+	}
+	if have, orig := binarySize(t, opt), binarySize(t, nested); have >= orig {
+		t.Errorf("expected smaller encoding: have=%d orig=%d", have, orig)
+	}
+	// the trailing list must stay intact
+	if last := opt.Args[4]; !last.IsSequence() || len(last.Args) != 1 {
+		t.Errorf("unexpected last element %s", last.Dump())
+	}
+	// idempotent
+	if again := opt.Optimize(); !again.IsEqual(opt) {
+		t.Errorf("optimize is not idempotent: %s", again.Dump())
+	}
+
+	// short combs stay binary pairs, variadic pairs are normalized
+	variadic := Prim{Type: PrimVariadicAnno, OpCode: D_PAIR, Args: []Prim{NewInt64(1), NewInt64(2), NewInt64(3)}}
+	opt = variadic.Optimize()
+	if !opt.IsEqual(NewPair(NewInt64(1), NewPair(NewInt64(2), NewInt64(3)))) {
+		t.Errorf("unexpected short comb %s", opt.Dump())
+	}
+	if have, orig := binarySize(t, opt), binarySize(t, variadic); have >= orig {
+		t.Errorf("expected smaller encoding: have=%d orig=%d", have, orig)
+	}
+
+	// nested values are optimized too
+	wrapped := NewOption(NewPair(NewInt64(1), NewPair(NewInt64(2), NewPair(NewInt64(3), NewInt64(4)))))
+	if opt = wrapped.Optimize(); !opt.Args[0].IsSequence() {
+		t.Errorf("expected nested comb sequence, got %s", opt.Dump())
+	}
+}
+
+func TestOptimizeType(t *testing.T) {
+	nat := NewCode(T_NAT)
+	var typ Prim = nat
+	for i := 0; i < 7; i++ {
+		typ = NewPairType(nat, typ)
+	}
+	opt := typ.Optimize()
+	if opt.OpCode != T_PAIR || len(opt.Args) != 8 {
+		t.Fatalf("expected variadic pair type of 8, got %s", opt.Dump())
+	}
+	if have, orig := binarySize(t, opt), binarySize(t, typ); have >= orig {
+		t.Errorf("expected smaller encoding: have=%d orig=%d", have, orig)
+	}
+	val := make([]Prim, 8)
+	for i := range val {
+		val[i] = NewInt64(int64(i))
+	}
+	if !NewSeq(val...).Implements(NewType(opt)) || !NewSeq(val...).Implements(NewType(typ)) {
+		t.Errorf("comb value does not implement optimized type")
+	}
+
+	// short types stay nested
+	short := NewPairType(nat, NewPairType(nat, nat))
+	if opt = short.Optimize(); !opt.IsEqual(short) {
+		t.Errorf("unexpected short type %s", opt.Dump())
+	}
+
+	// annotated inner pairs are kept
+	annotated := NewPairType(nat, NewPairType(nat, NewPairType(nat, nat), "%inner"))
+	if opt = annotated.Optimize(); !opt.IsEqualWithAnno(annotated) {
+		t.Errorf("unexpected annotated type %s", opt.Dump())
+	}
+}
@@ -189,6 +189,12 @@ func (p *Parameters) UnmarshalJSON(data []byte) error {
 }
 
 func (p *Parameters) DecodeBuffer(buf *bytes.Buffer) error {
+	return p.DecodeBufferWithOptions(buf, DecodeOptions{})
+}
+
+// DecodeBufferWithOptions decodes binary encoded parameters and fails when
+// the parameter value exceeds the limits defined in opts.
+func (p *Parameters) DecodeBufferWithOptions(buf *bytes.Buffer, opts DecodeOptions) error {
 	if buf.Len() < 1 {
 		return io.ErrShortBuffer
 	}
@@ -233,7 +239,7 @@ func (p *Parameters) DecodeBuffer(buf *bytes.Buffer) error {
 		return io.ErrShortBuffer
 	}
 	prim := Prim{}
-	if err := prim.DecodeBuffer(buf); err != nil {
+	if err := prim.DecodeBufferWithOptions(buf, opts); err != nil {
 		return err
 	}
 	p.Value = prim
@@ -794,6 +794,59 @@ func (p Prim) UnpackAllAsciiStrings() Prim {
 	return up
 }
 
+// Time decodes a timestamp from either its optimized int form (seconds since
+// the UNIX epoch) or its readable RFC3339 string form.
+func (p Prim) Time() (time.Time, error) {
+	switch p.Type {
+	case PrimInt:
+		if p.Int == nil || !p.Int.IsInt64() {
+			return time.Time{}, fmt.Errorf("micheline: timestamp %s out of range", p.Int)
+		}
+		return time.Unix(p.Int.Int64(), 0).UTC(), nil
+	case PrimString:
+		return ParseTimestamp(p.String)
+	default:
+		return time.Time{}, fmt.Errorf("micheline: invalid timestamp prim type %s", p.Type)
+	}
+}
+
+// ParseTimestamp parses a timestamp from an RFC3339 string or a decimal
+// string of seconds since the UNIX epoch.
+func ParseTimestamp(s string) (time.Time, error) {
+	if strings.Contains(s, "T") {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("micheline: invalid timestamp %q: %v", s, err)
+		}
+		return t.UTC(), nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("micheline: invalid timestamp %q: %v", s, err)
+	}
+	return time.Unix(i, 0).UTC(), nil
+}
+
+// ChainId decodes a chain id from either its optimized bytes form or its
+// readable base58 string form.
+func (p Prim) ChainId() (tezos.ChainIdHash, error) {
+	switch p.Type {
+	case PrimBytes:
+		if len(p.Bytes) != 4 {
+			return tezos.ZeroChainIdHash, fmt.Errorf("micheline: invalid chain_id length %d", len(p.Bytes))
+		}
+		return tezos.NewChainIdHash(p.Bytes), nil
+	case PrimString:
+		h, err := tezos.ParseChainIdHash(p.String)
+		if err != nil {
+			return tezos.ZeroChainIdHash, fmt.Errorf("micheline: invalid chain_id %q: %v", p.String, err)
+		}
+		return h, nil
+	default:
+		return tezos.ZeroChainIdHash, fmt.Errorf("micheline: invalid chain_id prim type %s", p.Type)
+	}
+}
+
 // Returns a typed/decoded value from an encoded primitive.
 func (p Prim) Value(as OpCode) interface{} {
 	var warn bool
@@ -852,8 +905,7 @@ func (p Prim) Value(as OpCode) interface{} {
 	case PrimBytes:
 		switch as {
 		case T_KEY_HASH, T_ADDRESS, T_CONTRACT:
-			a := tezos.Address{}
-			if err := a.Decode(p.Bytes); err == nil {
+			if a, err := DecodeAddress(p); err == nil {
 				return a
 			}
 		case T_TX_ROLLUP_L2_ADDRESS:
@@ -1327,6 +1379,26 @@ func (p *Prim) UnmarshalBinary(data []byte) error {
 }
 
 func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
+	return p.decodeBuffer(buf, &decodeState{}, 1)
+}
+
+// UnmarshalBinaryWithOptions decodes a binary encoded primitive tree and
+// fails when the tree exceeds the limits defined in opts. Use it to decode
+// untrusted data.
+func (p *Prim) UnmarshalBinaryWithOptions(data []byte, opts DecodeOptions) error {
+	return p.DecodeBufferWithOptions(bytes.NewBuffer(data), opts)
+}
+
+// DecodeBufferWithOptions decodes a binary encoded primitive tree from buf
+// and fails when the tree exceeds the limits defined in opts.
+func (p *Prim) DecodeBufferWithOptions(buf *bytes.Buffer, opts DecodeOptions) error {
+	return p.decodeBuffer(buf, &decodeState{opts: opts}, 1)
+}
+
+func (p *Prim) decodeBuffer(buf *bytes.Buffer, d *decodeState, depth int) error {
+	if err := d.visit(depth); err != nil {
+		return err
+	}
 	b := buf.Next(1)
 	if len(b) == 0 {
 		return io.ErrShortBuffer
@@ -1361,7 +1433,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 		p.Args = make([]Prim, 0)
 		for seq.Len() > 0 {
 			prim := Prim{}
-			if err := prim.DecodeBuffer(seq); err != nil {
+			if err := prim.decodeBuffer(seq, d, depth+1); err != nil {
 				return err
 			}
 			p.Args = append(p.Args, prim)
@@ -1401,7 +1473,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 
 		// argument
 		prim := Prim{}
-		if err := prim.DecodeBuffer(buf); err != nil {
+		if err := prim.decodeBuffer(buf, d, depth+1); err != nil {
 			return err
 		}
 		p.Args = append(p.Args, prim)
@@ -1416,7 +1488,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 
 		// argument
 		prim := Prim{}
-		if err := prim.DecodeBuffer(buf); err != nil {
+		if err := prim.decodeBuffer(buf, d, depth+1); err != nil {
 			return err
 		}
 		p.Args = append(p.Args, prim)
@@ -1440,7 +1512,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 		// 2 arguments
 		for i := 0; i < 2; i++ {
 			prim := Prim{}
-			if err := prim.DecodeBuffer(buf); err != nil {
+			if err := prim.decodeBuffer(buf, d, depth+1); err != nil {
 				return err
 			}
 			p.Args = append(p.Args, prim)
@@ -1457,7 +1529,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 		// 2 arguments
 		for i := 0; i < 2; i++ {
 			prim := Prim{}
-			if err := prim.DecodeBuffer(buf); err != nil {
+			if err := prim.decodeBuffer(buf, d, depth+1); err != nil {
 				return err
 			}
 			p.Args = append(p.Args, prim)
@@ -1488,7 +1560,7 @@ func (p *Prim) DecodeBuffer(buf *bytes.Buffer) error {
 		// decode contained primitives
 		for seq.Len() > 0 {
 			prim := Prim{}
-			if err := prim.DecodeBuffer(seq); err != nil {
+			if err := prim.decodeBuffer(seq, d, depth+1); err != nil {
 				return err
 			}
 			p.Args = append(p.Args, prim)
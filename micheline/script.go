@@ -47,6 +47,20 @@ func (s Script) StorageType() Type {
 	return Type{s.Code.Storage.Args[0]}
 }
 
+// IsStorageCompatible checks whether the storage type of other is structurally
+// equal to the storage type of s so that storage can be migrated as is to a new
+// contract version. Comb pairs are unfolded and annotations are ignored. The
+// second return value lists paths to all incompatible storage elements.
+func (s Script) IsStorageCompatible(other *Script) (bool, []string) {
+	if other == nil || len(s.Code.Storage.Args) == 0 || len(other.Code.Storage.Args) == 0 {
+		return false, []string{""}
+	}
+	a := s.StorageType().Typedef("").Unfold()
+	b := other.StorageType().Typedef("").Unfold()
+	diff := a.diff(b, "", nil)
+	return len(diff) == 0, diff
+}
+
 func (s Script) ParamType() Type {
 	return Type{s.Code.Param.Args[0]}
 }
@@ -55,6 +69,32 @@ func (s Script) Entrypoints(withPrim bool) (Entrypoints, error) {
 	return s.ParamType().Entrypoints(withPrim)
 }
 
+// EntrypointsWithDefault returns all entrypoints including type info like
+// Entrypoints(true) and always includes a `default` entrypoint. When the
+// parameter type has no explicit `%default` annotation, `default` maps to
+// the root parameter type which is what the protocol calls for transactions
+// without entrypoint. Malformed parameter types yield the root entry only.
+func (s Script) EntrypointsWithDefault() Entrypoints {
+	eps, err := s.Entrypoints(true)
+	if err != nil {
+		eps = make(Entrypoints)
+	}
+	if root := s.ParamType().Prim; root.IsValid() {
+		n := len(eps)
+		ep, ok := eps[DEFAULT]
+		switch {
+		case !ok:
+			eps[DEFAULT] = newEntrypoint(n, DEFAULT, "", root)
+		case ep.Branch != "" && ep.Prim.GetVarAnnoAny() != DEFAULT:
+			// an unnamed branch was listed as default, rename it
+			ep.Name = fmt.Sprintf("%s_%d", CONST_ENTRYPOINT, ep.Id)
+			eps[ep.Name] = ep
+			eps[DEFAULT] = newEntrypoint(n, DEFAULT, "", root)
+		}
+	}
+	return eps
+}
+
 func (s Script) ResolveEntrypointPath(name string) string {
 	return s.ParamType().ResolveEntrypointPath(name)
 }
@@ -152,6 +192,22 @@ func (s Script) CodeHash() uint64 {
 	return s.Code.Code.Hash64()
 }
 
+// CodeExprHash returns the blake2b hash of the binary encoded code section
+// (parameter, storage, code and views) like the protocol computes a script
+// hash, i.e. over the Micheline expression without the 4-byte length prefix
+// used by the script encoding. Unlike CodeHash it covers the entire script and
+// is suitable to pin contract code. Global constants must be expanded for the
+// hash to match across deployments, so use normalized scripts as returned from
+// a node.
+func (s Script) CodeExprHash() tezos.ExprHash {
+	buf, err := s.Code.MarshalBinary()
+	if err != nil || len(buf) < 4 {
+		return tezos.ZeroExprHash
+	}
+	d := tezos.Digest(buf[4:])
+	return tezos.NewExprHash(d[:])
+}
+
 // Returns named bigmap ids from the script's storage type and current value.
 func (s Script) Bigmaps() map[string]int64 {
 	return DetectBigmaps(s.Code.Storage, s.Storage)
@@ -161,9 +217,30 @@ func (s Script) Bigmaps() map[string]int64 {
 // In the edge case where a T_OR branch hides an exsting bigmap behind a None value,
 // the hidden bigmap is not detected.
 func DetectBigmaps(typ, storage Prim) map[string]int64 {
-	named := make(map[string]int64)
+	refs := DetectBigmapsOrdered(typ, storage)
+	named := make(map[string]int64, len(refs))
+	for _, v := range refs {
+		named[v.Name] = v.Id
+	}
+	return named
+}
+
+// BigmapRef is a named bigmap id detected in contract storage.
+type BigmapRef struct {
+	Name string `json:"name"`
+	Id   int64  `json:"id"`
+}
+
+// DetectBigmapsOrdered works like DetectBigmaps, but returns bigmaps in the
+// order they are declared in the storage type. Bigmaps inside maps, lists and
+// sets follow the order of elements in storage. Names are made unique in the
+// same order, so output is stable across calls. Duplicate ids are reported once.
+func DetectBigmapsOrdered(typ, storage Prim) []BigmapRef {
+	refs := make([]BigmapRef, 0)
+	names := make(map[string]struct{})
+	ids := make(map[int64]struct{})
 	uniqueName := func(n string) string {
-		if _, ok := named[n]; !ok && n != "" {
+		if _, ok := names[n]; !ok && n != "" {
 			return n
 		}
 		if n == "" {
@@ -171,19 +248,28 @@ func DetectBigmaps(typ, storage Prim) map[string]int64 {
 		}
 		for i := 0; ; i++ {
 			name := n + "_" + strconv.Itoa(i)
-			if _, ok := named[name]; ok {
+			if _, ok := names[name]; ok {
 				continue
 			}
 			return name
 		}
 	}
+	add := func(name string, id int64) {
+		if _, ok := ids[id]; ok {
+			return
+		}
+		name = uniqueName(name)
+		names[name] = struct{}{}
+		ids[id] = struct{}{}
+		refs = append(refs, BigmapRef{Name: name, Id: id})
+	}
 	stack := NewStack(storage)
 	_ = typ.Walk(func(p Prim) error {
 		val := stack.Pop()
 		switch p.OpCode {
 		case T_BIG_MAP:
 			if val.IsValid() && val.Type == PrimInt {
-				named[uniqueName(p.GetVarAnnoAny())] = val.Int.Int64()
+				add(p.GetVarAnnoAny(), val.Int.Int64())
 			}
 			return PrimSkip
 
@@ -196,10 +282,9 @@ func DetectBigmaps(typ, storage Prim) map[string]int64 {
 			return PrimSkip
 
 		case T_LIST, T_SET:
-			for i, p := range val.Args {
-				for n, v := range DetectBigmaps(typ.Args[0], p) {
-					n = n + "_" + strconv.Itoa(i)
-					named[uniqueName(n)] = v
+			for i, v := range val.Args {
+				for _, r := range DetectBigmapsOrdered(p.Args[0], v) {
+					add(r.Name+"_"+strconv.Itoa(i), r.Id)
 				}
 			}
 			return PrimSkip
@@ -210,8 +295,8 @@ func DetectBigmaps(typ, storage Prim) map[string]int64 {
 				branch = p.Args[1]
 			}
 			if len(val.Args) > 0 {
-				for n, v := range DetectBigmaps(branch, val.Args[0]) {
-					named[uniqueName(n)] = v
+				for _, r := range DetectBigmapsOrdered(branch, val.Args[0]) {
+					add(r.Name, r.Id)
 				}
 			}
 			return PrimSkip
@@ -248,7 +333,7 @@ func DetectBigmaps(typ, storage Prim) map[string]int64 {
 				if name == "" {
 					name = p.GetVarAnnoAny() + "_" + strconv.Itoa(i)
 				}
-				named[uniqueName(name)] = v.Args[1].Int.Int64()
+				add(name, v.Args[1].Int.Int64())
 			}
 			return PrimSkip
 
@@ -265,7 +350,7 @@ func DetectBigmaps(typ, storage Prim) map[string]int64 {
 			return PrimSkip
 		}
 	})
-	return named
+	return refs
 }
 
 // Returns a map of all known bigmap type definitions inside the scripts storage type.
@@ -360,8 +445,15 @@ func (p Script) EncodeBuffer(buf *bytes.Buffer) error {
 }
 
 func (p *Script) DecodeBuffer(buf *bytes.Buffer) error {
+	return p.DecodeBufferWithOptions(buf, DecodeOptions{})
+}
+
+// DecodeBufferWithOptions decodes a binary encoded script and fails when code
+// or storage exceed the limits defined in opts. Limits apply to code and
+// storage separately.
+func (p *Script) DecodeBufferWithOptions(buf *bytes.Buffer, opts DecodeOptions) error {
 	// 1 Code
-	if err := p.Code.DecodeBuffer(buf); err != nil {
+	if err := p.Code.DecodeBufferWithOptions(buf, opts); err != nil {
 		return err
 	}
 
@@ -380,7 +472,7 @@ func (p *Script) DecodeBuffer(buf *bytes.Buffer) error {
 
 	// read primitive tree
 	n := buf.Len()
-	if err := p.Storage.DecodeBuffer(buf); err != nil {
+	if err := p.Storage.DecodeBufferWithOptions(buf, opts); err != nil {
 		return err
 	}
 
@@ -456,6 +548,12 @@ func (c *Code) UnmarshalBinary(data []byte) error {
 }
 
 func (c *Code) DecodeBuffer(buf *bytes.Buffer) error {
+	return c.DecodeBufferWithOptions(buf, DecodeOptions{})
+}
+
+// DecodeBufferWithOptions decodes binary encoded code and fails when the
+// program exceeds the limits defined in opts.
+func (c *Code) DecodeBufferWithOptions(buf *bytes.Buffer, opts DecodeOptions) error {
 	// starts with BE uint32 total size
 	size := int(binary.BigEndian.Uint32(buf.Next(4)))
 	if buf.Len() < size {
@@ -464,7 +562,7 @@ func (c *Code) DecodeBuffer(buf *bytes.Buffer) error {
 
 	// read primitive tree
 	var prim Prim
-	if err := prim.DecodeBuffer(buf); err != nil {
+	if err := prim.DecodeBufferWithOptions(buf, opts); err != nil {
 		return err
 	}
 
@@ -0,0 +1,115 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+const (
+	// storage bytes charged by the protocol for a new bigmap on top of
+	// its key and value types
+	bigmapAllocSize = 33
+
+	// storage bytes charged by the protocol for each new bigmap entry on
+	// top of its value
+	bigmapEntrySize = 65
+)
+
+// EstimateStorageSize returns the number of storage bytes a contract storage
+// value of type typ occupies on chain. Bigmap literals are accounted as new
+// lazy storage allocations including all their entries while the remaining
+// storage is counted in its binary encoding. Script code is not included.
+// The result is an estimate since final bigmap ids are unknown before
+// origination and copies of existing bigmaps are not accounted.
+func EstimateStorageSize(value Prim, typ Type) int {
+	var lazy int
+	val := estimateLazySize(value, typ.Prim, &lazy)
+	buf, _ := val.MarshalBinary()
+	return len(buf) + lazy
+}
+
+// estimateLazySize returns a copy of val with bigmap literals replaced by ids
+// and adds the lazy storage size of replaced bigmaps to size.
+func estimateLazySize(val, typ Prim, size *int) Prim {
+	switch typ.OpCode {
+	case K_STORAGE:
+		if len(typ.Args) > 0 {
+			return estimateLazySize(val, typ.Args[0], size)
+		}
+
+	case T_BIG_MAP:
+		if val.Type == PrimInt || len(typ.Args) < 2 {
+			return val
+		}
+		kt, _ := typ.Args[0].CloneNoAnnots().MarshalBinary()
+		vt, _ := typ.Args[1].CloneNoAnnots().MarshalBinary()
+		*size += bigmapAllocSize + len(kt) + len(vt)
+		for _, elt := range val.Args {
+			if !elt.IsElt() || len(elt.Args) < 2 {
+				continue
+			}
+			buf, _ := elt.Args[1].MarshalBinary()
+			*size += bigmapEntrySize + len(buf)
+		}
+		return NewInt64(0)
+
+	case T_PAIR:
+		if len(typ.Args) < 2 {
+			break
+		}
+		args := val.Args
+		if !val.IsPair() && !val.IsSequence() || len(args) < 2 {
+			return val
+		}
+		// unfold comb types and values
+		left, right := typ.Args[0], typ.Args[1]
+		if len(typ.Args) > 2 {
+			right = NewCode(T_PAIR, typ.Args[1:]...)
+		}
+		r := args[1]
+		if len(args) > 2 {
+			r = NewCode(D_PAIR, args[1:]...)
+		}
+		return NewPair(
+			estimateLazySize(args[0], left, size),
+			estimateLazySize(r, right, size),
+		)
+
+	case T_OR:
+		if len(typ.Args) < 2 || len(val.Args) == 0 {
+			break
+		}
+		branch := typ.Args[0]
+		if val.OpCode == D_RIGHT {
+			branch = typ.Args[1]
+		}
+		return NewCode(val.OpCode, estimateLazySize(val.Args[0], branch, size))
+
+	case T_OPTION:
+		if val.OpCode == D_SOME && len(val.Args) > 0 && len(typ.Args) > 0 {
+			return NewCode(D_SOME, estimateLazySize(val.Args[0], typ.Args[0], size))
+		}
+
+	case T_LIST:
+		if len(typ.Args) == 0 || !val.IsSequence() {
+			break
+		}
+		seq := NewSeq()
+		for _, v := range val.Args {
+			seq.Args = append(seq.Args, estimateLazySize(v, typ.Args[0], size))
+		}
+		return seq
+
+	case T_MAP:
+		if len(typ.Args) < 2 || !val.IsSequence() {
+			break
+		}
+		seq := NewSeq()
+		for _, v := range val.Args {
+			if v.IsElt() && len(v.Args) == 2 {
+				v = NewCode(D_ELT, v.Args[0], estimateLazySize(v.Args[1], typ.Args[1], size))
+			}
+			seq.Args = append(seq.Args, v)
+		}
+		return seq
+	}
+	return val
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package micheline
+
+import (
+	"testing"
+)
+
+func TestEstimateStorageSize(t *testing.T) {
+	// storage (pair (big_map %ledger address nat) (nat %total))
+	typ := NewType(NewPairType(
+		NewCodeAnno(T_BIG_MAP, "%ledger", NewCode(T_ADDRESS), NewCode(T_NAT)),
+		NewCodeAnno(T_NAT, "%total"),
+	))
+	owner := NewString("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+
+	for _, test := range []struct {
+		name  string
+		value Prim
+		want  int
+	}{
+		// Pair 0 100 (0707 0000 00a401) = 7 bytes
+		{"bigmap reference", NewPair(NewInt64(0), NewInt64(100)), 7},
+		// 7 + 33 alloc + 2 key type (036e) + 2 value type (0362)
+		{"empty bigmap", NewPair(NewSeq(), NewInt64(100)), 44},
+		// 44 + 65 entry + 3 value (00a401)
+		{"filled bigmap", NewPair(NewSeq(NewCode(D_ELT, owner, NewInt64(100))), NewInt64(100)), 112},
+		// 44 + 2 * (65 + 2 value (0000 and 0001))
+		{"two entries", NewPair(NewSeq(
+			NewCode(D_ELT, owner, NewInt64(0)),
+			NewCode(D_ELT, NewString("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"), NewInt64(1)),
+		), NewInt64(100)), 178},
+	} {
+		if have := EstimateStorageSize(test.value, typ); have != test.want {
+			t.Errorf("%s: have %d want %d", test.name, have, test.want)
+		}
+	}
+}
@@ -66,6 +66,25 @@ func (a Typedef) Equal(b Typedef) bool {
 	return true
 }
 
+// diff appends paths of all elements where a and b are not equal to res. Paths
+// are built from element names joined by dots, unnamed elements use their index.
+func (a Typedef) diff(b Typedef, path string, res []string) []string {
+	if a.Type != b.Type || a.Optional != b.Optional || len(a.Args) != len(b.Args) {
+		return append(res, path)
+	}
+	for i, av := range a.Args {
+		name := av.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		res = av.diff(b.Args[i], name, res)
+	}
+	return res
+}
+
 func (a Typedef) Similar(b Typedef) bool {
 	if a.Optional != b.Optional {
 		return false
@@ -316,6 +335,25 @@ func (t Type) HasLabel() bool {
 	return t.HasAnno()
 }
 
+// FindByName returns the first type element annotated with name in depth-first
+// order along with its path of argument indices from the root. The path can be
+// used with GetIndex on both type and value trees of the same type.
+func (t Type) FindByName(name string) (Prim, []int, bool) {
+	return findByName(t.Prim, name, nil)
+}
+
+func findByName(p Prim, name string, path []int) (Prim, []int, bool) {
+	if p.MatchesAnno(name) {
+		return p, path, true
+	}
+	for i := range p.Args {
+		if v, vpath, ok := findByName(p.Args[i], name, append(path[:len(path):len(path)], i)); ok {
+			return v, vpath, true
+		}
+	}
+	return InvalidPrim, nil, false
+}
+
 func (t Type) IsEqual(t2 Type) bool {
 	return IsEqualPrim(t.Prim, t2.Prim, false)
 }
@@ -353,6 +391,30 @@ func (t Type) IsSimilar(t2 Type) bool {
 	return u1.Similar(u2)
 }
 
+// IsComparable returns true if values of type t can be compared and hence be
+// used as map, set and bigmap keys. Pairs, options and unions are comparable
+// when all their arguments are.
+func (t Type) IsComparable() bool {
+	switch t.OpCode {
+	case T_UNIT, T_NEVER, T_BOOL, T_INT, T_NAT, T_STRING, T_CHAIN_ID, T_BYTES,
+		T_MUTEZ, T_KEY_HASH, T_KEY, T_SIGNATURE, T_TIMESTAMP, T_ADDRESS,
+		T_TX_ROLLUP_L2_ADDRESS:
+		return len(t.Args) == 0
+	case T_PAIR, T_OPTION, T_OR:
+		if len(t.Args) == 0 {
+			return false
+		}
+		for _, v := range t.Args {
+			if !NewType(v).IsComparable() {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 func (t Type) MarshalJSON() ([]byte, error) {
 	if !t.IsValid() {
 		return []byte("{}"), nil
@@ -360,9 +422,12 @@ func (t Type) MarshalJSON() ([]byte, error) {
 	return json.Marshal(buildTypedef("", t.Prim, []int{}))
 }
 
+// Implements returns true when value p is a valid instance of type t. Values
+// are matched against the type tree, so nested pairs, combs and unions must
+// follow the type's structure. Optimized and readable encodings of scalars are
+// both accepted.
 func (p Prim) Implements(t Type) bool {
-	td := buildTypedef("", t.Prim, []int{})
-	return p.ImplementsType(td)
+	return implements(p, t.Prim)
 }
 
 func (p Prim) ImplementsType(t Typedef) bool {
@@ -499,6 +564,116 @@ func (p Prim) ImplementsType(t Typedef) bool {
 	return err == nil
 }
 
+// implements matches a value against a type tree. Each value node is visited
+// once, so the cost is linear in the size of the value.
+func implements(v, t Prim) bool {
+	switch t.OpCode {
+	case T_PAIR:
+		if len(t.Args) < 2 || len(v.Args) < 2 {
+			return false
+		}
+		if v.OpCode != D_PAIR && v.Type != PrimSequence {
+			return false
+		}
+		// unfold right combs on both sides
+		lv, rv := v.Args[0], v.Args[1]
+		if len(v.Args) > 2 {
+			rv = NewCode(D_PAIR, v.Args[1:]...)
+		}
+		lt, rt := t.Args[0], t.Args[1]
+		if len(t.Args) > 2 {
+			rt = NewCode(T_PAIR, t.Args[1:]...)
+		}
+		return implements(lv, lt) && implements(rv, rt)
+
+	case T_OR:
+		if len(t.Args) != 2 || len(v.Args) != 1 {
+			return false
+		}
+		switch v.OpCode {
+		case D_LEFT:
+			return implements(v.Args[0], t.Args[0])
+		case D_RIGHT:
+			return implements(v.Args[0], t.Args[1])
+		}
+		return false
+
+	case T_OPTION:
+		switch v.OpCode {
+		case D_NONE:
+			return len(v.Args) == 0
+		case D_SOME:
+			return len(v.Args) == 1 && len(t.Args) == 1 && implements(v.Args[0], t.Args[0])
+		}
+		return false
+
+	case T_BOOL:
+		return v.OpCode == D_TRUE || v.OpCode == D_FALSE
+
+	case T_UNIT:
+		return v.OpCode == D_UNIT
+
+	case T_LIST, T_SET:
+		if v.Type != PrimSequence || len(t.Args) != 1 {
+			return false
+		}
+		for _, e := range v.Args {
+			if !implements(e, t.Args[0]) {
+				return false
+			}
+		}
+		return true
+
+	case T_MAP, T_BIG_MAP:
+		if t.OpCode == T_BIG_MAP && v.Type == PrimInt {
+			return true // bigmap id
+		}
+		if v.Type != PrimSequence || len(t.Args) != 2 {
+			return false
+		}
+		for _, e := range v.Args {
+			if e.OpCode != D_ELT || len(e.Args) != 2 {
+				return false
+			}
+			if !implements(e.Args[0], t.Args[0]) || !implements(e.Args[1], t.Args[1]) {
+				return false
+			}
+		}
+		return true
+
+	case T_LAMBDA:
+		return v.Type == PrimSequence
+
+	case T_INT, T_NAT, T_MUTEZ:
+		return v.Type == PrimInt
+
+	case T_TIMESTAMP:
+		return v.Type == PrimInt || v.Type == PrimString
+
+	case T_STRING:
+		return v.Type == PrimString
+
+	case T_BYTES, T_BLS12_381_G1, T_BLS12_381_G2, T_CHEST, T_CHEST_KEY:
+		return v.Type == PrimBytes
+
+	case T_BLS12_381_FR:
+		return v.Type == PrimBytes || v.Type == PrimInt
+
+	case T_ADDRESS, T_CONTRACT, T_KEY_HASH, T_KEY, T_SIGNATURE,
+		T_CHAIN_ID, T_TX_ROLLUP_L2_ADDRESS:
+		return v.Type == PrimString || v.Type == PrimBytes
+
+	case T_NEVER:
+		return false
+
+	default:
+		// FIXME
+		// T_SAPLING_STATE, T_SAPLING_TRANSACTION,
+		// T_TICKET, T_OPERATION
+		return true
+	}
+}
+
 func buildTypedef(name string, typ Prim, path []int) Typedef {
 	if typ.HasAnno() {
 		n := typ.GetVarAnnoAny()
@@ -623,47 +798,10 @@ func (p Prim) BuildType() Type {
 	if p.OpCode.IsTypeCode() {
 		t.OpCode = p.OpCode
 	}
+	if st, ok := buildScalarType(p); ok {
+		return Type{st}
+	}
 	switch p.Type {
-	case PrimInt:
-		t.OpCode = p.Type.TypeCode()
-		t.Type = PrimNullary
-
-	case PrimBytes:
-		t.Type = PrimNullary
-		// detect address encoding first
-		var addr tezos.Address
-		if err := addr.Decode(p.Bytes); err == nil {
-			if addr.IsRollup() {
-				t.OpCode = T_TX_ROLLUP_L2_ADDRESS
-			} else {
-				t.OpCode = T_ADDRESS
-			}
-		}
-		if t.OpCode == 0 {
-			t.OpCode = p.Type.TypeCode()
-		}
-
-	case PrimString:
-		t.Type = PrimNullary
-		if len(p.String) > 0 {
-			// detect timestamp and address encoding first
-			if _, err := time.Parse(time.RFC3339, p.String); err == nil {
-				t.OpCode = T_TIMESTAMP
-			} else if addr, err := tezos.ParseAddress(p.String); err == nil {
-				if addr.IsRollup() {
-					t.OpCode = T_TX_ROLLUP_L2_ADDRESS
-				} else {
-					t.OpCode = T_ADDRESS
-				}
-			} else if _, err := tezos.ParseSignature(p.String); err == nil {
-				t.OpCode = T_SIGNATURE
-			}
-		}
-		// fallback to string
-		if t.OpCode == 0 {
-			t.OpCode = p.Type.TypeCode()
-		}
-
 	case PrimSequence:
 		switch {
 		case p.LooksLikeCode():
@@ -758,6 +896,99 @@ func (p Prim) BuildType() Type {
 	return Type{t}
 }
 
+// InferType returns a best-effort type tree for a value without type info such
+// as unpacked data. Scalars resolve like BuildType, pairs and combs resolve to
+// pair types, options and unions to their contained types, sequences of Elt
+// to maps and other sequences to lists. Ambiguous values like nat or mutez
+// resolve to int and empty or None values lose their contained types.
+func (p Prim) InferType() Type {
+	typ := inferType(p)
+	typ.WasPacked = p.WasPacked
+	return Type{typ}
+}
+
+func inferType(p Prim) Prim {
+	switch p.OpCode {
+	case D_PAIR:
+		if p.Type == PrimSequence {
+			break
+		}
+		args := make([]Prim, len(p.Args))
+		for i, v := range p.Args {
+			args[i] = inferType(v)
+		}
+		return NewCode(T_PAIR, args...)
+	case D_SOME:
+		if p.Type != PrimSequence && len(p.Args) == 1 {
+			return NewCode(T_OPTION, inferType(p.Args[0]))
+		}
+	case D_LEFT, D_RIGHT:
+		if p.Type != PrimSequence && len(p.Args) == 1 {
+			// in data we only see one branch, so we guess the other is similar
+			inner := inferType(p.Args[0])
+			return NewCode(T_OR, inner, inner.Clone())
+		}
+	}
+	if p.Type == PrimSequence && len(p.Args) > 0 && !p.LooksLikeCode() {
+		if p.LooksLikeMap() {
+			return NewCode(T_MAP, inferType(p.Args[0].Args[0]), inferType(p.Args[0].Args[1]))
+		}
+		return NewCode(T_LIST, inferType(p.Args[0]))
+	}
+	if t, ok := buildScalarType(p); ok {
+		return t
+	}
+	return p.BuildType().Prim
+}
+
+// buildScalarType returns the type of int, bytes and string values. Bytes and
+// strings are probed for address, timestamp and signature encodings.
+func buildScalarType(p Prim) (Prim, bool) {
+	t := Prim{Type: PrimNullary}
+	switch p.Type {
+	case PrimInt:
+		t.OpCode = p.Type.TypeCode()
+
+	case PrimBytes:
+		// detect address encoding first
+		var addr tezos.Address
+		if err := addr.Decode(p.Bytes); err == nil {
+			if addr.IsRollup() {
+				t.OpCode = T_TX_ROLLUP_L2_ADDRESS
+			} else {
+				t.OpCode = T_ADDRESS
+			}
+		}
+		if t.OpCode == 0 {
+			t.OpCode = p.Type.TypeCode()
+		}
+
+	case PrimString:
+		if len(p.String) > 0 {
+			// detect timestamp and address encoding first
+			if _, err := time.Parse(time.RFC3339, p.String); err == nil {
+				t.OpCode = T_TIMESTAMP
+			} else if addr, err := tezos.ParseAddress(p.String); err == nil {
+				if addr.IsRollup() {
+					t.OpCode = T_TX_ROLLUP_L2_ADDRESS
+				} else {
+					t.OpCode = T_ADDRESS
+				}
+			} else if _, err := tezos.ParseSignature(p.String); err == nil {
+				t.OpCode = T_SIGNATURE
+			}
+		}
+		// fallback to string
+		if t.OpCode == 0 {
+			t.OpCode = p.Type.TypeCode()
+		}
+
+	default:
+		return Prim{}, false
+	}
+	return t, true
+}
+
 func (p Prim) CanUnfoldType() bool {
 	if p.IsPair() {
 		return true
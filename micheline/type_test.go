@@ -5,8 +5,11 @@
 package micheline
 
 import (
+	"math/big"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type typedefTest struct {
@@ -259,12 +262,99 @@ var interfaceTests = []interfaceTest{
 		Value:  `[{"prim":"Pair","args":[{"bytes":"01b39686f116bb35115559f7e781200850e02854c400"},[{"prim":"Pair","args":[{"bytes":"0000f0ddca1cdfa0c48c92d162f3f72b8144ee2045ba"},{"int":"0"},{"int":"1027681"}]}]]}]`,
 		Expect: true,
 	},
-	// TODO
 	// union type
-	// optional flag
+	{
+		Name:   "nested_union_right",
+		Type:   `{"prim":"pair","args":[{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[{"prim":"Right","args":[{"prim":"Right","args":[{"string":"x"}]}]},{"int":"1"}]}`,
+		Expect: true,
+	},
+	{
+		Name:   "nested_union_left",
+		Type:   `{"prim":"pair","args":[{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[{"prim":"Left","args":[{"prim":"Unit"}]},{"int":"1"}]}`,
+		Expect: true,
+	},
+	{
+		Name:   "nested_union_wrong",
+		Type:   `{"prim":"pair","args":[{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[{"prim":"Right","args":[{"prim":"Right","args":[{"int":"1"}]}]},{"int":"1"}]}`,
+		Expect: false,
+	},
 	// set
-	// map
+	{
+		Name:   "set",
+		Type:   `{"prim":"pair","args":[{"prim":"set","args":[{"prim":"nat"}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[[{"int":"1"},{"int":"2"}],{"int":"1"}]}`,
+		Expect: true,
+	},
 	// bigmap
+	{
+		Name:   "empty_bigmap",
+		Type:   `{"prim":"pair","args":[{"prim":"big_map","args":[{"prim":"nat"},{"prim":"bytes"}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[[],{"int":"1"}]}`,
+		Expect: true,
+	},
+	// comb spanning an annotated inner pair
+	{
+		Name:   "comb_annotated_pair",
+		Type:   `{"prim":"pair","args":[{"prim":"nat"},{"prim":"pair","annots":["%inner"],"args":[{"prim":"string"},{"prim":"bool"}]}]}`,
+		Value:  `[{"int":"1"},{"string":"x"},{"prim":"True"}]`,
+		Expect: true,
+	},
+	{
+		Name:   "nested_pair",
+		Type:   `{"prim":"pair","args":[{"prim":"pair","args":[{"prim":"nat"},{"prim":"pair","args":[{"prim":"string"},{"prim":"bool"}]}]},{"prim":"nat"}]}`,
+		Value:  `{"prim":"Pair","args":[{"prim":"Pair","args":[{"int":"1"},{"prim":"Pair","args":[{"string":"x"},{"prim":"True"}]}]},{"int":"1"}]}`,
+		Expect: true,
+	},
+	// union branches are clipped on both sides
+	{
+		Name:   "nested_union_left_wrong",
+		Type:   `{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]}`,
+		Value:  `{"prim":"Left","args":[{"int":"1"}]}`,
+		Expect: false,
+	},
+	{
+		Name:   "nested_union_right_flat",
+		Type:   `{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]}`,
+		Value:  `{"prim":"Right","args":[{"int":"1"}]}`,
+		Expect: false,
+	},
+	{
+		Name:   "nested_union_right_left_wrong",
+		Type:   `{"prim":"or","args":[{"prim":"unit"},{"prim":"or","args":[{"prim":"nat"},{"prim":"string"}]}]}`,
+		Value:  `{"prim":"Right","args":[{"prim":"Left","args":[{"string":"x"}]}]}`,
+		Expect: false,
+	},
+	// pairs must follow the type structure
+	{
+		Name:   "pair_too_short",
+		Type:   `{"prim":"pair","args":[{"prim":"nat"},{"prim":"string"},{"prim":"bool"}]}`,
+		Value:  `{"prim":"Pair","args":[{"int":"1"},{"string":"x"}]}`,
+		Expect: false,
+	},
+	{
+		Name:   "pair_wrong_nesting",
+		Type:   `{"prim":"pair","args":[{"prim":"pair","args":[{"prim":"nat"},{"prim":"string"}]},{"prim":"bool"}]}`,
+		Value:  `{"prim":"Pair","args":[{"int":"1"},{"string":"x"},{"prim":"True"}]}`,
+		Expect: false,
+	},
+	{
+		Name:   "option_wrong",
+		Type:   `{"prim":"option","args":[{"prim":"nat"}]}`,
+		Value:  `{"prim":"Some","args":[{"string":"x"}]}`,
+		Expect: false,
+	},
+	// map
+	{
+		Name:   "map_wrong_value",
+		Type:   `{"prim":"map","args":[{"prim":"string"},{"prim":"nat"}]}`,
+		Value:  `[{"prim":"Elt","args":[{"string":"a"},{"string":"b"}]}]`,
+		Expect: false,
+	},
+	// TODO
+	// optional flag
 	// lambda
 	// ticket
 	// sapling
@@ -288,6 +378,75 @@ func TestInterfaceCheck(t *testing.T) {
 	}
 }
 
+func TestInterfaceCheckDeepNesting(t *testing.T) {
+	// nested pairs must not backtrack exponentially
+	typ, val := NewCode(T_NAT), NewNat(big.NewInt(1))
+	for i := 0; i < 64; i++ {
+		typ = NewPairType(NewPairType(NewCode(T_NAT), NewCode(T_NAT)), typ)
+		val = NewPair(NewPair(NewInt64(1), NewInt64(2)), val)
+	}
+	bad := NewPair(NewPair(NewInt64(1), NewInt64(2)), NewString("x"))
+	for i := 0; i < 64; i++ {
+		bad = NewPair(NewPair(NewInt64(1), NewInt64(2)), bad)
+	}
+	start := time.Now()
+	if !val.Implements(NewType(typ)) {
+		t.Errorf("nested value does not implement type")
+	}
+	if bad.Implements(NewType(typ)) {
+		t.Errorf("unexpected match for mismatched leaf")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("type check too slow: %s", d)
+	}
+}
+
+func TestInterfaceMissing(t *testing.T) {
+	script := NewScript()
+	script.Code.Param = NewCode(K_PARAMETER, NewCode(T_OR,
+		NewCodeAnno(T_OPTION, "%setDelegate", NewCode(T_KEY_HASH)),
+		NewCodeAnno(T_NAT, "%mint"),
+	))
+	eps, err := script.Entrypoints(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := ISetDelegate.Missing(eps); len(m) != 0 {
+		t.Errorf("unexpected missing entrypoints %v", m)
+	}
+	if m := IManager.Missing(eps); len(m) != 2 {
+		t.Errorf("expected 2 missing manager entrypoints, got %v", m)
+	}
+
+	// custom interface with one matching and one mismatched entrypoint
+	iface := Interface("TEST-MINTER")
+	if err := RegisterInterface(iface,
+		NewCodeAnno(T_OPTION, "%setDelegate", NewCode(T_KEY_HASH)),
+		NewCodeAnno(T_INT, "%mint"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		delete(InterfaceSpecs, iface)
+		WellKnownInterfaces = WellKnownInterfaces[:len(WellKnownInterfaces)-1]
+	}()
+	if m := iface.Missing(eps); len(m) != 1 || m[0] != "mint" {
+		t.Errorf("expected mismatched mint entrypoint, got %v", m)
+	}
+	if script.Implements(iface) {
+		t.Errorf("unexpected interface match")
+	}
+	if err := RegisterInterface(iface, NewCodeAnno(T_NAT, "%mint")); err != nil {
+		t.Fatal(err)
+	}
+	if !script.Interfaces().Contains(iface) {
+		t.Errorf("custom interface not detected")
+	}
+	if err := RegisterInterface(iface, NewCode(T_NAT)); err == nil {
+		t.Errorf("expected error for unnamed spec")
+	}
+}
+
 type bigmapDetectTest struct {
 	Name         string
 	Type         string
@@ -441,6 +600,56 @@ func TestBigmapDetect(t *testing.T) {
 	}
 }
 
+func TestBigmapDetectOrdered(t *testing.T) {
+	for _, test := range bigmapDetectTests {
+		if test.Name != "AKA-Royalties" {
+			continue
+		}
+		var typ, val Prim
+		if err := typ.UnmarshalJSON([]byte(test.Type)); err != nil {
+			t.Fatalf("unmarshal type: %v", err)
+		}
+		if err := val.UnmarshalJSON([]byte(test.Value)); err != nil {
+			t.Fatalf("unmarshal value: %v", err)
+		}
+		refs := DetectBigmapsOrdered(typ, val)
+		want := []BigmapRef{
+			{"constant_royalties", 55654},
+			{"get_royalty_list", 55655},
+			{"metadata", 55656},
+			{"KT1AFq5XorPduoYyWxs5gEyrFK6fVjJVbtCj", 55678},
+			{"KT1DEwdmXvjbdCz3HcehrYGiV46rVAwDiYVk", 292144},
+			{"KT1KEa8z6vWXDJrVqtMrAeDVzsvxat3kHaCE", 259052},
+			{"KT1MYSapB87YGSm1zxN3pbBGWDxea9YCkPH8", 300594},
+			{"KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton", 55710},
+			{"KT1U6EHmNxJTkvaWJ4ThczG4FSDaHC21ssvi", 259051},
+			{"royalties_updater", 55657},
+		}
+		if !reflect.DeepEqual(refs, want) {
+			t.Errorf("ordered bigmaps mismatch\nhave %v\nwant %v", refs, want)
+		}
+	}
+
+	// anonymous and duplicate names are numbered in declaration order
+	typ := NewPairType(
+		NewCode(T_BIG_MAP, NewPrim(T_NAT), NewPrim(T_NAT)),
+		NewPairType(
+			NewCode(T_BIG_MAP, NewPrim(T_NAT), NewPrim(T_NAT)),
+			NewPairType(
+				NewCodeAnno(T_BIG_MAP, "%ledger", NewPrim(T_NAT), NewPrim(T_NAT)),
+				NewCodeAnno(T_BIG_MAP, "%ledger", NewPrim(T_NAT), NewPrim(T_NAT)),
+			),
+		),
+	)
+	val := NewPair(NewBigmapRef(4), NewPair(NewBigmapRef(3), NewPair(NewBigmapRef(2), NewBigmapRef(1))))
+	want := []BigmapRef{{"bigmap_0", 4}, {"bigmap_1", 3}, {"ledger", 2}, {"ledger_0", 1}}
+	for i := 0; i < 10; i++ {
+		if refs := DetectBigmapsOrdered(typ, val); !reflect.DeepEqual(refs, want) {
+			t.Fatalf("unstable bigmap names\nhave %v\nwant %v", refs, want)
+		}
+	}
+}
+
 func TestBigmapTypeDetect(t *testing.T) {
 	for _, test := range bigmapDetectTests {
 		t.Run(test.Name, func(T *testing.T) {
@@ -639,3 +848,132 @@ func TestBigmapTypeCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{`{"int":"1"}`, `{"prim":"int"}`},
+		{`{"string":"a"}`, `{"prim":"string"}`},
+		{`{"prim":"Pair","args":[{"int":"1"},{"string":"a"},{"prim":"True"}]}`, `{"prim":"pair","args":[{"prim":"int"},{"prim":"string"},{"prim":"bool"}]}`},
+		{`[{"prim":"Elt","args":[{"string":"a"},{"prim":"Some","args":[{"int":"1"}]}]}]`, `{"prim":"map","args":[{"prim":"string"},{"prim":"option","args":[{"prim":"int"}]}]}`},
+		{`[{"bytes":"00"},{"bytes":"01"}]`, `{"prim":"list","args":[{"prim":"bytes"}]}`},
+		{`{"prim":"Left","args":[{"int":"1"}]}`, `{"prim":"or","args":[{"prim":"int"},{"prim":"int"}]}`},
+	}
+	for _, test := range tests {
+		var val Prim
+		if err := val.UnmarshalJSON([]byte(test.val)); err != nil {
+			t.Fatal(err)
+		}
+		want := MustParseType(test.want)
+		if got := val.InferType(); !got.IsEqual(want) {
+			t.Errorf("%s: want %s, got %s", test.val, want.Dump(), got.Dump())
+		}
+	}
+}
+
+func TestTypeIsComparable(t *testing.T) {
+	for i, test := range []struct {
+		typ  Prim
+		want bool
+	}{
+		{NewCode(T_NAT), true},
+		{NewCode(T_ADDRESS), true},
+		{NewPairType(NewCode(T_ADDRESS), NewCode(T_NAT)), true},
+		{NewCode(T_PAIR, NewCode(T_ADDRESS), NewCode(T_NAT), NewCode(T_STRING)), true},
+		{NewCode(T_OPTION, NewCode(T_KEY_HASH)), true},
+		{NewCode(T_OR, NewCode(T_INT), NewCode(T_BYTES)), true},
+		{NewCode(T_LIST, NewCode(T_NAT)), false},
+		{NewCode(T_MAP, NewCode(T_NAT), NewCode(T_NAT)), false},
+		{NewCode(T_CONTRACT, NewCode(T_UNIT)), false},
+		{NewPairType(NewCode(T_ADDRESS), NewCode(T_SET, NewCode(T_NAT))), false},
+		{NewCode(T_OPTION, NewCode(T_OPERATION)), false},
+	} {
+		if have := NewType(test.typ).IsComparable(); have != test.want {
+			t.Errorf("%d %s: have %t want %t", i, test.typ.Dump(), have, test.want)
+		}
+	}
+
+	// non-comparable key types are rejected before key construction
+	typ := NewType(NewCode(T_LIST, NewCode(T_NAT)))
+	if _, err := NewKey(typ, NewSeq(NewInt64(1))); err == nil {
+		t.Errorf("expected error for list key type")
+	}
+}
+
+func TestScriptIsStorageCompatible(t *testing.T) {
+	newScript := func(typ Prim) *Script {
+		s := NewScript()
+		s.Code.Storage = NewCode(K_STORAGE, typ)
+		return s
+	}
+	v1 := newScript(NewPairType(
+		NewCodeAnno(T_BIG_MAP, "%ledger", NewCode(T_ADDRESS), NewCode(T_NAT)),
+		NewPairType(
+			NewCodeAnno(T_ADDRESS, "%admin"),
+			NewCodeAnno(T_NAT, "%total"),
+		),
+	))
+
+	// comb layout and different annotations are compatible
+	v2 := newScript(NewCode(T_PAIR,
+		NewCodeAnno(T_BIG_MAP, "%balances", NewCode(T_ADDRESS), NewCode(T_NAT)),
+		NewCodeAnno(T_ADDRESS, "%owner"),
+		NewCodeAnno(T_NAT, "%supply"),
+	))
+	if ok, diff := v1.IsStorageCompatible(v2); !ok {
+		t.Errorf("expected compatible storage, diff %v", diff)
+	}
+
+	// changed field types are reported by path
+	v3 := newScript(NewPairType(
+		NewCodeAnno(T_BIG_MAP, "%ledger", NewCode(T_ADDRESS), NewCode(T_INT)),
+		NewPairType(
+			NewCodeAnno(T_ADDRESS, "%admin"),
+			NewCodeAnno(T_MUTEZ, "%total"),
+		),
+	))
+	ok, diff := v1.IsStorageCompatible(v3)
+	if ok {
+		t.Fatalf("expected incompatible storage")
+	}
+	if len(diff) != 2 || diff[0] != "ledger.@value" || diff[1] != "total" {
+		t.Errorf("unexpected diff %v", diff)
+	}
+}
+
+func TestTypeFindByName(t *testing.T) {
+	typ := NewType(NewPairType(
+		NewPairType(
+			NewCodeAnno(T_ADDRESS, "%admin"),
+			NewCodeAnno(T_BIG_MAP, "%records", NewCode(T_BYTES), NewCodeAnno(T_NAT, "%expiry")),
+		),
+		NewCodeAnno(T_NAT, "%total"),
+	))
+	for _, test := range []struct {
+		name string
+		op   OpCode
+		path []int
+	}{
+		{"admin", T_ADDRESS, []int{0, 0}},
+		{"records", T_BIG_MAP, []int{0, 1}},
+		{"expiry", T_NAT, []int{0, 1, 1}},
+		{"%total", T_NAT, []int{1}},
+	} {
+		p, path, ok := typ.FindByName(test.name)
+		if !ok {
+			t.Errorf("%s: not found", test.name)
+			continue
+		}
+		if p.OpCode != test.op || !reflect.DeepEqual(path, test.path) {
+			t.Errorf("%s: have %s %v want %s %v", test.name, p.OpCode, path, test.op, test.path)
+		}
+		if q, err := typ.GetIndex(path); err != nil || !q.IsEqual(p) {
+			t.Errorf("%s: path does not resolve to prim", test.name)
+		}
+	}
+	if _, _, ok := typ.FindByName("missing"); ok {
+		t.Errorf("expected missing name not to be found")
+	}
+}
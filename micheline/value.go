@@ -101,6 +101,9 @@ func (e *Value) FixType() {
 	e.Type.Anno = labels
 }
 
+// Map translates the value into a generic tree of Go maps, slices and scalars
+// using type annotations as keys. Numbers are returned as tezos.Z (bigmap ids
+// as int64) so that large ints and nats never lose precision.
 func (e *Value) Map() (interface{}, error) {
 	if e.mapped != nil {
 		return e.mapped, nil
@@ -123,6 +126,48 @@ func (e *Value) Map() (interface{}, error) {
 	return e.mapped, nil
 }
 
+// MapStrings works like Map, but renders all numbers as decimal strings
+// which makes the result safe for JSON consumers that parse numbers as
+// 64-bit floats.
+func (e *Value) MapStrings() (interface{}, error) {
+	m, err := e.Map()
+	if err != nil {
+		return nil, err
+	}
+	return stringifyNumbers(m), nil
+}
+
+// stringifyNumbers returns a copy of m with all numeric leaves converted to
+// strings. The original tree is left untouched since Map caches its result.
+func stringifyNumbers(m interface{}) interface{} {
+	switch t := m.(type) {
+	case map[string]interface{}:
+		mm := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			mm[k] = stringifyNumbers(v)
+		}
+		return mm
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = stringifyNumbers(v)
+		}
+		return s
+	case tezos.Z:
+		return t.String()
+	case *big.Int:
+		return t.Text(10)
+	case int:
+		return strconv.Itoa(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return m
+	}
+}
+
 func (e Value) MarshalJSON() ([]byte, error) {
 	m, err := e.Map()
 	if err != nil {
@@ -278,6 +323,30 @@ func (v *Value) GetBytes(label string) ([]byte, bool) {
 	return nil, false
 }
 
+// GetPacked reads the bytes field at label, unpacks its PACKed Michelson
+// contents (0x05 prefix) and returns them as value with inferred type.
+func (v *Value) GetPacked(label string) (Value, error) {
+	buf, ok := v.GetBytes(label)
+	if !ok {
+		return Value{}, fmt.Errorf("micheline: bytes field %q not found", label)
+	}
+	if !isPackedBytes(buf) {
+		return Value{}, fmt.Errorf("micheline: field %q does not contain packed data", label)
+	}
+	var p Prim
+	if err := p.UnmarshalBinary(buf[1:]); err != nil {
+		return Value{}, fmt.Errorf("micheline: unpacking field %q: %v", label, err)
+	}
+	p.WasPacked = true
+	typ := p.BuildType()
+	typ.WasPacked = true
+	return Value{
+		Type:   typ,
+		Value:  p,
+		Render: v.Render,
+	}, nil
+}
+
 func (v *Value) GetInt64(label string) (int64, bool) {
 	if m, err := v.Map(); err == nil {
 		if vv, ok := getPath(m, label); ok {
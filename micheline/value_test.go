@@ -403,3 +403,96 @@ func TestParamsValues(t *testing.T) {
 		}
 	}
 }
+
+func TestValueBigNumbers(t *testing.T) {
+	var test bigmapDetectTest
+	for _, v := range bigmapDetectTests {
+		if v.Name == "QLKUSD" {
+			test = v
+		}
+	}
+	var typ, val Prim
+	if err := typ.UnmarshalJSON([]byte(test.Type)); err != nil {
+		t.Fatalf("unmarshal type: %v", err)
+	}
+	if err := val.UnmarshalJSON([]byte(test.Value)); err != nil {
+		t.Fatalf("unmarshal value: %v", err)
+	}
+	const supply = "27974308647677254253603734093909520253599"
+	v := NewValue(NewType(typ), val)
+
+	// generic map must keep full precision
+	b, ok := v.GetBig("totalSupply")
+	if !ok {
+		t.Fatal("missing totalSupply")
+	}
+	if have := b.Text(10); have != supply {
+		t.Errorf("totalSupply mismatch: have %s want %s", have, supply)
+	}
+	m, err := v.Map()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := getPath(m, "totalSupply"); fmt.Sprint(have) != supply {
+		t.Errorf("mapped totalSupply mismatch: have %v (%T) want %s", have, have, supply)
+	}
+	buf, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"totalSupply":"`+supply+`"`) {
+		t.Errorf("json totalSupply not rendered as string: %s", buf)
+	}
+
+	// string variant renders all numbers as strings
+	s, err := v.MapStrings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"totalSupply":   supply,
+		"balances":      "1600",
+		"rewardPercent": "10",
+		"state":         "0",
+	} {
+		have, ok := getPath(s, path)
+		if !ok {
+			t.Errorf("missing %s", path)
+			continue
+		}
+		if str, ok := have.(string); !ok || str != want {
+			t.Errorf("%s: have %v (%T) want %q", path, have, have, want)
+		}
+	}
+
+	// cached map is not modified
+	if have, _ := getPath(m, "balances"); reflect.TypeOf(have).Kind() != reflect.Int64 {
+		t.Errorf("cached map modified: balances is %T", have)
+	}
+}
+
+func TestValueGetPacked(t *testing.T) {
+	typ := NewPairType(
+		NewCodeAnno(T_BYTES, "%data"),
+		NewCodeAnno(T_BYTES, "%raw"),
+	)
+	packed := NewPair(NewString("alice.tez"), NewInt64(42)).Pack()
+	v := NewValue(NewType(typ), NewPair(NewBytes(packed), NewBytes([]byte{0xff, 0x00})))
+
+	up, err := v.GetPacked("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := up.GetString("0"); !ok || s != "alice.tez" {
+		t.Errorf("unpacked string mismatch: have %q", s)
+	}
+	if n, ok := up.GetInt64("1"); !ok || n != 42 {
+		t.Errorf("unpacked int mismatch: have %d", n)
+	}
+	if _, err := v.GetPacked("raw"); err == nil {
+		t.Errorf("expected error for unpacked bytes")
+	}
+	if _, err := v.GetPacked("missing"); err == nil {
+		t.Errorf("expected error for missing field")
+	}
+}
@@ -10,9 +10,51 @@ import (
 // Ensure Activation implements the TypedOperation interface.
 var _ TypedOperation = (*Activation)(nil)
 
-// Activation represents a transaction operation
+// Activation represents an activate_account operation
 type Activation struct {
 	Generic
 	Pkh    tezos.Address  `json:"pkh"`
 	Secret tezos.HexBytes `json:"secret"`
 }
+
+// GetSource returns the activated account.
+func (a Activation) GetSource() tezos.Address {
+	return a.Pkh
+}
+
+// ActivateAccount is an alias for Activation using the codec type name.
+type ActivateAccount = Activation
+
+// Amount returns the amount credited to the activated account.
+func (a Activation) Amount() int64 {
+	var amount int64
+	for _, v := range a.Metadata.BalanceUpdates {
+		if v.Kind == CONTRACT && v.Change > 0 && v.Address().Equal(a.Pkh) {
+			amount += v.Change
+		}
+	}
+	return amount
+}
+
+// Commitment returns the blinded address of the commitment that was
+// consumed by this activation or an invalid address when the receipt has no
+// metadata.
+func (a Activation) Commitment() tezos.Address {
+	for _, v := range a.Metadata.BalanceUpdates {
+		if v.Kind == "commitment" {
+			return v.Committer
+		}
+	}
+	return tezos.InvalidAddress
+}
+
+// BlindedAddress returns the blinded address derived from the activated
+// public key hash and secret.
+func (a Activation) BlindedAddress() (tezos.Address, error) {
+	return tezos.BlindAddress(a.Pkh, a.Secret)
+}
+
+// Verify checks that the activation secret matches the consumed commitment.
+func (a Activation) Verify() bool {
+	return tezos.VerifyBlindedAddress(a.Commitment(), a.Pkh, a.Secret)
+}
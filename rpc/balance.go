@@ -7,10 +7,24 @@ import (
 	"blockwatch.cc/tzgo/tezos"
 )
 
+// BalanceOrigin describes where a balance update originates from.
+type BalanceOrigin string
+
+const (
+	OriginBlock      BalanceOrigin = "block"
+	OriginMigration  BalanceOrigin = "migration"
+	OriginSubsidy    BalanceOrigin = "subsidy"
+	OriginSimulation BalanceOrigin = "simulation"
+)
+
+func (o BalanceOrigin) String() string {
+	return string(o)
+}
+
 // BalanceUpdate is a variable structure depending on the Kind field
 type BalanceUpdate struct {
 	Kind     string `json:"kind"`          // contract, freezer, accumulator, commitment, minted, burned
-	Origin   string `json:"origin"`        // block, migration, subsidy
+	Origin   string `json:"origin"`        // block, migration, subsidy, simulation
 	Category string `json:"category"`      // optional, used on mint, burn, freezer
 	Change   int64  `json:"change,string"` // amount, <0 =
 
@@ -95,6 +109,17 @@ func (b BalanceUpdate) IsBakerStake() bool {
 	return b.Staker.Contract.IsValid() && (b.Staker.Delegate.IsValid() || b.Staker.Baker.IsValid())
 }
 
+// OriginType returns the typed origin of the update.
+func (b BalanceUpdate) OriginType() BalanceOrigin {
+	return BalanceOrigin(b.Origin)
+}
+
+// IsSimulation returns true when the update was produced by a simulation
+// and does not represent a real balance change on chain.
+func (b BalanceUpdate) IsSimulation() bool {
+	return b.OriginType() == OriginSimulation
+}
+
 func (b BalanceUpdate) Amount() int64 {
 	return b.Change
 }
@@ -115,3 +140,25 @@ func (b BalanceUpdate) Cycle() int64 {
 
 // BalanceUpdates is a list of balance update operations
 type BalanceUpdates []BalanceUpdate
+
+// ByOrigin returns all balance updates with origin o.
+func (l BalanceUpdates) ByOrigin(o BalanceOrigin) BalanceUpdates {
+	res := make(BalanceUpdates, 0, len(l))
+	for _, v := range l {
+		if v.OriginType() == o {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// nonSimulated returns all balance updates that did not originate from a simulation.
+func (l BalanceUpdates) nonSimulated() BalanceUpdates {
+	res := make(BalanceUpdates, 0, len(l))
+	for _, v := range l {
+		if !v.IsSimulation() {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -18,3 +18,8 @@ type Ballot struct {
 	Ballot   tezos.BallotVote   `json:"ballot"` // yay, nay, pass
 	Proposal tezos.ProtocolHash `json:"proposal"`
 }
+
+// GetSource returns the voting baker.
+func (e Ballot) GetSource() tezos.Address {
+	return e.Source
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of concurrent tasks a batch runs
+// when no explicit limit is set.
+var DefaultBatchConcurrency = 8
+
+// BatchFunc is a single task executed as part of a batch.
+type BatchFunc func(context.Context) error
+
+// Batch runs tasks concurrently with bounded parallelism. Use it for bulk
+// requests like fetching scripts, balances or metadata for many accounts.
+// By default the first error cancels the context passed to all remaining
+// tasks. Set ContinueOnError to run all tasks regardless. A batch is not safe
+// for concurrent use and can only be run once.
+type Batch struct {
+	ContinueOnError bool
+
+	c       *Client
+	workers int
+	tasks   []BatchFunc
+}
+
+// NewBatch returns a new batch that runs at most maxConcurrency tasks at
+// the same time. Values <= 0 select DefaultBatchConcurrency.
+func (c *Client) NewBatch(maxConcurrency int) *Batch {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultBatchConcurrency
+	}
+	return &Batch{
+		c:       c,
+		workers: maxConcurrency,
+	}
+}
+
+// Client returns the client the batch was created from.
+func (b *Batch) Client() *Client {
+	return b.c
+}
+
+// Len returns the number of tasks in the batch.
+func (b *Batch) Len() int {
+	return len(b.tasks)
+}
+
+// Add appends a task to the batch. Tasks start when Wait is called.
+func (b *Batch) Add(fn BatchFunc) *Batch {
+	b.tasks = append(b.tasks, fn)
+	return b
+}
+
+// Wait runs all tasks and blocks until they have finished. It returns the
+// first error a task has returned or the context error when ctx is canceled
+// before all tasks were started.
+func (b *Batch) Wait(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+		sem   = make(chan struct{}, b.workers)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			first = err
+			if !b.ContinueOnError {
+				cancel()
+			}
+		})
+	}
+
+	for _, fn := range b.tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(fn BatchFunc) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctx); err != nil {
+				fail(err)
+			}
+		}(fn)
+	}
+	wg.Wait()
+	b.tasks = nil
+	return first
+}
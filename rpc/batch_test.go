@@ -0,0 +1,89 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	c, err := NewClient("http://localhost", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bounded concurrency
+	var running, peak, done int32
+	b := c.NewBatch(3)
+	for i := 0; i < 20; i++ {
+		b.Add(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+	}
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if done != 20 {
+		t.Errorf("expected 20 finished tasks, got %d", done)
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent tasks, got %d", peak)
+	}
+
+	// cancel on first error
+	errFail := errors.New("fail")
+	var canceled int32
+	b = c.NewBatch(2)
+	b.Add(func(context.Context) error { return errFail })
+	for i := 0; i < 5; i++ {
+		b.Add(func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				atomic.AddInt32(&canceled, 1)
+			case <-time.After(time.Second):
+			}
+			return nil
+		})
+	}
+	if err := b.Wait(context.Background()); err != errFail {
+		t.Errorf("expected first error, got %v", err)
+	}
+	if canceled == 0 {
+		t.Errorf("expected running tasks to be canceled")
+	}
+
+	// continue on error
+	done = 0
+	b = c.NewBatch(2)
+	b.ContinueOnError = true
+	b.Add(func(context.Context) error { return errFail })
+	for i := 0; i < 5; i++ {
+		b.Add(func(ctx context.Context) error {
+			if ctx.Err() == nil {
+				atomic.AddInt32(&done, 1)
+			}
+			return nil
+		})
+	}
+	if err := b.Wait(context.Background()); err != errFail {
+		t.Errorf("expected first error, got %v", err)
+	}
+	if done != 5 {
+		t.Errorf("expected 5 finished tasks, got %d", done)
+	}
+}
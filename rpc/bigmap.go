@@ -0,0 +1,138 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// BigmapIterator reads bigmap entries page by page at a fixed block. Each page
+// loads values for a range of keys from the node's key listing, so the number
+// of values held in memory is bounded by the page size. Use ResumeToken to checkpoint progress and
+// ResumeBigmap to continue iteration later, e.g. after a restart.
+type BigmapIterator struct {
+	c        *Client
+	ctx      context.Context
+	bigmap   int64
+	block    tezos.BlockHash
+	pageSize int
+	offset   int              // listing offset of the next entry returned by Next
+	keys     []tezos.ExprHash // keys of the current page
+	vals     []micheline.Prim // values of the current page
+	done     bool             // set when the last page has been fetched
+	err      error
+}
+
+// IterateBigmap returns an iterator over all entries of bigmap at block id.
+// The block is resolved to its hash so that iteration remains stable while
+// the chain progresses. Since nodes do not expose key pre-images, entries are
+// returned as key hash and value.
+func (c *Client) IterateBigmap(ctx context.Context, bigmap int64, id BlockID, pageSize int) (*BigmapIterator, error) {
+	hash, err := c.GetBlockHash(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.newBigmapIterator(ctx, bigmap, hash, 0, pageSize), nil
+}
+
+// ResumeBigmap continues a bigmap iteration from a token previously returned
+// by BigmapIterator.ResumeToken.
+func (c *Client) ResumeBigmap(ctx context.Context, token string, pageSize int) (*BigmapIterator, error) {
+	fields := strings.Split(token, ":")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("rpc: invalid bigmap resume token %q", token)
+	}
+	bigmap, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("rpc: invalid bigmap resume token %q: %v", token, err)
+	}
+	block, err := tezos.ParseBlockHash(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("rpc: invalid bigmap resume token %q: %v", token, err)
+	}
+	offset, err := strconv.Atoi(fields[2])
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("rpc: invalid bigmap resume token %q: bad offset", token)
+	}
+	return c.newBigmapIterator(ctx, bigmap, block, offset, pageSize), nil
+}
+
+func (c *Client) newBigmapIterator(ctx context.Context, bigmap int64, block tezos.BlockHash, offset, pageSize int) *BigmapIterator {
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	return &BigmapIterator{
+		c:        c,
+		ctx:      ctx,
+		bigmap:   bigmap,
+		block:    block,
+		pageSize: pageSize,
+		offset:   offset,
+	}
+}
+
+// Next returns the next bigmap entry. It returns false when all entries have
+// been read or an error occurred. Check Err to distinguish both cases.
+func (it *BigmapIterator) Next() (tezos.ExprHash, micheline.Prim, bool) {
+	if it.err != nil {
+		return tezos.ZeroExprHash, micheline.InvalidPrim, false
+	}
+	if len(it.keys) == 0 {
+		if it.done {
+			return tezos.ZeroExprHash, micheline.InvalidPrim, false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return tezos.ZeroExprHash, micheline.InvalidPrim, false
+		}
+		if len(it.keys) == 0 {
+			return tezos.ZeroExprHash, micheline.InvalidPrim, false
+		}
+	}
+	key, val := it.keys[0], it.vals[0]
+	it.keys, it.vals = it.keys[1:], it.vals[1:]
+	it.offset++
+	return key, val, true
+}
+
+// fetch lists the next page of keys and loads their values.
+func (it *BigmapIterator) fetch() error {
+	keys, err := it.c.ListBigmapKeysExt(it.ctx, it.bigmap, it.block, it.offset, it.pageSize)
+	if err != nil {
+		return err
+	}
+	vals := make([]micheline.Prim, 0, len(keys))
+	for _, key := range keys {
+		val, err := it.c.GetBigmapValue(it.ctx, it.bigmap, key, it.block)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, val)
+	}
+	it.keys, it.vals = keys, vals
+	it.done = len(keys) < it.pageSize
+	return nil
+}
+
+// Err returns the first error encountered during iteration.
+func (it *BigmapIterator) Err() error {
+	return it.err
+}
+
+// Offset returns the position of the next entry in the node's key listing.
+func (it *BigmapIterator) Offset() int {
+	return it.offset
+}
+
+// ResumeToken returns an opaque token that identifies the current iteration
+// position. Resuming from it continues after the last entry returned by Next.
+func (it *BigmapIterator) ResumeToken() string {
+	return strconv.FormatInt(it.bigmap, 10) + ":" + it.block.String() + ":" + strconv.Itoa(it.offset)
+}
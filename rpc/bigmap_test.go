@@ -0,0 +1,207 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestBigmapIterator(t *testing.T) {
+	block := testHash("b1")
+	entries := make(map[tezos.ExprHash]int64)
+	var keys []tezos.ExprHash
+	for i := int64(0); i < 7; i++ {
+		h := tezos.NewExprHash([]byte(fmt.Sprintf("key_%d%s", i, strings.Repeat("_", 26))))
+		entries[h] = i
+		keys = append(keys, h)
+	}
+	var calls, pages int32
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/hash": block,
+		"/chains/main/blocks/" + block.String() + "/context/raw/json/big_maps/index/17/contents": func(r *http.Request) any {
+			// like Octez, ignore paging arguments and return the full listing
+			atomic.AddInt32(&pages, 1)
+			return keys
+		},
+		"/chains/main/blocks/" + block.String() + "/context/big_maps/17/*": func(r *http.Request) any {
+			atomic.AddInt32(&calls, 1)
+			p := r.URL.Path
+			h, err := tezos.ParseExprHash(p[strings.LastIndexByte(p, '/')+1:])
+			if err != nil {
+				return nil
+			}
+			return micheline.NewInt64(entries[h])
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	it, err := c.IterateBigmap(ctx, 17, Head, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		seen  []tezos.ExprHash
+		token string
+	)
+	for i := 0; i < 4; i++ {
+		k, v, ok := it.Next()
+		if !ok {
+			t.Fatalf("early end: %v", it.Err())
+		}
+		if v.Int.Int64() != entries[k] {
+			t.Errorf("value mismatch for %s", k)
+		}
+		seen = append(seen, k)
+	}
+	token = it.ResumeToken()
+	if n := atomic.LoadInt32(&calls); n != 6 {
+		t.Errorf("expected 2 pages of values, fetched %d", n)
+	}
+	if n := atomic.LoadInt32(&pages); n != 2 {
+		t.Errorf("expected 2 key pages, listed %d", n)
+	}
+	if it.Offset() != 4 {
+		t.Errorf("unexpected offset %d", it.Offset())
+	}
+
+	// resume in a new iterator
+	it, err = c.ResumeBigmap(ctx, token, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		k, v, ok := it.Next()
+		if !ok {
+			break
+		}
+		if v.Int.Int64() != entries[k] {
+			t.Errorf("value mismatch for %s", k)
+		}
+		seen = append(seen, k)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), len(seen))
+	}
+	for i, k := range seen {
+		if !k.Equal(keys[i]) {
+			t.Errorf("key %d out of listing order", i)
+		}
+	}
+	// 2 pages in the resumed iterator, the last one empty
+	if n := atomic.LoadInt32(&pages); n != 4 {
+		t.Errorf("expected 4 key pages in total, listed %d", n)
+	}
+	if _, err := c.ResumeBigmap(ctx, "17:invalid", 3); err == nil {
+		t.Errorf("expected error for invalid token")
+	}
+}
+
+func TestBigmapIteratorFullPage(t *testing.T) {
+	block := testHash("b1")
+	var keys []tezos.ExprHash
+	for i := 0; i < 3; i++ {
+		keys = append(keys, tezos.NewExprHash([]byte(fmt.Sprintf("key_%d%s", i, strings.Repeat("_", 26)))))
+	}
+	var pages int32
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/hash": block,
+		"/chains/main/blocks/" + block.String() + "/context/raw/json/big_maps/index/17/contents": func(*http.Request) any {
+			if atomic.AddInt32(&pages, 1) > 3 {
+				t.Errorf("iterator does not terminate")
+				return nil
+			}
+			return keys
+		},
+		"/chains/main/blocks/" + block.String() + "/context/big_maps/17/*": micheline.NewInt64(1),
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bigmap holds exactly one page of keys
+	it, err := c.IterateBigmap(context.Background(), 17, Head, len(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []tezos.ExprHash
+	for {
+		k, _, ok := it.Next()
+		if !ok {
+			break
+		}
+		seen = append(seen, k)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(keys) {
+		t.Fatalf("expected %d entries without duplicates, got %d", len(keys), len(seen))
+	}
+	for i, k := range seen {
+		if !k.Equal(keys[i]) {
+			t.Errorf("key %d out of listing order", i)
+		}
+	}
+	if n := atomic.LoadInt32(&pages); n != 2 {
+		t.Errorf("expected 2 key pages, listed %d", n)
+	}
+}
+
+func TestListBigmapKeysExt(t *testing.T) {
+	var keys []tezos.ExprHash
+	for i := 0; i < 5; i++ {
+		keys = append(keys, tezos.NewExprHash([]byte(fmt.Sprintf("key_%d%s", i, strings.Repeat("_", 26)))))
+	}
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/raw/json/big_maps/index/17/contents": keys,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		offset, limit int
+		want          []tezos.ExprHash
+	}{
+		{0, 10, keys},
+		{0, 5, keys},
+		{0, 2, keys[:2]},
+		{2, 10, keys[2:]},
+		{2, 2, keys[2:4]},
+		{4, 2, keys[4:]},
+		{5, 2, nil},
+		{7, 2, nil},
+	} {
+		have, err := c.ListBigmapKeysExt(context.Background(), 17, Head, v.offset, v.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(have) != len(v.want) {
+			t.Errorf("offset=%d limit=%d: want %d keys, got %d", v.offset, v.limit, len(v.want), len(have))
+			continue
+		}
+		for i := range have {
+			if !have[i].Equal(v.want[i]) {
+				t.Errorf("offset=%d limit=%d: key %d mismatch", v.offset, v.limit, i)
+			}
+		}
+	}
+}
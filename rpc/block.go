@@ -95,6 +95,96 @@ func (b Block) GetVotingPeriod() int64 {
 	return 0
 }
 
+// Baker returns the delegate who signed the block and received baking rewards.
+func (b Block) Baker() tezos.Address {
+	return b.Metadata.Baker
+}
+
+// Proposer returns the delegate who proposed the block payload. Before
+// Tenderbake (v012) proposer and baker were always identical.
+func (b Block) Proposer() tezos.Address {
+	if b.Metadata.Proposer.IsValid() {
+		return b.Metadata.Proposer
+	}
+	return b.Metadata.Baker
+}
+
+// Round returns the round at which a Tenderbake block was baked or the baking
+// priority for blocks before v012. Tenderbake stores the block round as last
+// fitness element, which may differ from the payload round on re-proposals.
+func (b Block) Round() int {
+	return b.Header.Round()
+}
+
+// PayloadHash returns the hash of the block payload. Empty before v012.
+func (b Block) PayloadHash() tezos.PayloadHash {
+	return b.Header.PayloadHash
+}
+
+// ConsumedGas returns the total gas consumed by all operations in the block.
+func (b Block) ConsumedGas() int64 {
+	return milliGasToGas(b.Metadata.ConsumedMilliGas, b.Metadata.ConsumedGas)
+}
+
+// NumOperations returns the total number of operations in all validation passes.
+func (b Block) NumOperations() int {
+	var n int
+	for _, list := range b.Operations {
+		n += len(list)
+	}
+	return n
+}
+
+// OperationAt returns the operation at position n when counting operations
+// across all validation passes in order. Along with the operation's first
+// content it returns the block id and the list and position of the operation
+// inside Operations. Use the coordinates to access all contents of a batch.
+func (b Block) OperationAt(n int) (TypedOperation, BlockID, int, int, error) {
+	if n >= 0 {
+		pos := n
+		for l, list := range b.Operations {
+			if pos < len(list) {
+				op := list[pos]
+				if len(op.Contents) == 0 {
+					return nil, b.Hash, l, pos, fmt.Errorf("rpc: empty operation %s", op.Hash)
+				}
+				return op.Contents[0], b.Hash, l, pos, nil
+			}
+			pos -= len(list)
+		}
+	}
+	return nil, b.Hash, -1, -1, fmt.Errorf("rpc: operation index %d out of range [0,%d)", n, b.NumOperations())
+}
+
+// OperationsForAddress returns all operation contents and internal operations
+// in the block where addr is source or destination or appears in balance
+// updates. Internal transactions are returned as *Transaction, other internal
+// operations as *InternalOperation. Internal operations follow their
+// top-level operation in block order.
+func (b Block) OperationsForAddress(addr tezos.Address) []TypedOperation {
+	res := make([]TypedOperation, 0)
+	for _, list := range b.Operations {
+		for _, op := range list {
+			for _, c := range op.Contents {
+				if isRelated(c, addr) {
+					res = append(res, c)
+				}
+				for _, r := range c.Meta().InternalResults {
+					if !r.isRelated(addr) {
+						continue
+					}
+					if tx := r.AsTransaction(); tx != nil {
+						res = append(res, tx)
+					} else {
+						res = append(res, NewInternalOperation(r))
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
 func (b Block) IsProtocolUpgrade() bool {
 	return !b.Metadata.Protocol.Equal(b.Metadata.NextProtocol)
 }
@@ -148,6 +238,18 @@ func (h BlockHeader) AiVote() tezos.FeatureVote {
 	return h.AdaptiveIssuanceVote
 }
 
+// Round returns the block round for Tenderbake headers or the baking priority
+// for headers before v012.
+func (h BlockHeader) Round() int {
+	if !h.PayloadHash.IsValid() {
+		return h.Priority
+	}
+	if n := len(h.Fitness); n > 0 && len(h.Fitness[n-1]) == 4 {
+		return int(int32(binary.BigEndian.Uint32(h.Fitness[n-1])))
+	}
+	return h.PayloadRound
+}
+
 // ProtocolData exports protocol-specific extra header fields as binary encoded data.
 // Used to produce compliant block monitor data streams.
 //
@@ -240,6 +342,7 @@ type BlockMetadata struct {
 	Proposer               tezos.Address          `json:"proposer"`
 	NonceHash              tezos.NonceHash        `json:"nonce_hash"`
 	ConsumedGas            int64                  `json:"consumed_gas,string"`
+	ConsumedMilliGas       int64                  `json:"consumed_milligas,string"` // v015+
 	Deactivated            []tezos.Address        `json:"deactivated"`
 	BalanceUpdates         BalanceUpdates         `json:"balance_updates"`
 
@@ -255,9 +358,19 @@ type BlockMetadata struct {
 	ImplicitOperationsResults []ImplicitResult `json:"implicit_operations_results"`
 	LiquidityBakingEscapeEma  int64            `json:"liquidity_baking_escape_ema"`
 
+	// v013+
+	LiquidityBakingToggleEma int64 `json:"liquidity_baking_toggle_ema"`
+
 	// v015+
 	ProposerConsensusKey tezos.Address `json:"proposer_consensus_key"`
 	BakerConsensusKey    tezos.Address `json:"baker_consensus_key"`
+
+	// v018+
+	AdaptiveIssuanceVoteEma         int64  `json:"adaptive_issuance_vote_ema"`
+	AdaptiveIssuanceActivationCycle *int64 `json:"adaptive_issuance_activation_cycle"`
+
+	// v019+
+	DalAttestation *tezos.Z `json:"dal_attestation"`
 }
 
 func (m *BlockMetadata) GetLevel() int64 {
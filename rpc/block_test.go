@@ -0,0 +1,223 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestBlockAccessors(t *testing.T) {
+	// Tenderbake block re-proposed at round 1 with a payload from round 0
+	const tenderbake = `{
+		"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+		"header": {
+			"level": 5000000,
+			"proto": 18,
+			"fitness": ["02", "004c4b40", "00000000", "ffffffff", "00000001"],
+			"payload_hash": "vh2nZrxixzv4ZjAJn7PRj79GumUMAJzxuEYMjo496TYSaWhXYjZM",
+			"payload_round": 0
+		},
+		"metadata": {
+			"proposer": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"baker": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw",
+			"consumed_milligas": "1520001"
+		}
+	}`
+	var b Block
+	if err := json.Unmarshal([]byte(tenderbake), &b); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := b.Proposer(), tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"); !have.Equal(want) {
+		t.Errorf("proposer: have %s want %s", have, want)
+	}
+	if have, want := b.Baker(), tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"); !have.Equal(want) {
+		t.Errorf("baker: have %s want %s", have, want)
+	}
+	if have := b.Round(); have != 1 {
+		t.Errorf("round: have %d want 1", have)
+	}
+	if !b.PayloadHash().IsValid() {
+		t.Errorf("missing payload hash")
+	}
+	if have := b.ConsumedGas(); have != 1521 {
+		t.Errorf("consumed gas: have %d want 1521", have)
+	}
+
+	// Emmy block uses priority and baker as proposer
+	const emmy = `{
+		"header": {"level": 1000, "proto": 10, "priority": 2},
+		"metadata": {"baker": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw", "consumed_gas": "400"}
+	}`
+	b = Block{}
+	if err := json.Unmarshal([]byte(emmy), &b); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Proposer().Equal(b.Baker()) {
+		t.Errorf("emmy proposer %s != baker %s", b.Proposer(), b.Baker())
+	}
+	if have := b.Round(); have != 2 {
+		t.Errorf("priority: have %d want 2", have)
+	}
+	if have := b.ConsumedGas(); have != 400 {
+		t.Errorf("consumed gas: have %d want 400", have)
+	}
+}
+
+func TestBlockOperationAt(t *testing.T) {
+	newOp := func(kind tezos.OpType) *Operation {
+		return &Operation{Contents: OperationList{&Generic{OpKind: kind}}}
+	}
+	b := Block{
+		Hash: tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm"),
+		Operations: [][]*Operation{
+			{newOp(tezos.OpTypeEndorsement), newOp(tezos.OpTypeEndorsement)},
+			{},
+			{newOp(tezos.OpTypeSeedNonceRevelation)},
+			{newOp(tezos.OpTypeTransaction), newOp(tezos.OpTypeDelegation)},
+		},
+	}
+	if n := b.NumOperations(); n != 5 {
+		t.Errorf("expected 5 operations, got %d", n)
+	}
+	for i, want := range []struct {
+		kind    tezos.OpType
+		list, n int
+	}{
+		{tezos.OpTypeEndorsement, 0, 0},
+		{tezos.OpTypeEndorsement, 0, 1},
+		{tezos.OpTypeSeedNonceRevelation, 2, 0},
+		{tezos.OpTypeTransaction, 3, 0},
+		{tezos.OpTypeDelegation, 3, 1},
+	} {
+		op, id, l, n, err := b.OperationAt(i)
+		if err != nil {
+			t.Fatalf("index %d: %v", i, err)
+		}
+		if op.Kind() != want.kind || l != want.list || n != want.n {
+			t.Errorf("index %d: have %s [%d/%d] want %s [%d/%d]", i, op.Kind(), l, n, want.kind, want.list, want.n)
+		}
+		if id.String() != b.Hash.String() {
+			t.Errorf("index %d: block id mismatch %s", i, id)
+		}
+	}
+	for _, i := range []int{-1, 5} {
+		if _, _, _, _, err := b.OperationAt(i); err == nil {
+			t.Errorf("index %d: expected out of range error", i)
+		}
+	}
+}
+
+func TestBlockOperationsForAddress(t *testing.T) {
+	alice := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	bob := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	kt1 := tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+	endorse := &Endorsement{Generic: Generic{
+		OpKind:   tezos.OpTypeEndorsement,
+		Metadata: OperationMetadata{Delegate: bob},
+	}}
+	tx := &Transaction{
+		Manager: Manager{
+			Generic: Generic{
+				OpKind: tezos.OpTypeTransaction,
+				Metadata: OperationMetadata{
+					InternalResults: []*InternalResult{{
+						Kind:        tezos.OpTypeTransaction,
+						Source:      kt1,
+						Destination: &bob,
+						Amount:      10,
+					}, {
+						Kind:     tezos.OpTypeDelegation,
+						Source:   kt1,
+						Delegate: &bob,
+					}},
+				},
+			},
+			Source: alice,
+		},
+		Destination: kt1,
+	}
+	b := Block{
+		Operations: [][]*Operation{
+			{{Contents: OperationList{endorse}}},
+			{},
+			{},
+			{{Contents: OperationList{tx}}},
+		},
+	}
+	for _, test := range []struct {
+		addr  tezos.Address
+		kinds string
+	}{
+		{alice, "transaction"},
+		{bob, "endorsement,transaction,delegation"},
+		{kt1, "transaction,transaction,delegation"},
+		{tezos.ZeroAddress, ""},
+	} {
+		ops := b.OperationsForAddress(test.addr)
+		kinds := make([]string, len(ops))
+		for i, op := range ops {
+			kinds[i] = op.Kind().String()
+		}
+		if have := strings.Join(kinds, ","); have != test.kinds {
+			t.Errorf("%s: have ops %q want %q", test.addr, have, test.kinds)
+		}
+	}
+	ops := b.OperationsForAddress(bob)
+	if itx, ok := ops[1].(*Transaction); !ok || !itx.Source.Equal(kt1) || itx.Amount != 10 {
+		t.Errorf("unexpected internal transaction %#v", ops[1])
+	}
+	if idel, ok := ops[2].(*InternalOperation); !ok || !idel.GetSource().Equal(kt1) {
+		t.Errorf("unexpected internal delegation %#v", ops[2])
+	}
+}
+
+func TestBlockActiveFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want FeatureFlags
+	}{{
+		name: "emmy",
+		json: `{"protocol": "PsFLorenaUUuikDWvMDr6fGBRG8kt3e3D3fHoXK1j1BFRxeSH4i"}`,
+		want: 0,
+	}, {
+		name: "granada",
+		json: `{"protocol": "PtGRANADsDU8R9daYKAgWnQYAJ64omN1o3KMGVCykShA97vQbvV",
+			"metadata": {"liquidity_baking_escape_ema": 120000}}`,
+		want: FeatureLiquidityBaking,
+	}, {
+		name: "mumbai lb off",
+		json: `{"protocol": "PtMumbai2TmsJHNGRkD8v8YDbtao7BLUC3wjASn1inAKLFCjaH1",
+			"metadata": {"liquidity_baking_toggle_ema": 1200000000}}`,
+		want: FeatureTenderbake | FeatureConsensusKeys | FeatureSmartRollups,
+	}, {
+		name: "oxford ai pending",
+		json: `{"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+			"metadata": {"level_info": {"cycle": 740}, "adaptive_issuance_activation_cycle": 748}}`,
+		want: FeatureLiquidityBaking | FeatureTenderbake | FeatureConsensusKeys | FeatureSmartRollups,
+	}, {
+		name: "oxford ai active",
+		json: `{"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+			"metadata": {"level_info": {"cycle": 748}, "adaptive_issuance_activation_cycle": 748}}`,
+		want: FeatureLiquidityBaking | FeatureTenderbake | FeatureConsensusKeys | FeatureSmartRollups | FeatureAdaptiveIssuance,
+	}, {
+		name: "alpha dal",
+		json: `{"protocol": "ProtoALphaALphaALphaALphaALphaALphaALphaALphaDdp3zK",
+			"metadata": {"dal_attestation": "0"}}`,
+		want: FeatureLiquidityBaking | FeatureTenderbake | FeatureConsensusKeys | FeatureSmartRollups | FeatureDal,
+	}}
+	for _, test := range tests {
+		var b Block
+		if err := json.Unmarshal([]byte(test.json), &b); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if have := b.ActiveFeatures(); have != test.want {
+			t.Errorf("%s: have features %s want %s", test.name, have, test.want)
+		}
+	}
+}
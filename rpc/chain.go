@@ -4,8 +4,10 @@
 package rpc
 
 import (
-	"blockwatch.cc/tzgo/tezos"
 	"context"
+	"encoding/json"
+
+	"blockwatch.cc/tzgo/tezos"
 )
 
 // GetChainId returns the chain id (i.e. network id).
@@ -61,3 +63,74 @@ func (c *Client) GetVersionInfo(ctx context.Context) (VersionInfo, error) {
 	err := c.Get(ctx, "version", &v)
 	return v, err
 }
+
+// CheckpointLevel identifies a block tracked by the node's store.
+type CheckpointLevel struct {
+	Hash  tezos.BlockHash `json:"block_hash"`
+	Level int64           `json:"level"`
+}
+
+// Checkpoint describes the range of history a node can serve. Blocks below
+// Caboose are unknown to the node, blocks below Savepoint have no metadata
+// (i.e. no receipts) and blocks up to Checkpoint are final.
+type Checkpoint struct {
+	Checkpoint  CheckpointLevel `json:"checkpoint"`
+	Savepoint   CheckpointLevel `json:"savepoint"`
+	Caboose     CheckpointLevel `json:"caboose"`
+	HistoryMode string          `json:"history_mode"`
+}
+
+// HasBlock returns true when the node stores the block at height.
+func (c Checkpoint) HasBlock(height int64) bool {
+	return height >= c.Caboose.Level
+}
+
+// HasMetadata returns true when the node stores block metadata and operation
+// receipts at height.
+func (c Checkpoint) HasMetadata(height int64) bool {
+	return height >= c.Savepoint.Level
+}
+
+// GetCheckpoint returns the node's checkpoint, savepoint, caboose and history
+// mode. Use it with rolling and full nodes to find out how much history is
+// available before scanning.
+// https://tezos.gitlab.io/shell/rpc.html#get-chains-chain-id-levels-checkpoint
+func (c *Client) GetCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+	for _, v := range []struct {
+		alias BlockAlias
+		level *CheckpointLevel
+	}{
+		{CheckpointBlock, &cp.Checkpoint},
+		{SavepointBlock, &cp.Savepoint},
+		{CabooseBlock, &cp.Caboose},
+	} {
+		if err := c.Get(ctx, "chains/main/levels/"+v.alias.String(), v.level); err != nil {
+			return nil, err
+		}
+	}
+	var mode struct {
+		Mode json.RawMessage `json:"history_mode"`
+	}
+	if err := c.Get(ctx, "config/history_mode", &mode); err != nil {
+		return nil, err
+	}
+	cp.HistoryMode = parseHistoryMode(mode.Mode)
+	return cp, nil
+}
+
+// parseHistoryMode extracts the mode name which is either a plain string
+// like "archive" or an object like {"rolling":{"additional_cycles":1}}.
+func parseHistoryMode(buf json.RawMessage) string {
+	var s string
+	if json.Unmarshal(buf, &s) == nil {
+		return s
+	}
+	var m map[string]json.RawMessage
+	if json.Unmarshal(buf, &m) == nil {
+		for k := range m {
+			return k
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+)
+
+func TestGetCheckpoint(t *testing.T) {
+	const hash = "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm"
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/levels/checkpoint": `{"block_hash":"` + hash + `","level":5000}`,
+		"/chains/main/levels/savepoint":  `{"block_hash":"` + hash + `","level":4000}`,
+		"/chains/main/levels/caboose":    `{"block_hash":"` + hash + `","level":3000}`,
+		"/config/history_mode":           `{"history_mode":{"rolling":{"additional_cycles":1}}}`,
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := c.GetCheckpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Checkpoint.Level != 5000 || cp.Savepoint.Level != 4000 || cp.Caboose.Level != 3000 {
+		t.Errorf("unexpected levels %#v", cp)
+	}
+	if cp.Caboose.Hash.String() != hash {
+		t.Errorf("unexpected caboose hash %s", cp.Caboose.Hash)
+	}
+	if cp.HistoryMode != "rolling" {
+		t.Errorf("unexpected history mode %q", cp.HistoryMode)
+	}
+	if cp.HasBlock(2999) || !cp.HasBlock(3000) || cp.HasMetadata(3999) || !cp.HasMetadata(4000) {
+		t.Errorf("unexpected history range checks")
+	}
+	if parseHistoryMode([]byte(`"archive"`)) != "archive" {
+		t.Errorf("unexpected plain history mode")
+	}
+}
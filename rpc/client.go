@@ -14,6 +14,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"blockwatch.cc/tzgo/signer"
 	"blockwatch.cc/tzgo/tezos"
@@ -56,8 +57,13 @@ type Client struct {
 	// Close connections. This may help with EOF errors from unexpected
 	// connection close by Tezos RPC.
 	CloseConns bool
+	// Default timeout for each HTTP request (zero = no timeout). Individual
+	// calls can override it using WithCallTimeout.
+	CallTimeout time.Duration
 	// Log is the logger implementation used by this client
 	Log log.Logger
+	// storage types of contracts per protocol, migrations may rewrite scripts
+	storageTypes storageTypeCache
 }
 
 // NewClient returns a new Tezos RPC client.
@@ -108,6 +114,36 @@ func (c *Client) UseIpfsUrl(uri string) error {
 	return nil
 }
 
+// WithDefaultCallTimeout sets a timeout that applies to each HTTP request
+// unless a call overrides it using WithCallTimeout.
+func (c *Client) WithDefaultCallTimeout(d time.Duration) *Client {
+	c.CallTimeout = d
+	return c
+}
+
+type callTimeoutKey struct{}
+
+// WithCallTimeout returns a copy of ctx that makes the client apply timeout d
+// to each HTTP request sent with this context. It overrides the client's
+// default call timeout, a zero duration disables timeouts.
+func WithCallTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, d)
+}
+
+// CallContext derives a context for a single HTTP request from ctx that
+// expires after the configured call timeout. Callers must call cancel after
+// the response was processed.
+func (c *Client) CallContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := c.CallTimeout
+	if v, ok := ctx.Value(callTimeoutKey{}).(time.Duration); ok {
+		d = v
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
 func (c *Client) Client() *http.Client {
 	return c.client
 }
@@ -145,6 +181,20 @@ func (c *Client) Get(ctx context.Context, urlpath string, result interface{}) er
 	return c.Do(req, result)
 }
 
+// GetWithParams sends a GET request to urlpath with URL encoded query params
+// and decodes the response into result. Params are appended to any query
+// already present in urlpath.
+func (c *Client) GetWithParams(ctx context.Context, urlpath string, params url.Values, result interface{}) error {
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(urlpath, "?") {
+			sep = "&"
+		}
+		urlpath += sep + params.Encode()
+	}
+	return c.Get(ctx, urlpath, result)
+}
+
 func (c *Client) GetAsync(ctx context.Context, urlpath string, mon Monitor) error {
 	req, err := c.NewRequest(ctx, http.MethodGet, urlpath, nil)
 	if err != nil {
@@ -255,7 +305,9 @@ func (c *Client) handleResponseMonitor(ctx context.Context, resp *http.Response,
 
 // Do retrieves values from the API and marshals them into the provided interface.
 func (c *Client) Do(req *http.Request, v interface{}) error {
-	resp, err := c.client.Do(req)
+	ctx, cancel := c.CallContext(req.Context())
+	defer cancel()
+	resp, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
 		if e, ok := err.(*url.Error); ok {
 			return e.Err
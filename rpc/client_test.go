@@ -0,0 +1,84 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestClientCallTimeout(t *testing.T) {
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/chain_id": func(r *http.Request) any {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(100 * time.Millisecond):
+			}
+			return `"NetXdQprcVkpaWU"`
+		},
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WithDefaultCallTimeout(10 * time.Millisecond)
+
+	// default timeout applies to each request
+	if _, err := c.GetChainId(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// per call timeout overrides the client default
+	ctx := WithCallTimeout(context.Background(), 5*time.Second)
+	if _, err := c.GetChainId(ctx); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// zero disables timeouts
+	ctx, cancel := c.CallContext(WithCallTimeout(context.Background(), 0))
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("expected context without deadline")
+	}
+}
+
+func TestClientGetWithParams(t *testing.T) {
+	var query string
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/helpers/baking_rights": func(r *http.Request) any {
+			query = r.URL.RawQuery
+			return `[]`
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	b := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	q := NewQuery().WithDelegate(a).Add("delegate", b.String()).WithCycle(5)
+
+	for _, v := range []struct {
+		path string
+		want string
+	}{
+		{"chains/main/blocks/head/helpers/baking_rights", "cycle=5&delegate=" + a.String() + "&delegate=" + b.String()},
+		{"chains/main/blocks/head/helpers/baking_rights?all", "all&cycle=5&delegate=" + a.String() + "&delegate=" + b.String()},
+	} {
+		var res []any
+		if err := c.GetWithParams(context.Background(), v.path, q.Values(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if query != v.want {
+			t.Errorf("%s: want query %q, got %q", v.path, v.want, query)
+		}
+	}
+}
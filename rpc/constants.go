@@ -83,6 +83,64 @@ func (c *Client) GetParams(ctx context.Context, id BlockID) (*tezos.Params, erro
 	return p, nil
 }
 
+// GetParamsRange returns params for each protocol active between block levels
+// from and to (inclusive). The first entry is valid at level from, each following
+// entry starts at a protocol migration. Migrations are taken from known network
+// deployments or detected by searching for protocol changes in block headers.
+func (c *Client) GetParamsRange(ctx context.Context, from, to int64) ([]*tezos.Params, error) {
+	if from > to {
+		return nil, fmt.Errorf("rpc: invalid params range [%d,%d]", from, to)
+	}
+	res := make([]*tezos.Params, 0)
+	for level := from; level <= to; {
+		p, err := c.GetParams(ctx, BlockLevel(level))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+		level, err = c.nextProtocolStart(ctx, p, level, to)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// nextProtocolStart returns the first level after level where a protocol other
+// than p.Protocol is active or a value > to when p lasts until to.
+func (c *Client) nextProtocolStart(ctx context.Context, p *tezos.Params, level, to int64) (int64, error) {
+	// use known deployment
+	if p.StartHeight <= level && p.EndHeight >= level {
+		return p.EndHeight + 1, nil
+	}
+
+	// binary search for the first block with a different protocol
+	isSame := func(l int64) (bool, error) {
+		head, err := c.GetBlockHeader(ctx, BlockLevel(l))
+		if err != nil {
+			return false, err
+		}
+		return head.Protocol.Equal(p.Protocol), nil
+	}
+	if same, err := isSame(to); err != nil || same {
+		return to + 1, err
+	}
+	lo, hi := level, to
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		same, err := isSame(mid)
+		if err != nil {
+			return 0, err
+		}
+		if same {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
 func (c Constants) MapToChainParams() *tezos.Params {
 	p := &tezos.Params{
 		BlocksPerCycle:               c.BlocksPerCycle,
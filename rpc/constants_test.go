@@ -0,0 +1,91 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestGetParamsRange(t *testing.T) {
+	// fake chain with migrations after levels 9 and 24
+	proto := func(level int64) tezos.ProtocolHash {
+		switch {
+		case level < 10:
+			return tezos.PtMumbai
+		case level < 25:
+			return tezos.PtNairobi
+		default:
+			return tezos.ProtoV018
+		}
+	}
+	chain := tezos.NewChainIdHash([]byte{1, 2, 3, 4})
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/chain_id": chain,
+		"/version":              map[string]any{"network_version": map[string]any{"chain_name": "TEST"}},
+		"/chains/main/blocks/*": func(r *http.Request) any {
+			path := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+			level, err := strconv.ParseInt(path[3], 10, 64)
+			if err != nil || len(path) < 5 {
+				return nil
+			}
+			switch strings.Join(path[4:], "/") {
+			case "header":
+				return map[string]any{"level": level, "protocol": proto(level)}
+			case "metadata":
+				return map[string]any{
+					"protocol":   proto(level),
+					"level_info": map[string]any{"level": level},
+				}
+			case "context/constants":
+				return map[string]any{"cost_per_byte": strconv.Itoa(int(level))}
+			default:
+				return nil
+			}
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := c.GetParamsRange(context.Background(), 3, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %d", len(params))
+	}
+	for i, want := range []struct {
+		proto tezos.ProtocolHash
+		start int64
+	}{
+		{tezos.PtMumbai, 3},
+		{tezos.PtNairobi, 10},
+		{tezos.ProtoV018, 25},
+	} {
+		p := params[i]
+		if !p.Protocol.Equal(want.proto) || p.CostPerByte != want.start {
+			t.Errorf("params %d: have %s/%d want %s/%d", i, p.Protocol, p.CostPerByte, want.proto, want.start)
+		}
+	}
+
+	// single protocol range
+	params, err = c.GetParamsRange(context.Background(), 11, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 || !params[0].Protocol.Equal(tezos.PtNairobi) {
+		t.Errorf("unexpected params for single protocol range: %d", len(params))
+	}
+	if _, err := c.GetParamsRange(context.Background(), 20, 10); err == nil {
+		t.Errorf("expected error for invalid range")
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"sync"
 
 	"blockwatch.cc/tzgo/micheline"
 	"blockwatch.cc/tzgo/tezos"
@@ -134,6 +136,52 @@ func (c *Client) GetContractExt(ctx context.Context, addr tezos.Address, id Bloc
 	return &info, nil
 }
 
+// Account aggregates account state commonly required to build and
+// send operations.
+type Account struct {
+	Address  tezos.Address `json:"address"`
+	Balance  int64         `json:"balance"`
+	Counter  int64         `json:"counter"`
+	Revealed bool          `json:"revealed"`
+	Delegate tezos.Address `json:"delegate"`
+	Manager  tezos.Key     `json:"manager"`
+}
+
+// GetAccount returns balance, counter, delegate and reveal status of an
+// account at block id. Contract state and manager key are fetched concurrently.
+// Smart contracts have no manager key and are never revealed.
+func (c *Client) GetAccount(ctx context.Context, addr tezos.Address, id BlockID) (*Account, error) {
+	var (
+		wg     sync.WaitGroup
+		key    tezos.Key
+		kerr   error
+		hasKey = addr.IsEOA()
+	)
+	if hasKey {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, kerr = c.GetManagerKey(ctx, addr, id)
+		}()
+	}
+	info, err := c.GetContract(ctx, addr, id)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if kerr != nil {
+		return nil, kerr
+	}
+	return &Account{
+		Address:  addr,
+		Balance:  info.Balance,
+		Counter:  info.Counter,
+		Revealed: key.IsValid(),
+		Delegate: info.Delegate,
+		Manager:  key,
+	}, nil
+}
+
 // ListContracts returns a list of all known contracts at head. This call may be very SLOW for
 // large chains and there is no means to limit the result. Use with caution and consider
 // calling an indexer API instead.
@@ -188,6 +236,73 @@ func (c *Client) GetContractStorage(ctx context.Context, addr tezos.Address, id
 	return prim, nil
 }
 
+// GetContractStorageValue returns the contract's storage at block id as typed
+// value. Only storage is fetched from block id, the storage type is read from
+// the contract's current script and cached per protocol. Protocol migrations
+// may rewrite contract scripts, so storage at blocks of earlier protocols may
+// not match the current type.
+func (c *Client) GetContractStorageValue(ctx context.Context, addr tezos.Address, id BlockID) (micheline.Value, error) {
+	typ, err := c.getStorageType(ctx, addr)
+	if err != nil {
+		return micheline.Value{}, err
+	}
+	prim, err := c.GetContractStorage(ctx, addr, id)
+	if err != nil {
+		return micheline.Value{}, err
+	}
+	return micheline.NewValue(typ, prim), nil
+}
+
+func (c *Client) getStorageType(ctx context.Context, addr tezos.Address) (micheline.Type, error) {
+	var proto tezos.ProtocolHash
+	if c.Params != nil {
+		proto = c.Params.Protocol
+	}
+	key := proto.String() + addr.String()
+	if typ, ok := c.storageTypes.Get(key); ok {
+		return typ, nil
+	}
+	script, err := c.GetContractScript(ctx, addr)
+	if err != nil {
+		return micheline.Type{}, err
+	}
+	typ := script.StorageType()
+	c.storageTypes.Add(key, typ)
+	return typ, nil
+}
+
+// maxStorageTypes limits the number of cached contract storage types.
+const maxStorageTypes = 1024
+
+// storageTypeCache is a size limited cache of contract storage types. When
+// full an arbitrary entry is evicted.
+type storageTypeCache struct {
+	sync.Mutex
+	types map[string]micheline.Type
+}
+
+func (c *storageTypeCache) Get(key string) (micheline.Type, bool) {
+	c.Lock()
+	defer c.Unlock()
+	typ, ok := c.types[key]
+	return typ, ok
+}
+
+func (c *storageTypeCache) Add(key string, typ micheline.Type) {
+	c.Lock()
+	defer c.Unlock()
+	if c.types == nil {
+		c.types = make(map[string]micheline.Type)
+	}
+	if _, ok := c.types[key]; !ok && len(c.types) >= maxStorageTypes {
+		for k := range c.types {
+			delete(c.types, k)
+			break
+		}
+	}
+	c.types[key] = typ
+}
+
 // GetContractStorageNormalized returns contract's storage at block id using unparsing mode.
 func (c *Client) GetContractStorageNormalized(ctx context.Context, addr tezos.Address, id BlockID, mode UnparsingMode) (micheline.Prim, error) {
 	u := fmt.Sprintf("chains/main/blocks/%s/context/contracts/%s/storage/normalized", id, addr)
@@ -207,9 +322,11 @@ func (c *Client) GetContractStorageNormalized(ctx context.Context, addr tezos.Ad
 	return prim, nil
 }
 
-// GetContractEntrypoints returns the contract's entrypoints.
-func (c *Client) GetContractEntrypoints(ctx context.Context, addr tezos.Address) (map[string]micheline.Type, error) {
-	u := fmt.Sprintf("chains/main/blocks/head/context/contracts/%s/entrypoints", addr)
+// GetContractEntrypoints returns the contract's entrypoints with types as
+// resolved by the node at block id. This is cheaper than loading and parsing
+// the full script when only entrypoint types are needed.
+func (c *Client) GetContractEntrypoints(ctx context.Context, addr tezos.Address, id BlockID) (map[string]micheline.Type, error) {
+	u := fmt.Sprintf("chains/main/blocks/%s/context/contracts/%s/entrypoints", id, addr)
 	type eptype struct {
 		Entrypoints map[string]micheline.Type `json:"entrypoints"`
 	}
@@ -221,6 +338,26 @@ func (c *Client) GetContractEntrypoints(ctx context.Context, addr tezos.Address)
 	return eps.Entrypoints, nil
 }
 
+// ListContractBigmaps returns the sorted ids of all bigmaps owned by contract addr
+// at block id. Octez does not index bigmaps by owner, so ids are collected from
+// the contract's storage at block id as seen by the node. Bigmaps that were
+// removed from storage are garbage collected by the protocol and no longer exist.
+// Like micheline.DetectBigmaps this misses bigmaps declared in an or/option
+// branch of the storage type that is not set in the current storage value.
+func (c *Client) ListContractBigmaps(ctx context.Context, addr tezos.Address, id BlockID) ([]int64, error) {
+	u := fmt.Sprintf("chains/main/blocks/%s/context/contracts/%s/script", id, addr)
+	s := micheline.NewScript()
+	if err := c.Get(ctx, u, s); err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0)
+	for _, v := range s.Bigmaps() {
+		ids = append(ids, v)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
 // ListBigmapKeys returns all keys in the bigmap at block id. This call may be very SLOW for
 // large bigmaps and there is no means to limit the result. Use of this method is discouraged.
 // Instead, call the ListBigmapValuesExt method below. In case you require the pre-image of
@@ -235,6 +372,28 @@ func (c *Client) ListBigmapKeys(ctx context.Context, bigmap int64, id BlockID) (
 	return hashes, nil
 }
 
+// ListBigmapKeysExt returns at most limit keys starting at offset from bigmap at
+// block id. Keys are returned in the node's context order which is stable for a
+// given block. The node's raw context endpoint does not support paging, so the
+// full key listing is fetched and paged locally.
+func (c *Client) ListBigmapKeysExt(ctx context.Context, bigmap int64, id BlockID, offset, limit int) ([]tezos.ExprHash, error) {
+	hashes, err := c.ListBigmapKeys(ctx, bigmap, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(hashes) {
+		return hashes[:0], nil
+	}
+	hashes = hashes[offset:]
+	if limit > 0 && len(hashes) > limit {
+		hashes = hashes[:limit]
+	}
+	return hashes, nil
+}
+
 // ListActiveBigmapKeys returns all keys in the bigmap at block id. This call may be very SLOW for
 // large bigmaps and there is no means to limit the result. Use of this method is discouraged.
 // Instead, call the ListActiveBigmapValuesExt method below. In case you require the pre-image of
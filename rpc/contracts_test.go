@@ -0,0 +1,161 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestGetAccount(t *testing.T) {
+	addr := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	key := "edpkuSLWfVU1Vq7Jg9FucPyKmma6otcMHac9zG4oU1KMHSTBpJuGQ2"
+	var revealed bool
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/contracts/" + addr.String(): `{"balance":"1000","delegate":"` + addr.String() + `","counter":"42"}`,
+		"/chains/main/blocks/head/context/contracts/" + addr.String() + "/manager_key": func(*http.Request) any {
+			if revealed {
+				return `"` + key + `"`
+			}
+			return `null`
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc, err := c.GetAccount(context.Background(), addr, Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance != 1000 || acc.Counter != 42 || !acc.Delegate.Equal(addr) {
+		t.Errorf("unexpected account %#v", acc)
+	}
+	if acc.Revealed || acc.Manager.IsValid() {
+		t.Errorf("expected unrevealed account")
+	}
+
+	revealed = true
+	acc, err = c.GetAccount(context.Background(), addr, Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acc.Revealed || acc.Manager.String() != key {
+		t.Errorf("expected revealed key %s, got %s", key, acc.Manager)
+	}
+}
+
+func TestGetContractStorageValue(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+	var scriptCalls int
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/contracts/" + addr.String() + "/script": func(*http.Request) any {
+			scriptCalls++
+			return `{"code":[{"prim":"parameter","args":[{"prim":"unit"}]},{"prim":"storage","args":[{"prim":"pair","args":[{"prim":"nat","annots":["%counter"]},{"prim":"string","annots":["%name"]}]}]},{"prim":"code","args":[[]]}],"storage":{"prim":"Pair","args":[{"int":"1"},{"string":"head"}]}}`
+		},
+		"/chains/main/blocks/10/context/contracts/" + addr.String() + "/storage": `{"prim":"Pair","args":[{"int":"10"},{"string":"ten"}]}`,
+		"/chains/main/blocks/11/context/contracts/" + addr.String() + "/storage": `{"prim":"Pair","args":[{"int":"11"},{"string":"eleven"}]}`,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []struct {
+		level   int64
+		counter int64
+		name    string
+	}{
+		{10, 10, "ten"},
+		{11, 11, "eleven"},
+	} {
+		val, err := c.GetContractStorageValue(context.Background(), addr, BlockLevel(want.level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, ok := val.GetInt64("counter"); !ok || n != want.counter {
+			t.Errorf("level %d: counter have %d want %d", want.level, n, want.counter)
+		}
+		if s, ok := val.GetString("name"); !ok || s != want.name {
+			t.Errorf("level %d: name have %q want %q", want.level, s, want.name)
+		}
+	}
+	if scriptCalls != 1 {
+		t.Errorf("expected storage type to be cached, got %d script calls", scriptCalls)
+	}
+
+	// a protocol migration may rewrite the script
+	c.Params = tezos.NewParams().WithProtocol(tezos.ProtoAlpha)
+	if _, err := c.GetContractStorageValue(context.Background(), addr, BlockLevel(11)); err != nil {
+		t.Fatal(err)
+	}
+	if scriptCalls != 2 {
+		t.Errorf("expected storage type reload after protocol change, got %d script calls", scriptCalls)
+	}
+
+	// the cache is bounded
+	var cache storageTypeCache
+	for i := 0; i < maxStorageTypes+10; i++ {
+		cache.Add(strconv.Itoa(i), micheline.Type{})
+	}
+	if n := len(cache.types); n != maxStorageTypes {
+		t.Errorf("expected %d cached types, got %d", maxStorageTypes, n)
+	}
+}
+
+func TestListContractBigmaps(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+	script := `{"code":[{"prim":"parameter","args":[{"prim":"unit"}]},` +
+		`{"prim":"storage","args":[{"prim":"pair","args":[` +
+		`{"prim":"big_map","args":[{"prim":"nat"},{"prim":"bytes"}],"annots":["%metadata"]},` +
+		`{"prim":"big_map","args":[{"prim":"address"},{"prim":"nat"}],"annots":["%ledger"]}]}]},` +
+		`{"prim":"code","args":[[{"prim":"CDR"},{"prim":"NIL","args":[{"prim":"operation"}]},{"prim":"PAIR"}]]}],` +
+		`"storage":{"prim":"Pair","args":[{"int":"42"},{"int":"7"}]}}`
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/123/context/contracts/" + addr.String() + "/script": script,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := c.ListContractBigmaps(context.Background(), addr, BlockLevel(123))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 7 || ids[1] != 42 {
+		t.Errorf("unexpected bigmap ids %v", ids)
+	}
+}
+
+func TestGetContractEntrypoints(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T")
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/100/context/contracts/" + addr.String() + "/entrypoints": `{"entrypoints":{"mint":{"prim":"nat"},"burn":{"prim":"pair","args":[{"prim":"address"},{"prim":"nat"}]}}}`,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eps, err := c.GetContractEntrypoints(context.Background(), addr, BlockLevel(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 2 {
+		t.Fatalf("expected 2 entrypoints, got %d", len(eps))
+	}
+	if typ, ok := eps["mint"]; !ok || typ.OpCode != micheline.T_NAT {
+		t.Errorf("unexpected mint type %s", typ.Dump())
+	}
+	if typ, ok := eps["burn"]; !ok || typ.OpCode != micheline.T_PAIR || len(typ.Args) != 2 {
+		t.Errorf("unexpected burn type %s", typ.Dump())
+	}
+}
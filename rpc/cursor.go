@@ -0,0 +1,97 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"sync"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// DefaultSeenCursorSize is the default number of operation hashes a
+// SeenCursor remembers.
+var DefaultSeenCursorSize = 1 << 16
+
+// SeenCursor remembers hashes of already delivered operations to filter
+// duplicates from a mempool stream. When full, the oldest hashes are
+// forgotten first. SeenCursor is safe for concurrent use.
+type SeenCursor struct {
+	mu    sync.Mutex
+	seen  map[tezos.OpHash]struct{}
+	order []tezos.OpHash
+	next  int
+	size  int
+}
+
+// NewSeenCursor returns a cursor that remembers up to size operation hashes.
+// Zero or negative sizes use DefaultSeenCursorSize.
+func NewSeenCursor(size int) *SeenCursor {
+	if size <= 0 {
+		size = DefaultSeenCursorSize
+	}
+	return &SeenCursor{
+		seen:  make(map[tezos.OpHash]struct{}),
+		order: make([]tezos.OpHash, 0),
+		size:  size,
+	}
+}
+
+// Seen returns true when hash h was already added.
+func (c *SeenCursor) Seen(h tezos.OpHash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.seen[h]
+	return ok
+}
+
+// Add adds hash h and returns true when it was not seen before.
+func (c *SeenCursor) Add(h tezos.OpHash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.add(h)
+}
+
+func (c *SeenCursor) add(h tezos.OpHash) bool {
+	if _, ok := c.seen[h]; ok {
+		return false
+	}
+	if len(c.order) < c.size {
+		c.order = append(c.order, h)
+	} else {
+		delete(c.seen, c.order[c.next])
+		c.order[c.next] = h
+		c.next = (c.next + 1) % c.size
+	}
+	c.seen[h] = struct{}{}
+	return true
+}
+
+// Filter adds hashes of all ops and returns ops that were not seen before.
+func (c *SeenCursor) Filter(ops []*Operation) []*Operation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]*Operation, 0, len(ops))
+	for _, op := range ops {
+		if c.add(op.Hash) {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// Len returns the number of remembered hashes.
+func (c *SeenCursor) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.order)
+}
+
+// Reset forgets all remembered hashes.
+func (c *SeenCursor) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen = make(map[tezos.OpHash]struct{})
+	c.order = c.order[:0]
+	c.next = 0
+}
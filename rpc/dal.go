@@ -13,19 +13,45 @@ var (
 	_ TypedOperation = (*DalAttestation)(nil)
 )
 
+// DalPublishSlotHeader represents a dal_publish_slot_header operation
 type DalPublishSlotHeader struct {
 	Manager
 	SlotHeader struct {
-		Level      int64          `json:"level"`
-		Index      byte           `json:"index"`
+		Level      int64          `json:"level"`      // until Nairobi
+		Index      byte           `json:"index"`      // until Nairobi
+		SlotIndex  byte           `json:"slot_index"` // since Oxford
 		Commitment string         `json:"commitment"`
 		Proof      tezos.HexBytes `json:"commitment_proof"`
 	} `json:"slot_header"`
 }
 
+// SlotIndex returns the index of the published DAL slot.
+func (d DalPublishSlotHeader) SlotIndex() int {
+	if d.SlotHeader.SlotIndex > 0 {
+		return int(d.SlotHeader.SlotIndex)
+	}
+	return int(d.SlotHeader.Index)
+}
+
+// Commitment returns the base58 encoded slot commitment.
+func (d DalPublishSlotHeader) Commitment() string {
+	return d.SlotHeader.Commitment
+}
+
+// CommitmentProof returns the slot commitment proof.
+func (d DalPublishSlotHeader) CommitmentProof() tezos.HexBytes {
+	return d.SlotHeader.Proof
+}
+
+// DalAttestation represents a dal_attestation operation
 type DalAttestation struct {
 	Generic
 	Attestor    tezos.Address `json:"attestor"`
 	Attestation tezos.Z       `json:"attestation"`
 	Level       int64         `json:"level"`
 }
+
+// GetSource returns the attesting delegate.
+func (d DalAttestation) GetSource() tezos.Address {
+	return d.Attestor
+}
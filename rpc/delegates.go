@@ -5,8 +5,11 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"blockwatch.cc/tzgo/tezos"
 )
@@ -87,6 +90,37 @@ func (c *Client) GetDelegate(ctx context.Context, addr tezos.Address, id BlockID
 	return delegate, nil
 }
 
+// IsRegisteredBaker returns true when addr is registered as baker at head,
+// i.e. it has delegated to itself and is not deactivated.
+//
+// Octez answers delegate RPCs for unregistered accounts with status 500 and
+// error id `proto.<protocol>.delegate.not_registered`, older nodes respond
+// with 404. Both are reported as not registered without error.
+func (c *Client) IsRegisteredBaker(ctx context.Context, addr tezos.Address) (bool, error) {
+	u := fmt.Sprintf("chains/main/blocks/%s/context/delegates/%s/deactivated", Head, addr)
+	var deactivated bool
+	if err := c.Get(ctx, u, &deactivated); err != nil {
+		if isNotRegistered(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !deactivated, nil
+}
+
+func isNotRegistered(err error) bool {
+	if ErrorStatus(err) == http.StatusNotFound {
+		return true
+	}
+	var e Error
+	if errors.As(err, &e) {
+		id := e.ErrorID()
+		return strings.HasSuffix(id, ".delegate.not_registered") ||
+			strings.HasSuffix(id, ".contract.manager.unregistered_delegate")
+	}
+	return false
+}
+
 // GetDelegateBalance returns a delegate's balance
 func (c *Client) GetDelegateBalance(ctx context.Context, addr tezos.Address, id BlockID) (int64, error) {
 	u := fmt.Sprintf("chains/main/blocks/%s/context/delegates/%s/balance", id, addr)
@@ -110,3 +144,20 @@ func (c *Client) GetDelegateKey(ctx context.Context, addr tezos.Address, id Bloc
 	err := c.Get(ctx, u, &key)
 	return key.Active.Pk, err
 }
+
+// StakerInfo is an external staker of a delegate and its frozen stake.
+type StakerInfo struct {
+	Staker tezos.Address `json:"staker"`
+	Amount int64         `json:"frozen_deposits,string"`
+}
+
+// GetStakers returns all external stakers of a delegate together with their
+// staked amount. Requires a protocol with adaptive issuance.
+func (c *Client) GetStakers(ctx context.Context, addr tezos.Address, id BlockID) ([]StakerInfo, error) {
+	u := fmt.Sprintf("chains/main/blocks/%s/context/delegates/%s/stakers", id, addr)
+	stakers := make([]StakerInfo, 0)
+	if err := c.Get(ctx, u, &stakers); err != nil {
+		return nil, err
+	}
+	return stakers, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestIsRegisteredBaker(t *testing.T) {
+	var (
+		active      = tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+		deactivated = tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+		unknown     = tezos.MustParseAddress("tz1gfArv665EUkSg2ojMBzcbfwuPxAvqPvjo")
+		legacy      = tezos.MustParseAddress("tz1burnburnburnburnburnburnburjAYjjX")
+		broken      = tezos.MustParseAddress("tz1Ke2h7sDdakHJQh8WX4Z372du1KChsksyU")
+	)
+	path := func(a tezos.Address) string {
+		return "/chains/main/blocks/head/context/delegates/" + a.String() + "/deactivated"
+	}
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		path(active):      `false`,
+		path(deactivated): `true`,
+		path(unknown): nodetest.Response{
+			Status: http.StatusInternalServerError,
+			Body:   `[{"kind":"temporary","id":"proto.019-PtParisB.delegate.not_registered","pkh":"` + unknown.String() + `"}]`,
+		},
+		path(broken): nodetest.Response{
+			Status: http.StatusInternalServerError,
+			Body:   `[{"kind":"temporary","id":"failure","msg":"storage error"}]`,
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		addr tezos.Address
+		want bool
+	}{
+		{active, true},
+		{deactivated, false},
+		{unknown, false},
+		{legacy, false},
+	} {
+		ok, err := c.IsRegisteredBaker(context.Background(), v.addr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", v.addr, err)
+			continue
+		}
+		if ok != v.want {
+			t.Errorf("%s: want %t, got %t", v.addr, v.want, ok)
+		}
+	}
+	if _, err := c.IsRegisteredBaker(context.Background(), broken); err == nil {
+		t.Errorf("expected error for unrelated node failure")
+	}
+}
+
+func TestGetStakers(t *testing.T) {
+	var (
+		baker  = tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+		staker = tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+		empty  = tezos.MustParseAddress("tz1gfArv665EUkSg2ojMBzcbfwuPxAvqPvjo")
+	)
+	path := func(a tezos.Address) string {
+		return "/chains/main/blocks/head/context/delegates/" + a.String() + "/stakers"
+	}
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		path(baker): `[{"staker":"` + baker.String() + `","frozen_deposits":"6000000000"},{"staker":"` + staker.String() + `","frozen_deposits":"1500000"}]`,
+		path(empty): `[]`,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := c.GetStakers(context.Background(), baker, Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("want 2 stakers, got %d", len(list))
+	}
+	for i, want := range []StakerInfo{
+		{Staker: baker, Amount: 6000000000},
+		{Staker: staker, Amount: 1500000},
+	} {
+		if !list[i].Staker.Equal(want.Staker) || list[i].Amount != want.Amount {
+			t.Errorf("staker %d: want %s/%d, got %s/%d", i, want.Staker, want.Amount, list[i].Staker, list[i].Amount)
+		}
+	}
+
+	list, err = c.GetStakers(context.Background(), empty, Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list == nil || len(list) != 0 {
+		t.Errorf("want empty non-nil list, got %v", list)
+	}
+
+	if _, err := c.GetStakers(context.Background(), staker, Head); err == nil {
+		t.Errorf("expected error for unknown delegate")
+	}
+}
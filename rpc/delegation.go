@@ -20,6 +20,6 @@ type Delegation struct {
 func (d Delegation) Costs() tezos.Costs {
 	return tezos.Costs{
 		Fee:     d.Manager.Fee,
-		GasUsed: d.Metadata.Result.ConsumedGas,
+		GasUsed: d.Metadata.Result.Gas(),
 	}
 }
@@ -4,6 +4,8 @@
 package rpc
 
 import (
+	"fmt"
+
 	"blockwatch.cc/tzgo/tezos"
 )
 
@@ -17,10 +19,60 @@ type DoubleBaking struct {
 	BH2 BlockHeader `json:"bh2"`
 }
 
+// DoubleBakingEvidence is a double_baking_evidence receipt. It is an alias
+// for DoubleBaking.
+type DoubleBakingEvidence = DoubleBaking
+
+// Level returns the level of the double baked block.
+func (d DoubleBaking) Level() int64 {
+	return d.BH1.Level
+}
+
+// Rounds returns the rounds (or priorities before v012) of both headers.
+func (d DoubleBaking) Rounds() (int, int) {
+	return d.BH1.Round(), d.BH2.Round()
+}
+
+// Offender returns the slashed baker. Since v018 the baker is reported as
+// forbidden delegate, earlier protocols debit the baker's deposits.
+func (d DoubleBaking) Offender() tezos.Address {
+	if d.Metadata.ForbiddenDelegate.IsValid() {
+		return d.Metadata.ForbiddenDelegate
+	}
+	for _, v := range d.Metadata.BalanceUpdates.nonSimulated() {
+		if v.Amount() < 0 {
+			return v.Address()
+		}
+	}
+	return tezos.InvalidAddress
+}
+
+// Validate checks that both headers conflict, i.e. they are for the same
+// level and round but contain a different payload. Emmy headers have no
+// payload hash and must have different signatures.
+func (d DoubleBaking) Validate() error {
+	if d.BH1.Level != d.BH2.Level {
+		return fmt.Errorf("rpc: double baking level mismatch %d != %d", d.BH1.Level, d.BH2.Level)
+	}
+	if r1, r2 := d.Rounds(); r1 != r2 {
+		return fmt.Errorf("rpc: double baking round mismatch %d != %d", r1, r2)
+	}
+	if d.BH1.PayloadHash.IsValid() || d.BH2.PayloadHash.IsValid() {
+		if d.BH1.PayloadHash.Equal(d.BH2.PayloadHash) {
+			return fmt.Errorf("rpc: double baking headers have the same payload %s", d.BH1.PayloadHash)
+		}
+		return nil
+	}
+	if d.BH1.Signature.Equal(d.BH2.Signature) {
+		return fmt.Errorf("rpc: double baking headers are identical")
+	}
+	return nil
+}
+
 // Costs returns operation cost to implement TypedOperation interface.
 func (d DoubleBaking) Costs() tezos.Costs {
 	var burn int64
-	upd := d.Metadata.BalanceUpdates
+	upd := d.Metadata.BalanceUpdates.nonSimulated()
 	// last item is accuser reward, rest is burned
 	for i, v := range upd {
 		if i == len(upd)-1 {
@@ -20,7 +20,7 @@ type DoubleEndorsement struct {
 // Costs returns operation cost to implement TypedOperation interface.
 func (d DoubleEndorsement) Costs() tezos.Costs {
 	var burn int64
-	upd := d.Metadata.BalanceUpdates
+	upd := d.Metadata.BalanceUpdates.nonSimulated()
 	// last item is accuser reward, rest is burned
 	for i, v := range upd {
 		if i == len(upd)-1 {
@@ -10,10 +10,66 @@ import (
 // Ensure DrainDelegate implements the TypedOperation interface.
 var _ TypedOperation = (*DrainDelegate)(nil)
 
-// DrainDelegate represents a transaction operation
+// DrainDelegate represents a drain_delegate operation
 type DrainDelegate struct {
 	Generic
 	ConsensusKey tezos.Address `json:"consensus_key"`
 	Delegate     tezos.Address `json:"delegate"`
 	Destination  tezos.Address `json:"destination"`
 }
+
+// GetSource returns the drained delegate.
+func (d DrainDelegate) GetSource() tezos.Address {
+	return d.Delegate
+}
+
+// Amount returns the amount drained from the delegate into destination.
+func (d DrainDelegate) Amount() int64 {
+	var amount int64
+	for _, v := range d.Metadata.BalanceUpdates {
+		if v.Kind == CONTRACT && v.Change > 0 && v.Address().Equal(d.Destination) {
+			amount += v.Change
+		}
+	}
+	return amount
+}
+
+// Fee returns the drain fee paid by the delegate to the block producer.
+func (d DrainDelegate) Fee() int64 {
+	var fee int64
+	for _, v := range d.Metadata.BalanceUpdates {
+		if v.Kind != CONTRACT || v.Change <= 0 {
+			continue
+		}
+		if addr := v.Address(); !addr.Equal(d.Destination) && !addr.Equal(d.Delegate) {
+			fee += v.Change
+		}
+	}
+	return fee
+}
+
+// GetFee returns the drain fee. Implements TypedOperation interface.
+func (d DrainDelegate) GetFee() int64 {
+	return d.Fee()
+}
+
+// Costs returns operation cost to implement TypedOperation interface.
+func (d DrainDelegate) Costs() tezos.Costs {
+	cost := tezos.Costs{
+		Fee: d.Fee(),
+	}
+	if d.Metadata.AllocatedDestination {
+		// allocation is burned from the drained delegate balance
+		var debit int64
+		for _, v := range d.Metadata.BalanceUpdates {
+			if v.Kind == CONTRACT && v.Change < 0 {
+				debit -= v.Change
+			}
+		}
+		if burn := debit - d.Amount() - cost.Fee; burn > 0 {
+			cost.AllocationBurn = burn
+			cost.Burn = burn
+		}
+	}
+	return cost
+}
@@ -20,6 +20,28 @@ type Endorsement struct {
 	PayloadHash tezos.PayloadHash   `json:"block_payload_hash"`    // v012+
 }
 
+// Preendorsement is a Tenderbake preendorsement receipt. Preendorsements share
+// their structure with endorsements and are decoded into the same type.
+type Preendorsement = Endorsement
+
+// IsPreendorsement returns true for preendorsement receipts.
+func (e Endorsement) IsPreendorsement() bool {
+	return e.OpKind == tezos.OpTypePreendorsement
+}
+
+// Power returns the endorsement or preendorsement power of the operation.
+func (e Endorsement) Power() int {
+	if e.IsPreendorsement() {
+		return e.Metadata.PreendorsementPower
+	}
+	return e.Metadata.EndorsementPower
+}
+
+// GetSource returns the endorsing delegate.
+func (e Endorsement) GetSource() tezos.Address {
+	return e.Metadata.Delegate
+}
+
 func (e Endorsement) GetLevel() int64 {
 	if e.Endorsement != nil {
 		return e.Endorsement.Operations.Level
@@ -0,0 +1,147 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// FeatureFlags is a set of protocol features that are active at a block.
+type FeatureFlags uint16
+
+const (
+	FeatureLiquidityBaking FeatureFlags = 1 << iota
+	FeatureTenderbake
+	FeatureConsensusKeys
+	FeatureSmartRollups
+	FeatureAdaptiveIssuance
+	FeatureDal
+)
+
+const (
+	// liquidity baking is disabled when the vote EMA reaches these thresholds
+	lbEscapeEmaThresholdV10 = 1_000_000
+	lbEscapeEmaThresholdV12 = 666_667
+	lbToggleEmaThreshold    = 1_000_000_000
+)
+
+func (f FeatureFlags) Contains(x FeatureFlags) bool {
+	return f&x > 0
+}
+
+func (f FeatureFlags) String() string {
+	return strings.Join(f.Array(), ",")
+}
+
+func (f FeatureFlags) Array() []string {
+	s := make([]string, 0)
+	var i FeatureFlags = 1
+	for f > 0 {
+		switch f & i {
+		case FeatureLiquidityBaking:
+			s = append(s, "liquidity_baking")
+		case FeatureTenderbake:
+			s = append(s, "tenderbake")
+		case FeatureConsensusKeys:
+			s = append(s, "consensus_keys")
+		case FeatureSmartRollups:
+			s = append(s, "smart_rollups")
+		case FeatureAdaptiveIssuance:
+			s = append(s, "adaptive_issuance")
+		case FeatureDal:
+			s = append(s, "dal")
+		}
+		f &= ^i
+		i <<= 1
+	}
+	return s
+}
+
+func (f FeatureFlags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Array())
+}
+
+// ActiveFeatures returns protocol features that are active at this block.
+// Features are derived from the block's protocol and from vote and activation
+// state in block metadata. DAL is reported when the block carries a DAL
+// attestation or DAL operations since the DAL feature flag itself is only
+// available from constants.
+func (b Block) ActiveFeatures() FeatureFlags {
+	var f FeatureFlags
+	proto := b.Protocol
+	if !proto.IsValid() {
+		proto = b.Metadata.Protocol
+	}
+	v := protocolVersion(proto)
+
+	// liquidity baking (v010+) can be switched off by baker votes
+	switch {
+	case v >= 13:
+		if b.Metadata.LiquidityBakingToggleEma < lbToggleEmaThreshold {
+			f |= FeatureLiquidityBaking
+		}
+	case v >= 12:
+		if b.Metadata.LiquidityBakingEscapeEma < lbEscapeEmaThresholdV12 {
+			f |= FeatureLiquidityBaking
+		}
+	case v >= 10:
+		if b.Metadata.LiquidityBakingEscapeEma < lbEscapeEmaThresholdV10 {
+			f |= FeatureLiquidityBaking
+		}
+	}
+	if v >= 12 {
+		f |= FeatureTenderbake
+	}
+	if v >= 15 {
+		f |= FeatureConsensusKeys
+	}
+	if v >= 16 {
+		f |= FeatureSmartRollups
+	}
+
+	// adaptive issuance (v018+) activates in a cycle after a successful vote
+	if v >= 18 {
+		if c := b.Metadata.AdaptiveIssuanceActivationCycle; c != nil && *c <= b.GetCycle() {
+			f |= FeatureAdaptiveIssuance
+		}
+	}
+
+	// dal (v019+) may be disabled by constants
+	if v >= 19 && (b.Metadata.DalAttestation != nil || b.hasDalOps()) {
+		f |= FeatureDal
+	}
+	return f
+}
+
+func (b Block) hasDalOps() bool {
+	for _, list := range b.Operations {
+		for _, op := range list {
+			for _, c := range op.Contents {
+				switch c.Kind() {
+				case tezos.OpTypeDalAttestation, tezos.OpTypeDalPublishSlotHeader:
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// protocolVersion returns the version of a known protocol. Unknown protocols
+// are assumed to be newer than all known protocols.
+func protocolVersion(h tezos.ProtocolHash) int {
+	if v, ok := tezos.Versions[h]; ok {
+		return v
+	}
+	var max int
+	for _, v := range tezos.Versions {
+		if v > max {
+			max = v
+		}
+	}
+	return max + 1
+}
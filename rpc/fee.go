@@ -0,0 +1,66 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/tzgo/codec"
+)
+
+// FeeEstimator defines the fee policy used by Send. Estimate is called after
+// simulation once gas and storage limits have been applied to op and must
+// return one fee in mutez for each operation in op.Contents.
+type FeeEstimator interface {
+	Estimate(ctx context.Context, op *codec.Op, sim *Receipt) ([]int64, error)
+}
+
+// FeeEstimatorFunc adapts an ordinary function to the FeeEstimator interface.
+type FeeEstimatorFunc func(ctx context.Context, op *codec.Op, sim *Receipt) ([]int64, error)
+
+func (f FeeEstimatorFunc) Estimate(ctx context.Context, op *codec.Op, sim *Receipt) ([]int64, error) {
+	return f(ctx, op, sim)
+}
+
+// DefaultFeeEstimator pays the minimum fee accepted by bakers under default
+// mempool settings or a higher user-defined fee when already set on an operation.
+var DefaultFeeEstimator FeeEstimator = FeeEstimatorFunc(minFeeEstimate)
+
+func minFeeEstimate(_ context.Context, op *codec.Op, _ *Receipt) ([]int64, error) {
+	fees := make([]int64, len(op.Contents))
+	for i, v := range op.Contents {
+		l := v.Limits()
+		userFee := l.Fee
+
+		// fee size affects operation size, so iterate until stable
+		for last := int64(-1); last < l.Fee; {
+			last = l.Fee
+			l.Fee = codec.CalculateMinFee(v, l.GasLimit, i == 0, op.Params)
+			if userFee > l.Fee {
+				l.Fee = userFee
+			}
+			v.WithLimits(l)
+		}
+		fees[i] = l.Fee
+	}
+	return fees, nil
+}
+
+// applyFees runs estimator est on op and sets the resulting fees.
+func applyFees(ctx context.Context, est FeeEstimator, op *codec.Op, sim *Receipt) error {
+	fees, err := est.Estimate(ctx, op, sim)
+	if err != nil {
+		return err
+	}
+	if len(fees) != len(op.Contents) {
+		return fmt.Errorf("rpc: fee estimator returned %d fees for %d operations", len(fees), len(op.Contents))
+	}
+	for i, v := range op.Contents {
+		l := v.Limits()
+		l.Fee = fees[i]
+		v.WithLimits(l)
+	}
+	return nil
+}
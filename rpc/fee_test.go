@@ -0,0 +1,60 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"blockwatch.cc/tzgo/codec"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestFeeEstimator(t *testing.T) {
+	addr := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	op := codec.NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithTransfer(addr, 1).
+		WithTransfer(addr, 2).
+		WithSource(addr)
+	for _, v := range op.Contents {
+		v.WithLimits(tezos.Limits{GasLimit: 1000})
+	}
+	ctx := context.Background()
+
+	// default estimator pays min fee
+	if err := applyFees(ctx, DefaultFeeEstimator, op, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range op.Contents {
+		if have, want := v.Limits().Fee, codec.CalculateMinFee(v, 1000, i == 0, op.Params); have != want {
+			t.Errorf("op %d: have fee %d want %d", i, have, want)
+		}
+	}
+
+	// flat fee policy
+	flat := FeeEstimatorFunc(func(_ context.Context, op *codec.Op, _ *Receipt) ([]int64, error) {
+		fees := make([]int64, len(op.Contents))
+		for i := range fees {
+			fees[i] = 5000
+		}
+		return fees, nil
+	})
+	if err := applyFees(ctx, flat, op, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range op.Contents {
+		if l := v.Limits(); l.Fee != 5000 || l.GasLimit != 1000 {
+			t.Errorf("op %d: unexpected limits %#v", i, l)
+		}
+	}
+
+	// wrong number of fees
+	short := FeeEstimatorFunc(func(context.Context, *codec.Op, *Receipt) ([]int64, error) {
+		return []int64{0}, nil
+	})
+	if err := applyFees(ctx, short, op, nil); err == nil {
+		t.Errorf("expected error for fee count mismatch")
+	}
+}
@@ -20,7 +20,10 @@ type ConstantRegistration struct {
 // Costs returns operation cost to implement TypedOperation interface.
 func (c ConstantRegistration) Costs() tezos.Costs {
 	res := c.Metadata.Result
-	burn := res.BalanceUpdates[0].Amount()
+	var burn int64
+	if upd := res.BalanceUpdates.nonSimulated(); len(upd) > 0 {
+		burn = upd[0].Amount()
+	}
 	return tezos.Costs{
 		Fee:         c.Manager.Fee,
 		GasUsed:     res.Gas(),
@@ -29,3 +32,14 @@ func (c ConstantRegistration) Costs() tezos.Costs {
 		StorageBurn: -burn,
 	}
 }
+
+// GlobalAddress returns the expression hash under which the constant was
+// registered. Use it to reference the constant in future scripts.
+func (c ConstantRegistration) GlobalAddress() tezos.ExprHash {
+	return c.Metadata.Result.GlobalAddress
+}
+
+// StorageSize returns the number of storage bytes paid for the constant.
+func (c ConstantRegistration) StorageSize() int64 {
+	return c.Metadata.Result.StorageSize
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// BalancePoint is a spendable balance change of an account in a block.
+type BalancePoint struct {
+	Height  int64           `json:"height"`
+	Block   tezos.BlockHash `json:"block"`
+	Change  int64           `json:"change"`
+	Balance int64           `json:"balance"`
+}
+
+// GetBalanceAt returns the spendable balance of addr at block id.
+func (c *Client) GetBalanceAt(ctx context.Context, addr tezos.Address, id BlockID) (tezos.Z, error) {
+	return c.GetContractBalance(ctx, addr, id)
+}
+
+// ReconstructBalanceHistory walks all blocks between from and to (inclusive)
+// and sums balance updates affecting the spendable balance of addr. It returns
+// one entry per block where the balance changed. The reconstructed balance
+// is checked against the node's balance at block to. On mismatch, the
+// reconstructed history is returned along with an error.
+func (c *Client) ReconstructBalanceHistory(ctx context.Context, addr tezos.Address, from, to int64) ([]BalancePoint, error) {
+	if from < 1 || from > to {
+		return nil, fmt.Errorf("rpc: invalid block range %d..%d", from, to)
+	}
+	start, err := c.GetBalanceAt(ctx, addr, BlockLevel(from-1))
+	if err != nil {
+		return nil, err
+	}
+	bal := start.Int64()
+	hist := make([]BalancePoint, 0)
+	for height := from; height <= to; height++ {
+		block, err := c.GetBlock(ctx, BlockLevel(height))
+		if err != nil {
+			return nil, err
+		}
+		change := blockBalanceChange(block, addr)
+		if change == 0 {
+			continue
+		}
+		bal += change
+		hist = append(hist, BalancePoint{
+			Height:  block.GetLevel(),
+			Block:   block.Hash,
+			Change:  change,
+			Balance: bal,
+		})
+	}
+	end, err := c.GetBalanceAt(ctx, addr, BlockLevel(to))
+	if err != nil {
+		return hist, err
+	}
+	if end.Int64() != bal {
+		return hist, fmt.Errorf("rpc: reconstructed balance %d of %s does not match balance %d at block %d", bal, addr, end.Int64(), to)
+	}
+	return hist, nil
+}
+
+// blockBalanceChange sums all non-simulated balance updates in block, its
+// implicit operations and its operations that affect the spendable balance of addr.
+func blockBalanceChange(block *Block, addr tezos.Address) int64 {
+	var change int64
+	sum := func(upd BalanceUpdates) {
+		for _, v := range upd {
+			if v.Kind == CONTRACT && !v.IsSimulation() && v.Contract.Equal(addr) {
+				change += v.Change
+			}
+		}
+	}
+	sum(block.Metadata.BalanceUpdates)
+	for _, v := range block.Metadata.ImplicitOperationsResults {
+		// liquidity baking subsidies and migration credits
+		sum(v.BalanceUpdates)
+	}
+	for _, list := range block.Operations {
+		for _, op := range list {
+			for _, o := range op.Contents {
+				meta := o.Meta()
+				sum(meta.BalanceUpdates)
+				sum(meta.Result.BalanceUpdates)
+				for _, in := range meta.InternalResults {
+					sum(in.Result.BalanceUpdates)
+				}
+			}
+		}
+	}
+	return change
+}
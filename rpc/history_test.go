@@ -0,0 +1,101 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestReconstructBalanceHistory(t *testing.T) {
+	addr := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	other := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	upd := func(a tezos.Address, change int64) string {
+		return fmt.Sprintf(`{"kind":"contract","contract":"%s","change":"%d","origin":"block"}`, a, change)
+	}
+	blocks := map[int64]string{
+		// transfer of 100 to addr with fee paid by other
+		11: `[[],[],[],[{"hash":"ooMwWU2b53uMg3cjPexf5H6pBkadfmiX2G546mxKNDCrTNVvGGJ","contents":[{"kind":"transaction",` +
+			`"source":"` + other.String() + `","destination":"` + addr.String() + `","amount":"100",` +
+			`"metadata":{"balance_updates":[` + upd(other, -5) + `],"operation_result":{"status":"applied",` +
+			`"balance_updates":[` + upd(other, -100) + `,` + upd(addr, 100) + `]}}}]}]]`,
+		// liquidity baking subsidy credited through an implicit operation
+		12: `[]`,
+		// transfer of 30 from addr plus fee and a simulated update
+		13: `[[],[],[],[{"hash":"ooMwWU2b53uMg3cjPexf5H6pBkadfmiX2G546mxKNDCrTNVvGGJ","contents":[{"kind":"transaction",` +
+			`"source":"` + addr.String() + `","destination":"` + other.String() + `","amount":"30",` +
+			`"metadata":{"balance_updates":[` + upd(addr, -2) + `],"operation_result":{"status":"applied",` +
+			`"balance_updates":[` + upd(addr, -30) + `,` + upd(other, 30) + `,` +
+			`{"kind":"contract","contract":"` + addr.String() + `","change":"-1","origin":"simulation"}]}}}]}]]`,
+	}
+	meta := map[int64]string{
+		12: `{"implicit_operations_results":[{"kind":"transaction","balance_updates":[` +
+			`{"kind":"minted","category":"subsidy","change":"-5","origin":"subsidy"},` +
+			`{"kind":"contract","contract":"` + addr.String() + `","change":"5","origin":"subsidy"}]}]}`,
+	}
+	final := "1073"
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/*": func(r *http.Request) any {
+			path := strings.TrimPrefix(r.URL.Path, "/chains/main/blocks/")
+			id, rest, _ := strings.Cut(path, "/")
+			height, _ := strconv.ParseInt(id, 10, 64)
+			switch rest {
+			case "":
+				m, ok := meta[height]
+				if !ok {
+					m = `{}`
+				}
+				return fmt.Sprintf(`{"hash":"%s","header":{"level":%d},"metadata":%s,"operations":%s}`, testHash(id), height, m, blocks[height])
+			case "context/contracts/" + addr.String() + "/balance":
+				switch height {
+				case 10:
+					return `"1000"`
+				case 13:
+					return `"` + final + `"`
+				}
+			}
+			return nil
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := c.ReconstructBalanceHistory(context.Background(), addr, 11, 13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 3 {
+		t.Fatalf("expected 3 balance points, got %d", len(hist))
+	}
+	if p := hist[0]; p.Height != 11 || p.Change != 100 || p.Balance != 1100 || !p.Block.Equal(testHash("11")) {
+		t.Errorf("unexpected first point %#v", p)
+	}
+	if p := hist[1]; p.Height != 12 || p.Change != 5 || p.Balance != 1105 {
+		t.Errorf("unexpected subsidy point %#v", p)
+	}
+	if p := hist[2]; p.Height != 13 || p.Change != -32 || p.Balance != 1073 {
+		t.Errorf("unexpected last point %#v", p)
+	}
+
+	final = "1000"
+	if hist, err = c.ReconstructBalanceHistory(context.Background(), addr, 11, 13); err == nil {
+		t.Errorf("expected balance mismatch error")
+	}
+	if len(hist) != 3 {
+		t.Errorf("expected history on mismatch, got %d points", len(hist))
+	}
+
+	if _, err := c.ReconstructBalanceHistory(context.Background(), addr, 13, 11); err == nil {
+		t.Errorf("expected range error")
+	}
+}
@@ -28,7 +28,7 @@ func (t IncreasePaidStorage) Costs() tezos.Costs {
 		return cost
 	}
 	for _, v := range res.BalanceUpdates {
-		if v.Kind != CONTRACT {
+		if v.Kind != CONTRACT || v.IsSimulation() {
 			continue
 		}
 		burn := v.Amount()
@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"time"
 
 	"blockwatch.cc/tzgo/codec"
@@ -10,8 +11,11 @@ import (
 	"blockwatch.cc/tzgo/tezos"
 )
 
-// Ensure Client implements the RpcClient interface
-var _ RpcClient = (*Client)(nil)
+// Ensure Client implements the RpcClient and RpcClientExt interfaces
+var (
+	_ RpcClient    = (*Client)(nil)
+	_ RpcClientExt = (*Client)(nil)
+)
 
 // RpcClient interface for various clients implementations and mocks generation
 type RpcClient interface {
@@ -55,7 +59,7 @@ type RpcClient interface {
 	GetNormalizedScript(ctx context.Context, addr tezos.Address, mode UnparsingMode) (*micheline.Script, error)
 	GetContractStorage(ctx context.Context, addr tezos.Address, id BlockID) (micheline.Prim, error)
 	GetContractStorageNormalized(ctx context.Context, addr tezos.Address, id BlockID, mode UnparsingMode) (micheline.Prim, error)
-	GetContractEntrypoints(ctx context.Context, addr tezos.Address) (map[string]micheline.Type, error)
+	GetContractEntrypoints(ctx context.Context, addr tezos.Address, id BlockID) (map[string]micheline.Type, error)
 	ListBigmapKeys(ctx context.Context, bigmap int64, id BlockID) ([]tezos.ExprHash, error)
 	ListActiveBigmapKeys(ctx context.Context, bigmap int64) ([]tezos.ExprHash, error)
 	GetBigmapValue(ctx context.Context, bigmap int64, hash tezos.ExprHash, id BlockID) (micheline.Prim, error)
@@ -121,3 +125,32 @@ type RpcClient interface {
 	GetVoteResult(ctx context.Context, id BlockID) (BallotSummary, error)
 	ListProposals(ctx context.Context, id BlockID) (ProposalList, error)
 }
+
+// RpcClientExt extends RpcClient with client methods added after RpcClient was
+// defined. RpcClient keeps its method set so that existing implementations and
+// mocks remain valid, new methods are declared here instead.
+type RpcClientExt interface {
+	RpcClient
+	GetWithParams(ctx context.Context, urlpath string, params url.Values, result interface{}) error
+	GetCheckpoint(ctx context.Context) (*Checkpoint, error)
+	GetParamsRange(ctx context.Context, from, to int64) ([]*tezos.Params, error)
+	GetIssuance(ctx context.Context, id BlockID) (*Issuance, error)
+	GetBalanceAt(ctx context.Context, addr tezos.Address, id BlockID) (tezos.Z, error)
+	ReconstructBalanceHistory(ctx context.Context, addr tezos.Address, from, to int64) ([]BalancePoint, error)
+	GetAccount(ctx context.Context, addr tezos.Address, id BlockID) (*Account, error)
+	GetContractStorageValue(ctx context.Context, addr tezos.Address, id BlockID) (micheline.Value, error)
+	ListContractBigmaps(ctx context.Context, addr tezos.Address, id BlockID) ([]int64, error)
+	ListBigmapKeysExt(ctx context.Context, bigmap int64, id BlockID, offset, limit int) ([]tezos.ExprHash, error)
+	IterateBigmap(ctx context.Context, bigmap int64, id BlockID, pageSize int) (*BigmapIterator, error)
+	ResumeBigmap(ctx context.Context, token string, pageSize int) (*BigmapIterator, error)
+	GetSaplingDiff(ctx context.Context, sapling int64, id BlockID) (*SaplingDiff, error)
+	GetSaplingDiffExt(ctx context.Context, sapling int64, id BlockID, commitmentOffset, nullifierOffset int64) (*SaplingDiff, error)
+	GetSaplingState(ctx context.Context, sapling int64, id BlockID) (*SaplingState, error)
+	GetStakers(ctx context.Context, addr tezos.Address, id BlockID) ([]StakerInfo, error)
+	IsRegisteredBaker(ctx context.Context, addr tezos.Address) (bool, error)
+	GetOperationProof(ctx context.Context, id BlockID, l, n int) (OperationProof, error)
+	NewBatch(maxConcurrency int) *Batch
+	CompleteWithOptions(ctx context.Context, o *codec.Op, key tezos.Key, opts *CallOptions) error
+	CallContext(ctx context.Context) (context.Context, context.CancelFunc)
+	WithDefaultCallTimeout(d time.Duration) *Client
+}
@@ -6,6 +6,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"strconv"
 )
 
 type IssuanceParameters struct {
@@ -18,12 +19,38 @@ type IssuanceParameters struct {
 	VdfTip          int64 `json:"vdf_revelation_tip,string"`
 }
 
-// GetIssuance returns expected xtz issuance for known future cycles
-func (c *Client) GetIssuance(ctx context.Context, id BlockID) ([]IssuanceParameters, error) {
-	u := fmt.Sprintf("chains/main/blocks/%s/context/issuance/expected_issuance", id)
-	p := make([]IssuanceParameters, 0, 5)
-	if err := c.Get(ctx, u, p); err != nil {
+// Issuance contains the current adaptive issuance rate along with expected
+// reward coefficients for known future cycles.
+type Issuance struct {
+	PerMinute  int64                // mutez issued per minute
+	YearlyRate float64              // current yearly issuance rate in percent
+	Expected   []IssuanceParameters // expected rewards per cycle
+}
+
+// GetIssuance returns current issuance and expected rewards for known future
+// cycles. Requires a protocol with adaptive issuance (v018+).
+func (c *Client) GetIssuance(ctx context.Context, id BlockID) (*Issuance, error) {
+	base := fmt.Sprintf("chains/main/blocks/%s/context/issuance/", id)
+	var (
+		iss        Issuance
+		perMinute  string
+		yearlyRate string
+		err        error
+	)
+	if err = c.Get(ctx, base+"expected_issuance", &iss.Expected); err != nil {
+		return nil, err
+	}
+	if err = c.Get(ctx, base+"issuance_per_minute", &perMinute); err != nil {
 		return nil, err
 	}
-	return p, nil
+	if iss.PerMinute, err = strconv.ParseInt(perMinute, 10, 64); err != nil {
+		return nil, fmt.Errorf("rpc: invalid issuance per minute %q: %v", perMinute, err)
+	}
+	if err = c.Get(ctx, base+"current_yearly_rate", &yearlyRate); err != nil {
+		return nil, err
+	}
+	if iss.YearlyRate, err = strconv.ParseFloat(yearlyRate, 64); err != nil {
+		return nil, fmt.Errorf("rpc: invalid yearly issuance rate %q: %v", yearlyRate, err)
+	}
+	return &iss, nil
 }
@@ -0,0 +1,37 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+)
+
+func TestGetIssuance(t *testing.T) {
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/issuance/expected_issuance":   `[{"cycle":700,"baking_reward_fixed_portion":"5000000","baking_reward_bonus_per_slot":"2000","attesting_reward_per_slot":"3000","liquidity_baking_subsidy":"5000000","seed_nonce_revelation_tip":"1000","vdf_revelation_tip":"1000"}]`,
+		"/chains/main/blocks/head/context/issuance/issuance_per_minute": `"80007812"`,
+		"/chains/main/blocks/head/context/issuance/current_yearly_rate": `"5.12"`,
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss, err := c.GetIssuance(context.Background(), Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iss.PerMinute != 80007812 {
+		t.Errorf("per minute mismatch: have %d want 80007812", iss.PerMinute)
+	}
+	if iss.YearlyRate != 5.12 {
+		t.Errorf("yearly rate mismatch: have %f want 5.12", iss.YearlyRate)
+	}
+	if len(iss.Expected) != 1 || iss.Expected[0].Cycle != 700 || iss.Expected[0].BakingReward != 5000000 {
+		t.Errorf("unexpected expected issuance %#v", iss.Expected)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// LazyDiff is a single entry of a unified lazy storage diff as found in
+// transaction and origination receipts since v008. Depending on Kind either
+// the bigmap related fields or Sapling are set.
+type LazyDiff struct {
+	Kind   micheline.LazyKind
+	Id     int64
+	Action micheline.DiffAction
+
+	// bigmap
+	SourceId  int64              // copy source
+	KeyType   micheline.Prim     // alloc only
+	ValueType micheline.Prim     // alloc only
+	Updates   []LazyBigmapUpdate // alloc, update
+
+	// sapling
+	Sapling *micheline.SaplingDiffElem
+}
+
+// LazyBigmapUpdate is a single key update in a bigmap diff. A missing value
+// marks a key removal. Typed key and value are only available when the
+// bigmap's types are known, i.e. for newly allocated bigmaps or after calling
+// LazyDiff.DecodeUpdates.
+type LazyBigmapUpdate struct {
+	KeyHash    tezos.ExprHash
+	Key        micheline.Prim
+	Value      micheline.Prim
+	TypedKey   *micheline.Key
+	TypedValue *micheline.Value
+}
+
+// IsRemove returns true when the update removes a key.
+func (u LazyBigmapUpdate) IsRemove() bool {
+	return !u.Value.IsValid()
+}
+
+func (d LazyDiff) IsBigmap() bool {
+	return d.Kind == micheline.LazyKindBigmap
+}
+
+func (d LazyDiff) IsSapling() bool {
+	return d.Kind == micheline.LazyKindSapling
+}
+
+// DecodeUpdates decodes keys and values of all bigmap updates against the
+// bigmap's key and value types. Use this for bigmaps allocated in earlier
+// operations where types must be taken from the contract script.
+func (d *LazyDiff) DecodeUpdates(keyType, valueType micheline.Type) error {
+	if !d.IsBigmap() {
+		return fmt.Errorf("rpc: cannot decode updates for lazy %s diff", d.Kind)
+	}
+	for i := range d.Updates {
+		u := &d.Updates[i]
+		if u.Key.IsValid() {
+			key, err := micheline.NewKey(keyType, u.Key)
+			if err != nil {
+				return fmt.Errorf("rpc: bigmap %d: %v", d.Id, err)
+			}
+			u.TypedKey = &key
+		}
+		if u.Value.IsValid() {
+			u.TypedValue = micheline.NewValuePtr(valueType, u.Value)
+		}
+	}
+	return nil
+}
+
+// LazyDiffs returns typed entries of the unified lazy storage diff. Updates
+// of newly allocated bigmaps are decoded against the allocated types. Results
+// that only carry a legacy bigmap diff or no diff at all return nil.
+func (r OperationResult) LazyDiffs() []LazyDiff {
+	if r.LazyStorageDiff == nil {
+		return nil
+	}
+	events := make(micheline.LazyEvents, 0)
+	if err := json.Unmarshal(r.LazyStorageDiff, &events); err != nil {
+		logger.Warnf("rpc: lazy storage diff: %v", err)
+		return nil
+	}
+	res := make([]LazyDiff, 0, len(events))
+	for _, v := range events {
+		diff := LazyDiff{
+			Kind: v.Kind(),
+			Id:   v.Id(),
+		}
+		switch ev := v.(type) {
+		case *micheline.LazyBigmapEvent:
+			diff.Action = ev.Diff.Action
+			diff.SourceId = ev.Diff.SourceId
+			diff.KeyType = ev.Diff.KeyType
+			diff.ValueType = ev.Diff.ValueType
+			diff.Updates = make([]LazyBigmapUpdate, len(ev.Diff.Updates))
+			for i, u := range ev.Diff.Updates {
+				diff.Updates[i] = LazyBigmapUpdate{
+					KeyHash: u.KeyHash,
+					Key:     u.Key,
+					Value:   u.Value,
+				}
+			}
+			if diff.Action == micheline.DiffActionAlloc {
+				_ = diff.DecodeUpdates(micheline.NewType(diff.KeyType), micheline.NewType(diff.ValueType))
+			}
+		case *micheline.LazySaplingEvent:
+			diff.Action = ev.Diff.Action
+			sapling := ev.Diff
+			diff.Sapling = &sapling
+		}
+		res = append(res, diff)
+	}
+	return res
+}
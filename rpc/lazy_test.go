@@ -0,0 +1,60 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"testing"
+
+	"blockwatch.cc/tzgo/micheline"
+)
+
+func TestOperationResultLazyDiffs(t *testing.T) {
+	r := OperationResult{
+		LazyStorageDiff: []byte(`[` +
+			`{"kind":"big_map","id":"7","diff":{"action":"alloc","updates":[{"key_hash":"exprtZBwZUeYYYfUs9B9Rg2ywHezVHnCCnmF9WsDQVrs582dSK63dC","key":{"string":"a"},"value":{"int":"1"}}],"key_type":{"prim":"string"},"value_type":{"prim":"nat"}}},` +
+			`{"kind":"big_map","id":"5","diff":{"action":"update","updates":[{"key_hash":"exprtZBwZUeYYYfUs9B9Rg2ywHezVHnCCnmF9WsDQVrs582dSK63dC","key":{"string":"a"}}]}},` +
+			`{"kind":"big_map","id":"8","diff":{"action":"copy","source":"5","updates":[]}},` +
+			`{"kind":"sapling_state","id":"3","diff":{"action":"alloc","updates":{"commitments":[],"ciphertexts":[],"nullifiers":[]},"memo_size":8}}` +
+			`]`),
+	}
+	diffs := r.LazyDiffs()
+	if len(diffs) != 4 {
+		t.Fatalf("expected 4 diffs, got %d", len(diffs))
+	}
+
+	// alloc decodes updates against allocated types
+	d := diffs[0]
+	if !d.IsBigmap() || d.Id != 7 || d.Action != micheline.DiffActionAlloc || len(d.Updates) != 1 {
+		t.Fatalf("unexpected alloc diff %#v", d)
+	}
+	if u := d.Updates[0]; u.IsRemove() || u.TypedKey == nil || u.TypedKey.String() != "a" || u.TypedValue == nil {
+		t.Errorf("expected typed update, got %#v", u)
+	}
+
+	// updates on existing bigmaps are untyped until decoded
+	d = diffs[1]
+	if d.Action != micheline.DiffActionUpdate || len(d.Updates) != 1 || !d.Updates[0].IsRemove() || d.Updates[0].TypedKey != nil {
+		t.Fatalf("unexpected update diff %#v", d)
+	}
+	if err := d.DecodeUpdates(micheline.NewType(micheline.NewPrim(micheline.T_STRING)), micheline.NewType(micheline.NewPrim(micheline.T_NAT))); err != nil {
+		t.Fatal(err)
+	}
+	if d.Updates[0].TypedKey == nil || d.Updates[0].TypedValue != nil {
+		t.Errorf("unexpected decoded update %#v", d.Updates[0])
+	}
+
+	if d = diffs[2]; d.Action != micheline.DiffActionCopy || d.SourceId != 5 || d.Id != 8 {
+		t.Errorf("unexpected copy diff %#v", d)
+	}
+	if d = diffs[3]; !d.IsSapling() || d.Sapling == nil || d.Sapling.MemoSize != 8 || d.Action != micheline.DiffActionAlloc {
+		t.Errorf("unexpected sapling diff %#v", d)
+	}
+	if err := d.DecodeUpdates(micheline.Type{}, micheline.Type{}); err == nil {
+		t.Errorf("expected error decoding sapling updates")
+	}
+
+	if (OperationResult{}).LazyDiffs() != nil {
+		t.Errorf("expected nil diffs for empty result")
+	}
+}
@@ -236,6 +236,7 @@ type MempoolMonitor struct {
 	result chan *[]*Operation
 	closed chan struct{}
 	err    error
+	cursor *SeenCursor
 }
 
 // make sure MempoolMonitor implements Monitor interface
@@ -287,6 +288,37 @@ func (m *MempoolMonitor) Recv(ctx context.Context) ([]*Operation, error) {
 	}
 }
 
+// WithCursor sets the cursor RecvNew uses to skip operations that were already
+// delivered. Share a cursor between monitors to skip operations the node
+// re-sends after a reconnect.
+func (m *MempoolMonitor) WithCursor(c *SeenCursor) *MempoolMonitor {
+	m.cursor = c
+	return m
+}
+
+// Cursor returns the monitor's cursor of delivered operations.
+func (m *MempoolMonitor) Cursor() *SeenCursor {
+	if m.cursor == nil {
+		m.cursor = NewSeenCursor(0)
+	}
+	return m.cursor
+}
+
+// RecvNew is like Recv, but skips operations delivered in prior batches. It
+// blocks until at least one new operation is received.
+func (m *MempoolMonitor) RecvNew(ctx context.Context) ([]*Operation, error) {
+	cursor := m.Cursor()
+	for {
+		ops, err := m.Recv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ops = cursor.Filter(ops); len(ops) > 0 {
+			return ops, nil
+		}
+	}
+}
+
 func (m *MempoolMonitor) Err(err error) {
 	m.err = err
 	m.Close()
@@ -5,6 +5,7 @@ package rpc
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"time"
 
@@ -16,15 +17,23 @@ import (
 // TODO:
 // - support AdressObserver with address subscription filter
 // - disable events/polling when no subscriber exists
-// - handle reorgs (inclusion may switch to a different block hash)
 
 type ObserverCallback func(*BlockHeaderLogEntry, int64, int, int, bool) bool
 
+// ObserverReorgCallback is called with the current head block and the height
+// of a previously reported inclusion block when this block has been orphaned
+// by a chain reorganization. Subscribers should reset their state and wait for
+// the operation to be included again.
+type ObserverReorgCallback func(*BlockHeaderLogEntry, int64)
+
 type observerSubscription struct {
 	id      int
 	cb      ObserverCallback
+	onReorg ObserverReorgCallback
 	oh      tezos.OpHash
 	matched bool
+	block   tezos.BlockHash // inclusion block, used to detect reorgs
+	height  int64           // inclusion block height
 }
 
 type Observer struct {
@@ -38,6 +47,7 @@ type Observer struct {
 	cancel   context.CancelFunc
 	c        *Client
 	minDelay time.Duration
+	poll     bool
 	head     *BlockHeaderLogEntry
 }
 
@@ -58,11 +68,32 @@ func NewObserver() *Observer {
 }
 
 func (m *Observer) Head() *BlockHeaderLogEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.head
 }
 
+// blockDelay returns the minimal block delay of the observed network.
+func (m *Observer) blockDelay() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.minDelay
+}
+
 func (m *Observer) WithDelay(minDelay time.Duration) *Observer {
+	m.mu.Lock()
 	m.minDelay = minDelay
+	m.mu.Unlock()
+	return m
+}
+
+// WithPollMode disables the streaming block monitor and polls the chain tip
+// every minimal block delay instead. This is useful for nodes or proxies that
+// do not forward streaming responses. Must be called before Listen. Without
+// it the observer falls back to polling when a node reports the monitor
+// endpoint as unsupported.
+func (m *Observer) WithPollMode() *Observer {
+	m.poll = true
 	return m
 }
 
@@ -76,21 +107,33 @@ func (m *Observer) Close() {
 }
 
 func (m *Observer) Subscribe(oh tezos.OpHash, cb ObserverCallback) int {
+	return m.SubscribeWithReorg(oh, cb, nil)
+}
+
+// SubscribeWithReorg works like Subscribe and additionally calls onReorg when
+// the block that included the operation gets orphaned. When the operation is
+// included again cb is called as for a first match.
+func (m *Observer) SubscribeWithReorg(oh tezos.OpHash, cb ObserverCallback, onReorg ObserverReorgCallback) int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.seq++
 	seq := m.seq
 	m.subs[seq] = &observerSubscription{
-		id: seq,
-		cb: cb,
-		oh: oh,
+		id:      seq,
+		cb:      cb,
+		onReorg: onReorg,
+		oh:      oh,
 	}
 	if pos, ok := m.recent[oh]; ok {
 		match := m.subs[seq]
 		m.c.Log.Debugf("monitor: %03d direct match %s", seq, oh)
 		if remove := match.cb(m.head, pos[0], int(pos[1]), int(pos[2]), false); remove {
 			delete(m.subs, match.id)
+			return seq
 		}
+		match.matched = true
+		match.block = m.head.Hash
+		match.height = pos[0]
 	}
 	m.c.Log.Debugf("monitor: %03d subscribed %s", seq, oh)
 	m.watched[oh] = append(m.watched[oh], seq)
@@ -125,20 +168,24 @@ func (m *Observer) removeWatcher(oh tezos.OpHash, id int) {
 
 func (m *Observer) Listen(cli *Client) {
 	m.once.Do(func() {
+		m.mu.Lock()
 		m.c = cli
 		if m.c.Params != nil {
 			m.minDelay = m.c.Params.MinimalBlockDelay
 		}
+		m.mu.Unlock()
 		go m.listenBlocks()
 	})
 }
 
 func (m *Observer) ListenMempool(cli *Client) {
 	m.once.Do(func() {
+		m.mu.Lock()
 		m.c = cli
 		if m.c.Params != nil {
 			m.minDelay = m.c.Params.MinimalBlockDelay
 		}
+		m.mu.Unlock()
 		go m.listenMempool()
 	})
 }
@@ -150,7 +197,7 @@ func (m *Observer) listenMempool() {
 func (m *Observer) listenBlocks() {
 	var (
 		mon       *BlockHeaderMonitor
-		useEvents bool = true
+		useEvents bool = !m.poll
 		firstLoop bool = true
 	)
 	defer func() {
@@ -173,7 +220,7 @@ func (m *Observer) listenBlocks() {
 			if err := m.c.MonitorBlockHeader(m.ctx, mon); err != nil {
 				mon.Close()
 				mon = nil
-				if ErrorStatus(err) == 404 {
+				if isMonitorUnsupported(err) {
 					m.c.Log.Debug("monitor: event mode unsupported, falling back to poll mode.")
 					useEvents = false
 				} else {
@@ -230,7 +277,11 @@ func (m *Observer) listenBlocks() {
 		}
 		m.c.Log.Debugf("monitor: new block %d %s", head.Level, head.Hash)
 
-		// TODO: check for reorg and gaps
+		// check whether inclusion blocks of previous matches were orphaned,
+		// reset and search again for the operations they contain
+		if orphans := m.findOrphans(head); len(orphans) > 0 {
+			m.rematch(head, orphans)
+		}
 
 		// handle block watchers
 		m.mu.Lock()
@@ -309,6 +360,8 @@ func (m *Observer) listenBlocks() {
 						removed = append(removed, sub)
 					} else {
 						sub.matched = true
+						sub.block = head.Hash
+						sub.height = head.Level
 					}
 				}
 
@@ -333,3 +386,128 @@ func (m *Observer) listenBlocks() {
 		}
 	}
 }
+
+// isMonitorUnsupported returns true when a node rejects streaming monitor
+// requests because the endpoint is missing, disabled or blocked by a proxy.
+func isMonitorUnsupported(err error) bool {
+	switch ErrorStatus(err) {
+	case http.StatusNotFound,
+		http.StatusForbidden,
+		http.StatusMethodNotAllowed,
+		http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// findOrphans returns all matched subscriptions whose inclusion block is no longer
+// part of the canonical chain ending at head. Orphaned subscriptions are reset
+// and their subscribers are notified.
+func (m *Observer) findOrphans(head *BlockHeaderLogEntry) []*observerSubscription {
+	// when the new head extends the last seen head the chain has not changed
+	if head.Predecessor.Equal(m.head.Hash) || !m.head.Hash.IsValid() {
+		return nil
+	}
+
+	// collect inclusion blocks by height
+	m.mu.Lock()
+	check := make(map[int64]tezos.BlockHash)
+	for _, v := range m.subs {
+		if v.matched {
+			check[v.height] = tezos.ZeroBlockHash
+		}
+	}
+	m.mu.Unlock()
+	if len(check) == 0 {
+		return nil
+	}
+
+	// lookup canonical block hashes at inclusion heights
+	for height := range check {
+		if height >= head.Level {
+			check[height] = head.Hash
+			continue
+		}
+		hash, err := m.c.GetBlockHash(m.ctx, NewBlockOffset(head.Hash, height-head.Level))
+		if err != nil {
+			m.c.Log.Warnf("monitor: cannot check block %d for reorg: %v", height, err)
+			delete(check, height)
+			continue
+		}
+		check[height] = hash
+	}
+
+	// reset subscriptions on orphaned blocks
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var orphans []*observerSubscription
+	for _, v := range m.subs {
+		if !v.matched {
+			continue
+		}
+		hash, ok := check[v.height]
+		if !ok || hash.Equal(v.block) {
+			continue
+		}
+		m.c.Log.Warnf("monitor: reorg orphaned block %d %s for %d %s", v.height, v.block, v.id, v.oh)
+		v.matched = false
+		v.block = tezos.ZeroBlockHash
+		if v.onReorg != nil {
+			v.onReorg(head, v.height)
+		}
+		orphans = append(orphans, v)
+	}
+	return orphans
+}
+
+// rematch searches the canonical chain below head for operations of orphaned
+// subscriptions. When an operation was included again, its subscription
+// restarts counting confirmations from the new inclusion block.
+func (m *Observer) rematch(head *BlockHeaderLogEntry, orphans []*observerSubscription) {
+	from := head.Level
+	for _, v := range orphans {
+		if v.height < from {
+			from = v.height
+		}
+	}
+	for height := from; height < head.Level; height++ {
+		id := NewBlockOffset(head.Hash, height-head.Level)
+		ohs, err := m.c.GetBlockOperationHashes(m.ctx, id)
+		if err != nil {
+			m.c.Log.Warnf("monitor: cannot fetch block ops: %v", err)
+			return
+		}
+		var block *BlockHeaderLogEntry
+		for l, list := range ohs {
+			for n, h := range list {
+				for _, sub := range orphans {
+					if sub.matched || !sub.oh.Equal(h) {
+						continue
+					}
+					if block == nil {
+						bh, err := m.c.GetBlockHeader(m.ctx, id)
+						if err != nil {
+							m.c.Log.Warnf("monitor: cannot fetch block header: %v", err)
+							return
+						}
+						block = bh.LogEntry()
+					}
+					m.c.Log.Debugf("monitor: re-matched %d %s in block %d", sub.id, sub.oh, height)
+					m.mu.Lock()
+					if _, ok := m.subs[sub.id]; ok {
+						if remove := sub.cb(block, height, l, n, false); remove {
+							delete(m.subs, sub.id)
+							m.removeWatcher(sub.oh, sub.id)
+						} else {
+							sub.matched = true
+							sub.block = block.Hash
+							sub.height = height
+						}
+					}
+					m.mu.Unlock()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+type testBlock struct {
+	hash  tezos.BlockHash
+	pred  tezos.BlockHash
+	level int64
+	ops   []tezos.OpHash
+}
+
+// testChain is a minimal fake node serving the endpoints used by the block observer.
+type testChain struct {
+	sync.Mutex
+	blocks  map[tezos.BlockHash]*testBlock
+	head    *testBlock
+	mempool []tezos.OpHash
+	refused []tezos.OpHash
+}
+
+func newTestChain() *testChain {
+	return &testChain{blocks: make(map[tezos.BlockHash]*testBlock)}
+}
+
+func testHash(name string) tezos.BlockHash {
+	return tezos.NewBlockHash([]byte(name + strings.Repeat("_", 32-len(name))))
+}
+
+func (c *testChain) add(name, pred string, level int64, ops ...tezos.OpHash) {
+	c.Lock()
+	defer c.Unlock()
+	b := &testBlock{
+		hash:  testHash(name),
+		level: level,
+		ops:   ops,
+	}
+	if pred != "" {
+		b.pred = testHash(pred)
+	}
+	c.blocks[b.hash] = b
+	c.head = b
+}
+
+// resolve translates block ids of the form `head`, `<hash>` and `<hash>~n`
+func (c *testChain) resolve(id string) *testBlock {
+	var n int
+	if base, ofs, ok := strings.Cut(id, "~"); ok {
+		n, _ = strconv.Atoi(ofs)
+		id = base
+	}
+	b := c.head
+	if id != "head" {
+		h, err := tezos.ParseBlockHash(id)
+		if err != nil {
+			return nil
+		}
+		b = c.blocks[h]
+	}
+	for ; b != nil && n > 0; n-- {
+		b = c.blocks[b.pred]
+	}
+	return b
+}
+
+func (c *testChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	if r.URL.Path == "/chains/main/mempool/monitor_operations" {
+		ops := make([]map[string]any, 0)
+		if r.URL.Query().Get("validated") != "false" {
+			for _, oh := range c.mempool {
+				ops = append(ops, map[string]any{"hash": oh})
+			}
+		}
+		if r.URL.Query().Get("refused") != "false" {
+			for _, oh := range c.refused {
+				ops = append(ops, map[string]any{"hash": oh})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ops)
+		return
+	}
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/chains/main/blocks/"), "/")
+	if len(path) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	b := c.resolve(path[0])
+	if b == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var resp any
+	switch path[1] {
+	case "header":
+		resp = map[string]any{
+			"hash":        b.hash,
+			"level":       b.level,
+			"predecessor": b.pred,
+		}
+	case "hash":
+		resp = b.hash
+	case "operation_hashes":
+		resp = [][]tezos.OpHash{{}, {}, {}, b.ops}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func waitUntil(t *testing.T, what string, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestObserverReorg(t *testing.T) {
+	oh := tezos.NewOpHash([]byte("op_hash_under_test______________"))
+	chain := newTestChain()
+	chain.add("a9", "", 9)
+	srv := nodetest.NewServer(t, nodetest.Routes{"/*": chain})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs := NewObserver().WithDelay(10 * time.Millisecond)
+	defer obs.Close()
+	obs.Listen(c)
+
+	res := NewResult(oh).WithConfirmations(3)
+	res.Listen(obs)
+	waitUntil(t, "initial head", func() bool { return obs.Head().Level == 9 })
+
+	// op gets included, first confirmation
+	chain.add("a10", "a9", 10, oh)
+	waitUntil(t, "inclusion", func() bool { return res.Confirmations() == 1 })
+
+	// reorg onto a branch that does not contain op
+	chain.add("b10", "a9", 10)
+	chain.add("b11", "b10", 11)
+	waitUntil(t, "reorg", func() bool { return obs.Head().Level == 11 })
+	if got := res.Confirmations(); got != 0 {
+		t.Fatalf("confirmations after reorg: want 0, got %d", got)
+	}
+
+	// op gets included again on the new branch
+	chain.add("b12", "b11", 12, oh)
+	waitUntil(t, "re-inclusion", func() bool { return res.Confirmations() == 1 })
+	chain.add("b13", "b12", 13)
+	waitUntil(t, "second confirmation", func() bool { return res.Confirmations() == 2 })
+	chain.add("b14", "b13", 14)
+	select {
+	case <-res.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for confirmations, have %d", res.Confirmations())
+	}
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !res.block.Equal(testHash("b12")) || res.height != 12 {
+		t.Errorf("inclusion block: want b12/12, got %s/%d", res.block, res.height)
+	}
+}
+
+func TestObserverReorgRematch(t *testing.T) {
+	oh := tezos.NewOpHash([]byte("op_hash_under_test______________"))
+	chain := newTestChain()
+	chain.add("a9", "", 9)
+	srv := nodetest.NewServer(t, nodetest.Routes{"/*": chain})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs := NewObserver().WithDelay(10 * time.Millisecond)
+	defer obs.Close()
+	obs.Listen(c)
+
+	res := NewResult(oh).WithConfirmations(5)
+	res.Listen(obs)
+	waitUntil(t, "initial head", func() bool { return obs.Head().Level == 9 })
+
+	chain.add("a10", "a9", 10, oh)
+	waitUntil(t, "inclusion", func() bool { return res.Confirmations() == 1 })
+
+	// new branch includes op in a block the observer never saw as head
+	chain.Lock()
+	b10 := &testBlock{hash: testHash("b10"), pred: testHash("a9"), level: 10, ops: []tezos.OpHash{oh}}
+	chain.blocks[b10.hash] = b10
+	chain.Unlock()
+	chain.add("b11", "b10", 11)
+	waitUntil(t, "reorg", func() bool { return obs.Head().Level == 11 })
+	waitUntil(t, "re-inclusion", func() bool { return res.Confirmations() == 2 })
+	if !res.block.Equal(testHash("b10")) || res.height != 10 {
+		t.Errorf("inclusion block: want b10/10, got %s/%d", res.block, res.height)
+	}
+}
+
+func TestResultMempoolConfirmation(t *testing.T) {
+	oh := tezos.NewOpHash([]byte("op_hash_under_test______________"))
+	chain := newTestChain()
+	chain.add("a9", "", 9)
+	srv := nodetest.NewServer(t, nodetest.Routes{"/*": chain})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs := NewObserver().WithDelay(10 * time.Millisecond)
+	defer obs.Close()
+	obs.Listen(c)
+
+	res := NewResult(oh).WithConfirmations(2).WithMempoolConfirmation()
+	res.Listen(obs)
+
+	// op enters the mempool
+	chain.Lock()
+	chain.mempool = []tezos.OpHash{oh}
+	chain.Unlock()
+	select {
+	case <-res.Seen():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for mempool visibility")
+	}
+	res.Wait()
+	if got := res.Confirmations(); got != 0 {
+		t.Fatalf("confirmations after mempool: want 0, got %d", got)
+	}
+	if _, err := res.GetReceipt(context.Background()); !errors.Is(err, ErrNotIncluded) {
+		t.Errorf("receipt before inclusion: want ErrNotIncluded, got %v", err)
+	}
+
+	// block confirmations continue in the background
+	chain.add("a10", "a9", 10, oh)
+	waitUntil(t, "inclusion", func() bool { return res.Confirmations() == 1 })
+	chain.add("a11", "a10", 11)
+	select {
+	case <-res.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for confirmations, have %d", res.Confirmations())
+	}
+	if !res.block.Equal(testHash("a10")) {
+		t.Errorf("inclusion block: want a10, got %s", res.block)
+	}
+}
+
+func TestResultMempoolIgnoresRefused(t *testing.T) {
+	oh := tezos.NewOpHash([]byte("op_hash_under_test______________"))
+	chain := newTestChain()
+	chain.add("a9", "", 9)
+	chain.refused = []tezos.OpHash{oh}
+	srv := nodetest.NewServer(t, nodetest.Routes{"/*": chain})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs := NewObserver().WithDelay(10 * time.Millisecond)
+	defer obs.Close()
+	obs.Listen(c)
+
+	res := NewResult(oh).WithConfirmations(1).WithMempoolConfirmation()
+	res.Listen(obs)
+	defer res.Cancel()
+
+	// refused operations must not count as seen
+	select {
+	case <-res.Seen():
+		t.Fatal("refused operation reported as seen")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	chain.Lock()
+	chain.mempool = []tezos.OpHash{oh}
+	chain.Unlock()
+	select {
+	case <-res.Seen():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for mempool visibility")
+	}
+}
+
+func TestObserverPollFallback(t *testing.T) {
+	chain := newTestChain()
+	chain.add("a9", "", 9)
+	for _, test := range []struct {
+		name   string
+		status int
+		force  bool
+	}{
+		{"not_implemented", http.StatusNotImplemented, false},
+		{"forbidden", http.StatusForbidden, false},
+		{"forced", http.StatusOK, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var monitorCalls int32
+			srv := nodetest.NewServer(t, nodetest.Routes{
+				"/monitor/heads/main": func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&monitorCalls, 1)
+					http.Error(w, "unsupported", test.status)
+				},
+				"/*": chain,
+			})
+
+			c, err := NewClient(srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			obs := NewObserver().WithDelay(10 * time.Millisecond)
+			if test.force {
+				obs.WithPollMode()
+			}
+			defer obs.Close()
+			obs.Listen(c)
+			waitUntil(t, "head", func() bool { return obs.Head().Level == 9 })
+			if n := atomic.LoadInt32(&monitorCalls); test.force && n > 0 {
+				t.Errorf("expected no monitor calls in poll mode, got %d", n)
+			} else if !test.force && n != 1 {
+				t.Errorf("expected a single monitor call before fallback, got %d", n)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"blockwatch.cc/tzgo/micheline"
 	"blockwatch.cc/tzgo/tezos"
@@ -56,6 +58,8 @@ type TypedOperation interface {
 	Kind() tezos.OpType
 	Meta() OperationMetadata
 	Result() OperationResult
+	GetSource() tezos.Address
+	GetFee() int64
 	Costs() tezos.Costs
 	Limits() tezos.Limits
 }
@@ -65,9 +69,57 @@ type TypedOperation interface {
 type OperationError struct {
 	GenericError
 	Contract *tezos.Address  `json:"contract,omitempty"`
+	Location int64           `json:"location,omitempty"`
 	Raw      json.RawMessage `json:"-"`
 }
 
+// Error returns a readable error message including the full protocol error id,
+// the failing contract, the script location and the rejected value if present.
+func (o OperationError) Error() string {
+	var b strings.Builder
+	b.WriteString("tezos: ")
+	b.WriteString(o.ID)
+	if o.Kind != "" {
+		b.WriteString(" (")
+		b.WriteString(o.Kind)
+		b.WriteByte(')')
+	}
+	if o.Contract != nil && o.Contract.IsValid() {
+		b.WriteString(" contract=")
+		b.WriteString(o.Contract.String())
+	}
+	if o.Location > 0 {
+		b.WriteString(" location=")
+		b.WriteString(strconv.FormatInt(o.Location, 10))
+	}
+	if o.With.IsValid() {
+		b.WriteString(" with=")
+		b.WriteString(o.With.Dump())
+	}
+	return b.String()
+}
+
+// ShortID returns the error id without protocol prefix, e.g.
+// `michelson_v1.script_rejected` for `proto.018-Proxford.michelson_v1.script_rejected`.
+func (o OperationError) ShortID() string {
+	if strings.HasPrefix(o.ID, "proto.") {
+		if _, id, ok := strings.Cut(o.ID[6:], "."); ok {
+			return id
+		}
+	}
+	return o.ID
+}
+
+// traceError returns the most specific error from an error trace. Since nodes
+// report the failing contract on an outer trace entry it is copied over.
+func traceError(errs []OperationError) OperationError {
+	e := errs[len(errs)-1]
+	for i := len(errs) - 2; i >= 0 && e.Contract == nil; i-- {
+		e.Contract = errs[i].Contract
+	}
+	return e
+}
+
 // OperationMetadata contains execution receipts for successful and failed
 // operations.
 type OperationMetadata struct {
@@ -88,6 +140,9 @@ type OperationMetadata struct {
 
 	// v18 slashing ops may block a baker
 	ForbiddenDelegate tezos.Address `json:"forbidden_delegate"` // v18+
+
+	// drain delegate only
+	AllocatedDestination bool `json:"allocated_destination_contract"`
 }
 
 // Address returns the delegate address for endorsements.
@@ -146,21 +201,70 @@ func (r OperationResult) BigmapEvents() micheline.BigmapEvents {
 	return nil
 }
 
+// isRelated returns true when addr appears in balance updates or originated
+// contracts of the result.
+func (r OperationResult) isRelated(addr tezos.Address) bool {
+	for _, v := range r.BalanceUpdates {
+		if v.Address().Equal(addr) {
+			return true
+		}
+	}
+	for _, v := range r.OriginatedContracts {
+		if v.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// StorageBurn returns the amount burned for storage paid by this result.
+// It is computed from paid storage size and the cost per byte in params
+// and does not depend on the order of balance updates. Nil params use
+// tezos.DefaultParams. When balance updates contain storage fee burns that
+// do not match the computed storage and allocation burn a warning is logged.
+func (r OperationResult) StorageBurn(params *tezos.Params) tezos.Z {
+	if params == nil {
+		params = tezos.DefaultParams
+	}
+	burn := tezos.NewZ(r.PaidStorageSizeDiff).Mul64(params.CostPerByte)
+
+	// cross-check against burned storage fees
+	var (
+		fees  int64
+		found bool
+	)
+	for _, v := range r.BalanceUpdates {
+		if v.Kind == "burned" && v.Category == "storage fees" && !v.IsSimulation() {
+			fees += v.Amount()
+			found = true
+		}
+	}
+	if found {
+		alloc := int64(len(r.OriginatedContracts))
+		if r.Allocated {
+			alloc++
+		}
+		want := burn.Add64(alloc * params.OriginationSize * params.CostPerByte)
+		if have := tezos.NewZ(fees); !have.Equal(want) {
+			logger.Warnf("rpc: storage burn mismatch: balance updates %s, expected %s", have, want)
+		}
+	}
+	return burn
+}
+
 func (r OperationResult) IsSuccess() bool {
 	return r.Status == tezos.OpStatusApplied
 }
 
+// Gas returns consumed gas units independent of protocol version. Nodes
+// report consumed_milligas since v007 and dropped consumed_gas in v015, so
+// prefer this over reading either field directly. Milligas is rounded up
+// to full gas units like the protocol does when charging gas limits.
 func (r OperationResult) Gas() int64 {
-	if r.ConsumedMilliGas > 0 {
-		var corr int64
-		if r.ConsumedMilliGas%1000 > 0 {
-			corr++
-		}
-		return r.ConsumedMilliGas/1000 + corr
-	}
-	return r.ConsumedGas
+	return milliGasToGas(r.ConsumedMilliGas, r.ConsumedGas)
 }
 
+// MilliGas returns consumed milligas independent of protocol version.
 func (r OperationResult) MilliGas() int64 {
 	if r.ConsumedMilliGas > 0 {
 		return r.ConsumedMilliGas
@@ -168,6 +272,15 @@ func (r OperationResult) MilliGas() int64 {
 	return r.ConsumedGas * 1000
 }
 
+// milliGasToGas converts milligas to gas units rounding up and falls back
+// to gas reported by legacy protocols.
+func milliGasToGas(milligas, gas int64) int64 {
+	if milligas > 0 {
+		return (milligas + 999) / 1000
+	}
+	return gas
+}
+
 func (o OperationError) MarshalJSON() ([]byte, error) {
 	return o.Raw, nil
 }
@@ -177,6 +290,15 @@ func (o *OperationError) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, (*alias)(o)); err != nil {
 		return err
 	}
+	// runtime errors name the failing contract `contract_handle`
+	if o.Contract == nil {
+		var h struct {
+			Handle *tezos.Address `json:"contract_handle"`
+		}
+		if err := json.Unmarshal(data, &h); err == nil {
+			o.Contract = h.Handle
+		}
+	}
 	o.Raw = make([]byte, len(data))
 	copy(o.Raw, data)
 	return nil
@@ -203,6 +325,17 @@ func (e Generic) Result() OperationResult {
 	return e.Metadata.Result
 }
 
+// GetSource returns an invalid address for operations without a signer, e.g.
+// denunciations and nonce revelations. Implements TypedOperation interface.
+func (e Generic) GetSource() tezos.Address {
+	return tezos.InvalidAddress
+}
+
+// GetFee returns zero for operations that pay no fee. Implements TypedOperation interface.
+func (e Generic) GetFee() int64 {
+	return 0
+}
+
 // Costs returns empty operation costs to implement TypedOperation interface.
 func (e Generic) Costs() tezos.Costs {
 	return tezos.Costs{}
@@ -232,6 +365,47 @@ func (e Manager) Limits() tezos.Limits {
 	}
 }
 
+// GetSource returns the manager operation's source. Implements TypedOperation interface.
+func (e Manager) GetSource() tezos.Address {
+	return e.Source
+}
+
+// GetFee returns the manager operation's fee. Implements TypedOperation interface.
+func (e Manager) GetFee() int64 {
+	return e.Fee
+}
+
+// isRelated returns true when addr is the source or destination of op or
+// appears in its balance updates or originated contracts. Internal operations
+// are not considered.
+func isRelated(op TypedOperation, addr tezos.Address) bool {
+	if op.GetSource().Equal(addr) {
+		return true
+	}
+	var dest tezos.Address
+	switch v := op.(type) {
+	case *Transaction:
+		dest = v.Destination
+	case *Delegation:
+		dest = v.Delegate
+	case *DrainDelegate:
+		dest = v.Destination
+	case *TransferTicket:
+		dest = v.Destination
+	case *IncreasePaidStorage:
+		dest = v.Destination
+	}
+	if dest.Equal(addr) {
+		return true
+	}
+	for _, v := range op.Meta().BalanceUpdates {
+		if v.Address().Equal(addr) {
+			return true
+		}
+	}
+	return op.Result().isRelated(addr)
+}
+
 // OperationList is a slice of TypedOperation (interface type) with custom JSON unmarshaller
 type OperationList []TypedOperation
 
@@ -309,7 +483,7 @@ func (e *OperationList) UnmarshalJSON(data []byte) error {
 			tezos.OpTypeDoublePreendorsementEvidence:
 			op = &DoubleEndorsement{}
 		case tezos.OpTypeSeedNonceRevelation:
-			op = &SeedNonce{}
+			op = &SeedNonceRevelation{}
 		case tezos.OpTypeDrainDelegate:
 			op = &DrainDelegate{}
 
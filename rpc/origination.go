@@ -40,7 +40,7 @@ func (o Origination) Costs() tezos.Costs {
 	}
 	var i int
 	for _, v := range res.BalanceUpdates {
-		if v.Kind != CONTRACT {
+		if v.Kind != CONTRACT || v.IsSimulation() {
 			continue
 		}
 		if res.PaidStorageSizeDiff > 0 && i == 0 {
@@ -0,0 +1,137 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// MerkleStep is a single step in a Merkle inclusion path. Hash is the sibling
+// node on the same tree level, Left is true when the sibling is the left child.
+type MerkleStep struct {
+	Hash tezos.HexBytes `json:"hash"`
+	Left bool           `json:"left"`
+}
+
+// OperationProof proves inclusion of an operation in a block. ListPath links
+// the operation hash to its validation pass list hash and BlockPath links the
+// list hash to the block header's operations hash.
+type OperationProof struct {
+	List      int          `json:"list"`
+	Position  int          `json:"position"`
+	ListPath  []MerkleStep `json:"list_path"`
+	BlockPath []MerkleStep `json:"block_path"`
+}
+
+// Verify checks that the operation with hash oh is included at the proof's
+// list and position under the operations hash root from a trusted block header.
+func (p OperationProof) Verify(oh tezos.OpHash, root tezos.OpListListHash) bool {
+	h, pos, ok := merkleCheck(merkleLeaf(oh[:]), p.ListPath)
+	if !ok || pos != p.Position {
+		return false
+	}
+	h, pos, ok = merkleCheck(merkleLeaf(h[:]), p.BlockPath)
+	if !ok || pos != p.List {
+		return false
+	}
+	return h == root
+}
+
+// GetOperationProof returns a Merkle proof for the operation at list l and
+// position n in block id. Tezos nodes do not serve proofs directly, so the
+// proof is built from the block's operation hashes. Callers must verify it
+// against an independently trusted block header.
+func (c *Client) GetOperationProof(ctx context.Context, id BlockID, l, n int) (OperationProof, error) {
+	ohs, err := c.GetBlockOperationHashes(ctx, id)
+	if err != nil {
+		return OperationProof{}, err
+	}
+	if l < 0 || l >= len(ohs) {
+		return OperationProof{}, fmt.Errorf("rpc: operation list %d out of range [0,%d)", l, len(ohs))
+	}
+	if n < 0 || n >= len(ohs[l]) {
+		return OperationProof{}, fmt.Errorf("rpc: operation position %d out of range [0,%d)", n, len(ohs[l]))
+	}
+	lists := make([][32]byte, len(ohs))
+	proof := OperationProof{List: l, Position: n}
+	for i, list := range ohs {
+		leaves := make([][32]byte, len(list))
+		for j, oh := range list {
+			leaves[j] = merkleLeaf(oh[:])
+		}
+		if i == l {
+			lists[i], proof.ListPath = merklePath(leaves, n)
+		} else {
+			lists[i], _ = merklePath(leaves, -1)
+		}
+		lists[i] = merkleLeaf(lists[i][:])
+	}
+	_, proof.BlockPath = merklePath(lists, l)
+	return proof, nil
+}
+
+// merkleLeaf hashes a leaf value, Tezos Merkle trees hash all leaves again.
+func merkleLeaf(b []byte) [32]byte {
+	return tezos.Digest(b)
+}
+
+func merkleNode(l, r [32]byte) [32]byte {
+	return tezos.Digest(append(l[:], r[:]...))
+}
+
+// merklePath computes the root of a Tezos Merkle tree over hashed leaves and
+// the inclusion path for the leaf at pos. Like Octez the tree is padded to the
+// next power of two with copies of the last leaf, so odd levels are padded
+// with the root of a subtree of such copies. The root of an empty tree is the
+// hash of an empty string.
+func merklePath(leaves [][32]byte, pos int) ([32]byte, []MerkleStep) {
+	if len(leaves) == 0 {
+		return tezos.Digest(nil), nil
+	}
+	var path []MerkleStep
+	level := append([][32]byte(nil), leaves...)
+	pad := leaves[len(leaves)-1]
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, pad)
+		}
+		if pos >= 0 {
+			if pos%2 == 0 {
+				path = append(path, MerkleStep{Hash: tezos.HexBytes(level[pos+1][:])})
+			} else {
+				path = append(path, MerkleStep{Hash: tezos.HexBytes(level[pos-1][:]), Left: true})
+			}
+			pos /= 2
+		}
+		next := make([][32]byte, len(level)/2)
+		for i := range next {
+			next[i] = merkleNode(level[2*i], level[2*i+1])
+		}
+		level = next
+		pad = merkleNode(pad, pad)
+	}
+	return level[0], path
+}
+
+// merkleCheck folds a path into a root hash and the leaf position it proves.
+func merkleCheck(h [32]byte, path []MerkleStep) ([32]byte, int, bool) {
+	var pos int
+	for i, step := range path {
+		if len(step.Hash) != 32 {
+			return h, 0, false
+		}
+		var sib [32]byte
+		copy(sib[:], step.Hash)
+		if step.Left {
+			h = merkleNode(sib, h)
+			pos |= 1 << i
+		} else {
+			h = merkleNode(h, sib)
+		}
+	}
+	return h, pos, true
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestOperationProof(t *testing.T) {
+	// operations hash of a block without operations
+	var root tezos.OpListListHash
+	lists := make([][32]byte, 4)
+	for i := range lists {
+		h, _ := merklePath(nil, -1)
+		lists[i] = merkleLeaf(h[:])
+	}
+	root, _ = merklePath(lists, -1)
+	if exp := tezos.MustParseOpListListHash("LLoa7bxRTKaQN2bLYoitYB6bU2DvLnBAqrVjZcvJ364cTcX2PZYKU"); !root.Equal(exp) {
+		t.Fatalf("empty operations hash mismatch: have %s want %s", root, exp)
+	}
+
+	// six operations need padding on two tree levels, the expected root
+	// was computed with an independent port of Octez' Merkle tree
+	ohs := []tezos.OpHash{
+		tezos.MustParseOpHash("onmZTNuXQP5LivDJpEASkA1oYg4AA18YKW8AtqRh8qv8u3JUhBJ"),
+		tezos.MustParseOpHash("ooGusNTLR5BEmuM2suNrxHQDF4KTuezanG97kB2r3xaRiVMZmQn"),
+		tezos.MustParseOpHash("ooMwWU2b53uMg3cjPexf5H6pBkadfmiX2G546mxKNDCrTNVvGGJ"),
+		tezos.MustParseOpHash("ooQuRnwv2Bo1VVPMxmFvUZrDB7t34H3eCty2DAZW2Ps6LLyWoH6"),
+		tezos.MustParseOpHash("ooX1zp3d4Qf74914x9Jm3r4RMbwamyJc2xEPeRfEnKA253dZwmP"),
+		tezos.MustParseOpHash("ooXrSxKx6DkzbXFfFJmKDVPPdbFuvX1gu6i9hC999aroYrnsWFw"),
+	}
+	root = tezos.MustParseOpListListHash("LLobDbg97ottW5CoDvbBPaLNk52k52vond7feyXDVFQjo4Lwv8oZf")
+	chain := newTestChain()
+	chain.add("a1", "", 1, ohs...)
+	srv := nodetest.NewServer(t, nodetest.Routes{"/*": chain})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n, oh := range ohs {
+		proof, err := c.GetOperationProof(context.Background(), BlockAlias("head"), 3, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proof.Verify(oh, root) {
+			t.Errorf("op %d: proof does not verify", n)
+		}
+		if proof.Verify(ohs[(n+1)%len(ohs)], root) {
+			t.Errorf("op %d: proof verifies wrong operation", n)
+		}
+		proof.Position ^= 1
+		if proof.Verify(oh, root) {
+			t.Errorf("op %d: proof verifies wrong position", n)
+		}
+	}
+	if _, err := c.GetOperationProof(context.Background(), BlockAlias("head"), 3, len(ohs)); err == nil {
+		t.Errorf("expected out of range error")
+	}
+}
@@ -17,3 +17,8 @@ type Proposals struct {
 	Period    int                  `json:"period"`
 	Proposals []tezos.ProtocolHash `json:"proposals"`
 }
+
+// GetSource returns the proposing baker.
+func (e Proposals) GetSource() tezos.Address {
+	return e.Source
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"net/url"
+	"strconv"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// Query builds typed and properly encoded RPC query parameters for use
+// with GetWithParams.
+type Query url.Values
+
+func NewQuery() Query {
+	return make(Query)
+}
+
+// With sets a custom query parameter, replacing existing values.
+func (q Query) With(key, val string) Query {
+	url.Values(q).Set(key, val)
+	return q
+}
+
+// Add appends a custom query parameter value. Use this for parameters that
+// may be repeated like delegate or cycle.
+func (q Query) Add(key, val string) Query {
+	url.Values(q).Add(key, val)
+	return q
+}
+
+func (q Query) WithDelegate(addr tezos.Address) Query {
+	return q.With("delegate", addr.String())
+}
+
+func (q Query) WithLevel(height int64) Query {
+	return q.With("level", strconv.FormatInt(height, 10))
+}
+
+func (q Query) WithCycle(cycle int64) Query {
+	return q.With("cycle", strconv.FormatInt(cycle, 10))
+}
+
+func (q Query) WithMaxRound(round int) Query {
+	return q.With("max_round", strconv.Itoa(round))
+}
+
+func (q Query) WithAll() Query {
+	return q.With("all", "true")
+}
+
+// Values returns query parameters as url.Values.
+func (q Query) Values() url.Values {
+	return url.Values(q)
+}
+
+// Encode returns the URL encoded query string.
+func (q Query) Encode() string {
+	return url.Values(q).Encode()
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"blockwatch.cc/tzgo/tezos"
 )
@@ -14,14 +15,31 @@ import (
 var (
 	Canceled    = errors.New("operation confirm canceled")
 	TTLExceeded = errors.New("operation ttl exceeded")
+
+	// ErrNotIncluded is returned when a receipt is requested for an operation
+	// that has not been included in a block yet, e.g. when waiting for mempool
+	// confirmation only.
+	ErrNotIncluded = errors.New("operation not included")
 )
 
 type Receipt struct {
-	Block  tezos.BlockHash
-	Height int64
-	List   int
-	Pos    int
-	Op     *Operation
+	Block    tezos.BlockHash
+	Height   int64
+	List     int
+	Pos      int
+	Op       *Operation
+	Estimate *tezos.Costs // simulated costs and final fee, set by Send
+}
+
+// CostDelta returns the costs estimated from simulation before sending the
+// operation and the actual costs charged on inclusion. Use it to detect
+// estimation drift and tune gas and storage margins. Estimated costs are
+// empty when the receipt was not produced by Send.
+func (r *Receipt) CostDelta() (estimated, actual tezos.Costs) {
+	if r.Estimate != nil {
+		estimated = *r.Estimate
+	}
+	return estimated, r.TotalCosts()
 }
 
 // TotalCosts returns the sum of costs across all batched and internal operations.
@@ -42,6 +60,9 @@ func (r *Receipt) Costs() []tezos.Costs {
 
 // IsSuccess returns true when all operations in this group have been applied successfully.
 func (r *Receipt) IsSuccess() bool {
+	if r.Op == nil {
+		return false
+	}
 	for _, v := range r.Op.Contents {
 		switch v.Result().Status {
 		case tezos.OpStatusApplied:
@@ -58,23 +79,36 @@ func (r *Receipt) IsSuccess() bool {
 
 // Error returns the first execution error found in this operation group or one of
 // its internal results that is of status failed. This helper only exports the error
-// as GenericError. To access error details or all errors, visit
-// r.Op.Contents[].OperationResult.Errors[] and
-// r.Op.Contents[].Metadata.InternalResults.Result.Errors[]
+// as GenericError. Use OperationError for error details and a readable message or
+// visit r.Op.Contents[].OperationResult.Errors[] and
+// r.Op.Contents[].Metadata.InternalResults.Result.Errors[] for all errors.
 func (r *Receipt) Error() error {
+	if e, ok := r.OperationError(); ok {
+		return e.GenericError
+	}
+	return nil
+}
+
+// OperationError returns the first execution error found in this operation group
+// or one of its internal results that is of status failed. The error contains the
+// protocol error id, failing contract and the rejected value.
+func (r *Receipt) OperationError() (OperationError, bool) {
+	if r.Op == nil {
+		return OperationError{}, false
+	}
 	for _, v := range r.Op.Contents {
 		res := v.Result()
 		if len(res.Errors) > 0 && res.Status != tezos.OpStatusApplied {
-			return res.Errors[len(res.Errors)-1].GenericError
+			return traceError(res.Errors), true
 		}
 		for _, vv := range v.Meta().InternalResults {
 			res := vv.Result
 			if len(res.Errors) > 0 && res.Status != tezos.OpStatusApplied {
-				return res.Errors[len(res.Errors)-1].GenericError
+				return traceError(res.Errors), true
 			}
 		}
 	}
-	return nil
+	return OperationError{}, false
 }
 
 // OriginatedContract returns the first contract address deployed by the operation.
@@ -92,10 +126,46 @@ func (r *Receipt) OriginatedContract() (tezos.Address, bool) {
 	return tezos.InvalidAddress, false
 }
 
+// HasAllocation returns true when the operation at index n or one of its
+// internal operations allocates a new account, e.g. a transfer to an empty
+// implicit account. Allocations incur an extra allocation burn, so callers may
+// use this on simulation results to warn users before sending.
+func (r *Receipt) HasAllocation(n int) bool {
+	if r.Op == nil || n < 0 || n >= len(r.Op.Contents) {
+		return false
+	}
+	meta := r.Op.Contents[n].Meta()
+	if meta.Result.Allocated || meta.AllocatedDestination {
+		return true
+	}
+	for _, v := range meta.InternalResults {
+		if v.Result.Allocated {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredConstant returns the global address of the first constant registered
+// by the operation.
+func (r *Receipt) RegisteredConstant() (tezos.ExprHash, bool) {
+	if r.IsSuccess() {
+		for _, contents := range r.Op.Contents {
+			if c, ok := contents.(*ConstantRegistration); ok && c.GlobalAddress().IsValid() {
+				return c.GlobalAddress(), true
+			}
+		}
+	}
+	return tezos.ZeroExprHash, false
+}
+
 // MinLimits returns a list of individual operation costs mapped to limits for use
 // in simulation results. Fee is reset to zero to prevent higher simulation fee from
 // spilling over into real fees paid.
 func (r *Receipt) MinLimits() []tezos.Limits {
+	if r.Op == nil {
+		return nil
+	}
 	lims := make([]tezos.Limits, len(r.Op.Contents))
 	for i, v := range r.Op.Costs() {
 		lims[i].Fee = 0
@@ -105,6 +175,10 @@ func (r *Receipt) MinLimits() []tezos.Limits {
 	return lims
 }
 
+// TenderbakeFinalityDepth is the number of blocks that must be baked on top
+// of a block before it becomes final under Tenderbake consensus.
+const TenderbakeFinalityDepth = 2
+
 type Result struct {
 	oh     tezos.OpHash    // the operation hash to watch
 	block  tezos.BlockHash // the block hash where op was included
@@ -119,6 +193,11 @@ type Result struct {
 	subId  int             // monitor subscription id
 	done   chan struct{}   // channel used to signal completion
 	once   sync.Once       // ensures only one completion state exists
+	mem    bool            // resolve waits on mempool visibility
+	seen   chan struct{}   // channel used to signal mempool or block visibility
+	seenMu sync.Once       // ensures seen is closed only once
+	onConf func(*Result)   // optional callback on each confirmation
+	mu     sync.Mutex      // guards state updated by the observer
 }
 
 func NewResult(oh tezos.OpHash) *Result {
@@ -126,6 +205,7 @@ func NewResult(oh tezos.OpHash) *Result {
 		oh:   oh,
 		wait: 1,
 		done: make(chan struct{}),
+		seen: make(chan struct{}),
 	}
 }
 
@@ -136,16 +216,25 @@ func (r *Result) Hash() tezos.OpHash {
 func (r *Result) Listen(o *Observer) {
 	if o != nil {
 		r.obs = o
-		r.subId = r.obs.Subscribe(r.oh, r.callback)
+		id := r.obs.SubscribeWithReorg(r.oh, r.callback, r.reorg)
+		r.mu.Lock()
+		r.subId = id
+		r.mu.Unlock()
+		if r.mem && o.c != nil {
+			go r.watchMempool(o.c)
+		}
 	}
 }
 
 func (r *Result) Cancel() {
 	r.once.Do(func() {
-		if r.subId > 0 {
-			r.obs.Unsubscribe(r.subId)
-			r.err = Canceled
-			r.subId = 0
+		r.mu.Lock()
+		id := r.subId
+		r.subId = 0
+		r.mu.Unlock()
+		if id > 0 {
+			r.obs.Unsubscribe(id)
+			r.setErr(Canceled)
 		}
 		close(r.done)
 	})
@@ -156,35 +245,110 @@ func (r *Result) WithConfirmations(n int64) *Result {
 	return r
 }
 
+// WithMempoolConfirmation makes Wait and WaitContext return as soon as the
+// operation is seen as applied in the mempool (0-conf). Block confirmations
+// continue to be tracked in the background and signalled via Done.
+func (r *Result) WithMempoolConfirmation() *Result {
+	r.mem = true
+	return r
+}
+
 func (r *Result) WithTTL(n int64) *Result {
 	r.ttl = n
 	return r
 }
 
+// WithCallback registers fn to be called on inclusion and on each subsequent
+// confirmation block. The callback runs on the observer goroutine and must
+// not block.
+func (r *Result) WithCallback(fn func(*Result)) *Result {
+	r.onConf = fn
+	return r
+}
+
+// Block returns the hash and height of the block where the operation was
+// included or a zero hash when not yet included.
+func (r *Result) Block() (tezos.BlockHash, int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.block, r.height
+}
+
+// Confirmations returns the number of blocks seen since the operation was
+// included. The count is reset to zero when the inclusion block gets orphaned
+// by a chain reorganization.
 func (r *Result) Confirmations() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.blocks
 }
 
+// BlocksUntilFinal returns how many more blocks must be baked before the
+// operation is final under Tenderbake. A block is final once two blocks have
+// been baked on top of it. Since Confirmations counts the inclusion block,
+// an operation that is not yet included needs three blocks.
+func (r *Result) BlocksUntilFinal() int {
+	n := TenderbakeFinalityDepth + 1 - int(r.Confirmations())
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// EstimatedTimeUntilFinal returns the expected time until the operation is
+// final based on the minimal block delay of the observed network.
+func (r *Result) EstimatedTimeUntilFinal() time.Duration {
+	delay := tezos.DefaultParams.MinimalBlockDelay
+	if r.obs != nil {
+		if d := r.obs.blockDelay(); d > 0 {
+			delay = d
+		}
+	}
+	return time.Duration(r.BlocksUntilFinal()) * delay
+}
+
 func (r *Result) Done() <-chan struct{} {
 	return r.done
 }
 
+// Seen returns a channel that is closed once the operation was observed
+// either in the mempool or in a block.
+func (r *Result) Seen() <-chan struct{} {
+	return r.seen
+}
+
 func (r *Result) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.err
 }
 
+func (r *Result) setErr(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+}
+
+// GetReceipt fetches the receipt of the included operation. It returns
+// ErrNotIncluded while the operation is not yet part of a block.
 func (r *Result) GetReceipt(ctx context.Context) (*Receipt, error) {
-	if r.err != nil {
-		return nil, r.err
-	}
+	r.mu.Lock()
+	err := r.err
 	rec := &Receipt{
 		Block:  r.block,
 		Height: r.height,
 		Pos:    r.pos,
 		List:   r.list,
 	}
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !rec.Block.IsValid() {
+		return nil, ErrNotIncluded
+	}
 	if r.obs != nil {
-		op, err := r.obs.c.GetBlockOperation(ctx, r.block, r.list, r.pos)
+		op, err := r.obs.c.GetBlockOperation(ctx, rec.Block, rec.List, rec.Pos)
 		if err != nil {
 			return rec, err
 		}
@@ -194,25 +358,112 @@ func (r *Result) GetReceipt(ctx context.Context) (*Receipt, error) {
 }
 
 func (r *Result) Wait() {
-	<-r.done
+	select {
+	case <-r.done:
+	case <-r.mempoolSeen():
+	}
 }
 
 func (r *Result) WaitContext(ctx context.Context) bool {
 	select {
 	case <-ctx.Done():
-		r.err = context.Canceled
+		r.setErr(context.Canceled)
 		return false
 	case <-r.done:
 		return true
+	case <-r.mempoolSeen():
+		return true
+	}
+}
+
+// mempoolSeen returns the seen channel when mempool confirmation is enabled
+// and a nil channel that blocks forever otherwise.
+func (r *Result) mempoolSeen() <-chan struct{} {
+	if r.mem {
+		return r.seen
 	}
+	return nil
+}
+
+func (r *Result) markSeen() {
+	r.seenMu.Do(func() {
+		close(r.seen)
+	})
+}
+
+// mempoolValidatedPath streams only operations the node has validated. Refused,
+// outdated and branch delayed/refused operations will never be included and
+// must not count as mempool confirmation.
+const mempoolValidatedPath = "chains/main/mempool/monitor_operations?validated=true&branch_delayed=false&branch_refused=false&refused=false&outdated=false"
+
+// watchMempool streams validated mempool operations until the watched operation
+// is found or the result completes. Monitor streams are reset by the node on
+// every new head, so the stream is reopened after each close.
+func (r *Result) watchMempool(c *Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.done:
+		case <-r.seen:
+		}
+		cancel()
+	}()
+	for {
+		mon := NewMempoolMonitor()
+		if err := c.GetAsync(ctx, mempoolValidatedPath, mon); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.Log.Debugf("mempool: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for {
+			ops, err := mon.Recv(ctx)
+			if err != nil {
+				break
+			}
+			for _, op := range ops {
+				if op.Hash.Equal(r.oh) {
+					c.Log.Debugf("mempool: seen %s", r.oh)
+					r.markSeen()
+					return
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// reorg resets the inclusion state when the inclusion block was orphaned.
+// The observer reports the operation again once it is included in another block.
+func (r *Result) reorg(_ *BlockHeaderLogEntry, _ int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.block = tezos.ZeroBlockHash
+	r.height = 0
+	r.list = 0
+	r.pos = 0
+	r.blocks = 0
 }
 
 func (r *Result) callback(block *BlockHeaderLogEntry, height int64, list, pos int, force bool) bool {
+	r.mu.Lock()
 	if force {
 		r.block = block.Hash
 		r.height = height
 		r.list = list
 		r.pos = pos
+		r.mu.Unlock()
 		return false
 	}
 	if !r.block.IsValid() {
@@ -220,19 +471,30 @@ func (r *Result) callback(block *BlockHeaderLogEntry, height int64, list, pos in
 		r.height = height
 		r.list = list
 		r.pos = pos
+		r.markSeen()
 	}
 	r.blocks++
-	if r.ttl > 0 && r.blocks >= r.ttl {
+	blocks := r.blocks
+	r.mu.Unlock()
+
+	if r.onConf != nil {
+		r.onConf(r)
+	}
+	if r.ttl > 0 && blocks >= r.ttl {
 		r.once.Do(func() {
+			r.mu.Lock()
 			r.err = TTLExceeded
 			r.subId = 0
+			r.mu.Unlock()
 			close(r.done)
 		})
 		return true
 	}
-	if r.blocks >= r.wait {
+	if blocks >= r.wait {
 		r.once.Do(func() {
+			r.mu.Lock()
 			r.subId = 0
+			r.mu.Unlock()
 			close(r.done)
 		})
 		return true
@@ -0,0 +1,742 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestReceiptRegisteredConstant(t *testing.T) {
+	const data = `{
+		"protocol": "PtMumbai2TmsJHNGRkD8v8YDbtao7BLUC3wjASn1inAKLFCjaH1",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "register_global_constant",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"fee": "372",
+			"counter": "1",
+			"gas_limit": "1330",
+			"storage_limit": "94",
+			"value": {"int": "42"},
+			"metadata": {
+				"operation_result": {
+					"status": "applied",
+					"consumed_milligas": "1229000",
+					"storage_size": "74",
+					"global_address": "exprvD1v8DxXvrsCqbx7BA2ZqxYuUk9jXE1QrXuL46i3MWG6o1szUq"
+				}
+			}
+		}]
+	}`
+	// operation list decoding expects node-style compact JSON
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := op.Contents[0].(*ConstantRegistration)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	want := tezos.MustParseExprHash("exprvD1v8DxXvrsCqbx7BA2ZqxYuUk9jXE1QrXuL46i3MWG6o1szUq")
+	if !c.GlobalAddress().Equal(want) {
+		t.Errorf("global address mismatch: have %s want %s", c.GlobalAddress(), want)
+	}
+	if c.StorageSize() != 74 {
+		t.Errorf("storage size mismatch: have %d want 74", c.StorageSize())
+	}
+	rcpt := &Receipt{Op: &op}
+	if h, ok := rcpt.RegisteredConstant(); !ok || !h.Equal(want) {
+		t.Errorf("receipt constant mismatch: have %s/%t want %s", h, ok, want)
+	}
+}
+
+func TestReceiptOperationError(t *testing.T) {
+	const data = `[{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"1000","counter":"2","gas_limit":"10000","storage_limit":"0","amount":"0","destination":"KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton","metadata":{"operation_result":{"status":"failed","errors":[{"kind":"temporary","id":"proto.018-Proxford.michelson_v1.runtime_error","contract_handle":"KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton","contract_code":"Deprecated"},{"kind":"temporary","id":"proto.018-Proxford.michelson_v1.script_rejected","location":42,"with":{"string":"FA2_INSUFFICIENT_BALANCE"}}]}}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	rcpt := &Receipt{Op: &Operation{Contents: list}}
+
+	// Error keeps returning the last error as GenericError
+	ge, ok := rcpt.Error().(GenericError)
+	if !ok {
+		t.Fatalf("unexpected error type %T", rcpt.Error())
+	}
+	if have, want := ge.ID, "proto.018-Proxford.michelson_v1.script_rejected"; have != want {
+		t.Errorf("error id mismatch: have %s want %s", have, want)
+	}
+
+	oe, ok := rcpt.OperationError()
+	if !ok {
+		t.Fatal("expected operation error")
+	}
+	if have, want := oe.ShortID(), "michelson_v1.script_rejected"; have != want {
+		t.Errorf("short id mismatch: have %s want %s", have, want)
+	}
+	msg := oe.Error()
+	for _, want := range []string{
+		"proto.018-Proxford.michelson_v1.script_rejected",
+		"location=42",
+		"contract=KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton",
+		"FA2_INSUFFICIENT_BALANCE",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q does not contain %q", msg, want)
+		}
+	}
+	if len(oe.Raw) == 0 {
+		t.Errorf("missing raw error data")
+	}
+}
+
+func TestReceiptHasAllocation(t *testing.T) {
+	const data = `[{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"1000","counter":"2","gas_limit":"10000","storage_limit":"257","amount":"1","destination":"tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw","metadata":{"operation_result":{"status":"applied","allocated_destination_contract":true}}},{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"1000","counter":"3","gas_limit":"10000","storage_limit":"0","amount":"1","destination":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","metadata":{"operation_result":{"status":"applied"}}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	rcpt := &Receipt{Op: &Operation{Contents: list}}
+	for i, want := range []bool{true, false, false} {
+		if have := rcpt.HasAllocation(i); have != want {
+			t.Errorf("op %d: have %t want %t", i, have, want)
+		}
+	}
+}
+
+func TestReceiptWithoutOperation(t *testing.T) {
+	rcpt := &Receipt{}
+	if rcpt.IsSuccess() {
+		t.Errorf("empty receipt reports success")
+	}
+	if err := rcpt.Error(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, ok := rcpt.OriginatedContract(); ok {
+		t.Errorf("empty receipt reports originated contract")
+	}
+	if _, ok := rcpt.RegisteredConstant(); ok {
+		t.Errorf("empty receipt reports registered constant")
+	}
+	if lims := rcpt.MinLimits(); lims != nil {
+		t.Errorf("unexpected limits %v", lims)
+	}
+}
+
+func TestSeedNonceRevelationReceipt(t *testing.T) {
+	const data = `{
+		"protocol": "PtMumbai2TmsJHNGRkD8v8YDbtao7BLUC3wjASn1inAKLFCjaH1",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "seed_nonce_revelation",
+			"level": 4096,
+			"nonce": "abababababababababababababababababababababababababababababababab",
+			"metadata": {
+				"balance_updates": [
+					{"kind": "minted", "category": "nonce revelation rewards", "change": "-156250", "origin": "block"},
+					{"kind": "contract", "contract": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "change": "156250", "origin": "block"}
+				]
+			}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	rev, ok := op.Contents[0].(*SeedNonceRevelation)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	if rev.Level != 4096 || len(rev.Nonce) != 32 {
+		t.Errorf("unexpected revelation level=%d nonce=%s", rev.Level, rev.Nonce)
+	}
+	if rev.Reward() != 156250 {
+		t.Errorf("reward mismatch: have %d want 156250", rev.Reward())
+	}
+	if want := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"); !rev.Baker().Equal(want) {
+		t.Errorf("baker mismatch: have %s want %s", rev.Baker(), want)
+	}
+}
+
+func TestVotingReceipts(t *testing.T) {
+	const data = `{
+		"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "ballot",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"period": 95,
+			"proposal": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+			"ballot": "nay",
+			"metadata": {}
+		},{
+			"kind": "proposals",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"period": 94,
+			"proposals": ["PtNairobiyssHuh87hEhfVBGCVrK3WnS8Z2FT4ymB5tAa4r1nQf"],
+			"metadata": {}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	b, ok := op.Contents[0].(*Ballot)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	if b.Ballot != tezos.BallotVoteNay || b.Period != 95 || !b.Proposal.Equal(tezos.ProtoV018) {
+		t.Errorf("unexpected ballot %#v", b)
+	}
+	p, ok := op.Contents[1].(*Proposals)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[1])
+	}
+	if p.Period != 94 || len(p.Proposals) != 1 || !p.Proposals[0].Equal(tezos.PtNairobi) {
+		t.Errorf("unexpected proposals %#v", p)
+	}
+}
+
+func TestReceiptCostDelta(t *testing.T) {
+	const data = `[{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"1200","counter":"2","gas_limit":"10000","storage_limit":"257","amount":"1","destination":"tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw","metadata":{"operation_result":{"status":"applied","consumed_milligas":"2100000"}}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	rcpt := &Receipt{Op: &Operation{Contents: list}}
+	if est, _ := rcpt.CostDelta(); est != (tezos.Costs{}) {
+		t.Errorf("expected empty estimate, got %#v", est)
+	}
+	rcpt.Estimate = &tezos.Costs{Fee: 1200, GasUsed: 2000}
+	est, act := rcpt.CostDelta()
+	if est.Fee != 1200 || est.GasUsed != 2000 {
+		t.Errorf("unexpected estimate %#v", est)
+	}
+	if act.Fee != 1200 || act.GasUsed != 2100 {
+		t.Errorf("unexpected actual costs %#v", act)
+	}
+}
+
+func TestBalanceUpdateOrigin(t *testing.T) {
+	const data = `[{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"1200","counter":"2","gas_limit":"10000","storage_limit":"257","amount":"1000","destination":"tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw","metadata":{"operation_result":{"status":"applied","consumed_milligas":"2100000","allocated_destination_contract":true,"balance_updates":[` +
+		`{"kind":"contract","contract":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","change":"-1000","origin":"block"},` +
+		`{"kind":"contract","contract":"tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw","change":"1000","origin":"block"},` +
+		`{"kind":"contract","contract":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","change":"-500000","origin":"simulation"},` +
+		`{"kind":"contract","contract":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","change":"-64250","origin":"block"},` +
+		`{"kind":"burned","category":"storage fees","change":"64250","origin":"block"},` +
+		`{"kind":"minted","category":"migration","change":"-7","origin":"migration"}` +
+		`]}}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	tx := list[0].(*Transaction)
+	upd := tx.Metadata.Result.BalanceUpdates
+	for _, v := range []struct {
+		origin BalanceOrigin
+		n      int
+	}{
+		{OriginBlock, 4},
+		{OriginSimulation, 1},
+		{OriginMigration, 1},
+		{OriginSubsidy, 0},
+	} {
+		if got := upd.ByOrigin(v.origin); len(got) != v.n {
+			t.Errorf("%s: want %d updates, got %d", v.origin, v.n, len(got))
+		}
+	}
+	if !upd[2].IsSimulation() || upd[2].Origin != "simulation" {
+		t.Errorf("expected simulated update, got %#v", upd[2])
+	}
+
+	// simulated updates must not count as burn
+	cost := tx.Costs()
+	if cost.AllocationBurn != 64250 || cost.Burn != 64250 {
+		t.Errorf("unexpected costs %#v", cost)
+	}
+}
+
+func TestOperationResultGas(t *testing.T) {
+	for _, c := range []struct {
+		data     string
+		gas      int64
+		milligas int64
+	}{
+		{`{"status":"applied","consumed_gas":"1421"}`, 1421, 1421000},
+		{`{"status":"applied","consumed_milligas":"1420040"}`, 1421, 1420040},
+		{`{"status":"applied","consumed_gas":"1420","consumed_milligas":"1420000"}`, 1420, 1420000},
+		{`{"status":"applied"}`, 0, 0},
+	} {
+		var res OperationResult
+		if err := json.Unmarshal([]byte(c.data), &res); err != nil {
+			t.Fatal(err)
+		}
+		if g := res.Gas(); g != c.gas {
+			t.Errorf("%s: gas have %d want %d", c.data, g, c.gas)
+		}
+		if m := res.MilliGas(); m != c.milligas {
+			t.Errorf("%s: milligas have %d want %d", c.data, m, c.milligas)
+		}
+	}
+	var d Delegation
+	d.Metadata.Result.ConsumedMilliGas = 1000001
+	if g := d.Costs().GasUsed; g != 1001 {
+		t.Errorf("delegation gas have %d want 1001", g)
+	}
+}
+
+func TestResultCallback(t *testing.T) {
+	var (
+		head   = tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")
+		counts []int64
+	)
+	res := NewResult(tezos.ZeroOpHash).WithConfirmations(2).WithCallback(func(r *Result) {
+		counts = append(counts, r.Confirmations())
+		if block, height := r.Block(); !block.Equal(head) || height != 100 {
+			t.Errorf("unexpected inclusion block %s/%d", block, height)
+		}
+	})
+	if res.callback(&BlockHeaderLogEntry{Hash: head}, 100, 3, 0, false) {
+		t.Errorf("expected result to wait for more confirmations")
+	}
+	if !res.callback(&BlockHeaderLogEntry{Hash: testHash("next")}, 101, 3, 0, false) {
+		t.Errorf("expected result to complete")
+	}
+	if len(counts) != 2 || counts[0] != 1 || counts[1] != 2 {
+		t.Errorf("unexpected confirmation updates %v", counts)
+	}
+	if SendStatusBroadcast.String() != "broadcast" {
+		t.Errorf("unexpected status name %q", SendStatusBroadcast)
+	}
+}
+
+func TestResultBlocksUntilFinal(t *testing.T) {
+	res := NewResult(tezos.ZeroOpHash).WithConfirmations(10)
+	res.obs = &Observer{minDelay: 8 * time.Second}
+	for i, want := range []int{3, 2, 1, 0, 0} {
+		if have := res.BlocksUntilFinal(); have != want {
+			t.Errorf("%d confirmations: have %d blocks until final, want %d", i, have, want)
+		}
+		if have, want := res.EstimatedTimeUntilFinal(), time.Duration(want)*8*time.Second; have != want {
+			t.Errorf("%d confirmations: have %s until final, want %s", i, have, want)
+		}
+		res.callback(&BlockHeaderLogEntry{Hash: testHash("block")}, int64(100+i), 3, 0, false)
+	}
+}
+
+func TestResultBlocksUntilFinalConcurrent(t *testing.T) {
+	res := NewResult(tezos.ZeroOpHash).WithConfirmations(100)
+	res.obs = NewObserver()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = res.BlocksUntilFinal()
+				_ = res.EstimatedTimeUntilFinal()
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		res.obs.WithDelay(time.Duration(i) * time.Second)
+		res.callback(&BlockHeaderLogEntry{Hash: testHash("block")}, int64(100+i), 3, 0, false)
+	}
+	close(stop)
+	<-done
+	if res.BlocksUntilFinal() != 0 {
+		t.Errorf("expected final result")
+	}
+}
+
+func TestInternalResultAsTransaction(t *testing.T) {
+	const data = `{"kind":"transaction","source":"KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T","nonce":1,"amount":"100","destination":"tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw","parameters":{"entrypoint":"default","value":{"prim":"Unit"}},"result":{"status":"applied","consumed_milligas":"2100000"}}`
+	var in InternalResult
+	if err := json.Unmarshal([]byte(data), &in); err != nil {
+		t.Fatal(err)
+	}
+	tx := in.AsTransaction()
+	if tx == nil {
+		t.Fatal("expected transaction")
+	}
+	if tx.Kind() != tezos.OpTypeTransaction || !tx.Source.Equal(in.Source) || !tx.Destination.Equal(*in.Destination) {
+		t.Errorf("unexpected transaction %#v", tx)
+	}
+	if tx.Amount != 100 || tx.Parameters == nil || tx.Parameters.Entrypoint != "default" {
+		t.Errorf("unexpected amount or params %#v", tx)
+	}
+	if !tx.Result().IsSuccess() || tx.Costs().GasUsed != 2100 || tx.Costs().Fee != 0 {
+		t.Errorf("unexpected result or costs %#v", tx.Costs())
+	}
+	in.Kind = tezos.OpTypeDelegation
+	if in.AsTransaction() != nil {
+		t.Errorf("expected nil for non-transaction")
+	}
+}
+
+func TestActivationReceipt(t *testing.T) {
+	pkh := tezos.MustParseAddress("tz1T1rRqmAk4XtGadNJuNpq8dUdWqLv2Gtq4")
+	var secret tezos.HexBytes
+	if err := secret.UnmarshalText([]byte("06da1e038224114366831e47aee7f128f4675311")); err != nil {
+		t.Fatal(err)
+	}
+	blinded, err := tezos.BlindAddress(pkh, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `[{"kind":"activate_account","pkh":"` + pkh.String() + `","secret":"` + secret.String() + `","metadata":{"balance_updates":[` +
+		`{"kind":"commitment","committer":"` + blinded.String() + `","change":"-4000000","origin":"block"},` +
+		`{"kind":"contract","contract":"` + pkh.String() + `","change":"4000000","origin":"block"}]}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	act, ok := list[0].(*ActivateAccount)
+	if !ok {
+		t.Fatalf("unexpected type %T", list[0])
+	}
+	if act.Amount() != 4000000 {
+		t.Errorf("unexpected amount %d", act.Amount())
+	}
+	if !act.Commitment().Equal(blinded) {
+		t.Errorf("unexpected commitment %s", act.Commitment())
+	}
+	if !act.Verify() {
+		t.Errorf("expected activation to verify")
+	}
+	act.Secret[0] ^= 0xff
+	if act.Verify() {
+		t.Errorf("expected verification to fail with wrong secret")
+	}
+	if tezos.VerifyBlindedAddress(blinded, pkh, secret[:10]) {
+		t.Errorf("expected verification to fail with short secret")
+	}
+}
+
+func TestPreendorsementReceipt(t *testing.T) {
+	delegate := "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"
+	data := `[{"kind":"preendorsement","slot":3,"level":100,"round":1,` +
+		`"block_payload_hash":"vh2TyrWeZ2dydEy9ZjmvrjQvyCs5sdHZPypcZrXDUSM1tNuPermf",` +
+		`"metadata":{"delegate":"` + delegate + `","preendorsement_power":12}},` +
+		`{"kind":"endorsement","slot":4,"level":100,"round":1,` +
+		`"block_payload_hash":"vh2TyrWeZ2dydEy9ZjmvrjQvyCs5sdHZPypcZrXDUSM1tNuPermf",` +
+		`"metadata":{"delegate":"` + delegate + `","endorsement_power":7}}]`
+	var list OperationList
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	pre, ok := list[0].(*Preendorsement)
+	if !ok {
+		t.Fatalf("unexpected type %T", list[0])
+	}
+	if !pre.IsPreendorsement() || pre.Power() != 12 || pre.Slot != 3 || pre.Round != 1 {
+		t.Errorf("unexpected preendorsement %#v", pre)
+	}
+	end := list[1].(*Endorsement)
+	if end.IsPreendorsement() || end.Power() != 7 {
+		t.Errorf("unexpected endorsement %#v", end)
+	}
+}
+
+func TestTypedOperationSourceAndFee(t *testing.T) {
+	const data = `{
+		"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "transaction",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"fee": "404",
+			"counter": "1",
+			"gas_limit": "1101",
+			"storage_limit": "0",
+			"amount": "1000",
+			"destination": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw",
+			"metadata": {"operation_result": {"status": "applied", "consumed_milligas": "1000000"}}
+		},{
+			"kind": "endorsement",
+			"slot": 1,
+			"level": 5000000,
+			"round": 0,
+			"block_payload_hash": "vh2TyrWeZ2dydEy9ZjmvrjQvyCs5sdHZPypcZrXDUSM1tNuPermf",
+			"metadata": {"delegate": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw", "endorsement_power": 10}
+		},{
+			"kind": "ballot",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"period": 95,
+			"proposal": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+			"ballot": "yay",
+			"metadata": {}
+		},{
+			"kind": "seed_nonce_revelation",
+			"level": 5000000,
+			"nonce": "0000000000000000000000000000000000000000000000000000000000000000",
+			"metadata": {}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	alice := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	bob := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	for i, want := range []struct {
+		src tezos.Address
+		fee int64
+	}{
+		{alice, 404},
+		{bob, 0},
+		{alice, 0},
+		{tezos.InvalidAddress, 0},
+	} {
+		o := op.Contents[i]
+		if have := o.GetSource(); !have.Equal(want.src) {
+			t.Errorf("%s source: have %s want %s", o.Kind(), have, want.src)
+		}
+		if have := o.GetFee(); have != want.fee {
+			t.Errorf("%s fee: have %d want %d", o.Kind(), have, want.fee)
+		}
+	}
+}
+
+func TestDoubleBakingEvidenceReceipt(t *testing.T) {
+	const data = `{
+		"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "double_baking_evidence",
+			"bh1": {
+				"level": 5000000,
+				"proto": 18,
+				"fitness": ["02", "004c4b40", "", "ffffffff", "00000001"],
+				"payload_hash": "vh2TyrWeZ2dydEy9ZjmvrjQvyCs5sdHZPypcZrXDUSM1tNuPermf",
+				"payload_round": 1
+			},
+			"bh2": {
+				"level": 5000000,
+				"proto": 18,
+				"fitness": ["02", "004c4b40", "", "ffffffff", "00000001"],
+				"payload_hash": "vh2nZrxixzv4ZjAJn7PRj79GumUMAJzxuEYMjo496TYSaWhXYjZM",
+				"payload_round": 1
+			},
+			"metadata": {
+				"forbidden_delegate": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw",
+				"balance_updates": [
+					{"kind": "freezer", "category": "deposits", "staker": {"baker": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"}, "change": "-1000", "origin": "block"},
+					{"kind": "burned", "category": "punishments", "change": "900", "origin": "block"},
+					{"kind": "contract", "contract": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "change": "100", "origin": "block"}
+				]
+			}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := op.Contents[0].(*DoubleBakingEvidence)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	if d.Level() != 5000000 {
+		t.Errorf("level: have %d want 5000000", d.Level())
+	}
+	if r1, r2 := d.Rounds(); r1 != 1 || r2 != 1 {
+		t.Errorf("rounds: have %d/%d want 1/1", r1, r2)
+	}
+	bob := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	if have := d.Offender(); !have.Equal(bob) {
+		t.Errorf("offender: have %s want %s", have, bob)
+	}
+	d.Metadata.ForbiddenDelegate = tezos.InvalidAddress
+	if have := d.Offender(); !have.Equal(bob) {
+		t.Errorf("offender from deposits: have %s want %s", have, bob)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	// headers must conflict
+	d2 := *d
+	d2.BH2.Level++
+	if err := d2.Validate(); err == nil {
+		t.Errorf("expected level mismatch error")
+	}
+	d2 = *d
+	d2.BH2.PayloadHash = d.BH1.PayloadHash
+	if err := d2.Validate(); err == nil {
+		t.Errorf("expected same payload error")
+	}
+}
+
+func TestSmartRollupRefuteProof(t *testing.T) {
+	const data = `{
+		"protocol": "PtNairobiyssHuh87hEhfVBGCVrK3WnS8Z2FT4ymB5tAa4r1nQf",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "smart_rollup_refute",
+			"source": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"fee": "1000",
+			"counter": "1",
+			"gas_limit": "10000",
+			"storage_limit": "0",
+			"rollup": "sr1Fq8fPi2NjhWUXtcXBggbL6zFjZctGkmso",
+			"opponent": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw",
+			"refutation": {
+				"refutation_kind": "move",
+				"choice": "1000",
+				"step": {
+					"pvm_step": "010203",
+					"input_proof": {
+						"input_proof_kind": "reveal_proof",
+						"reveal_proof": {"reveal_proof_kind": "raw_data_proof", "raw_data": "cafe"}
+					}
+				}
+			},
+			"metadata": {"operation_result": {"status": "applied", "consumed_milligas": "1000"}}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := op.Contents[0].(*SmartRollupRefute)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	proof := r.Refutation.Step.Proof
+	if proof == nil || proof.Kind() != "reveal_proof" {
+		t.Fatalf("unexpected proof %#v", proof)
+	}
+	if rp := proof.InputProof.RevealProof; rp == nil || rp.RawData.String() != "cafe" {
+		t.Errorf("reveal proof data lost: %#v", rp)
+	}
+	js, err := json.Marshal(r.Refutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"raw_data":"cafe"`) || !strings.Contains(string(js), `"pvm_step":"010203"`) {
+		t.Errorf("proof data lost on marshal: %s", js)
+	}
+}
+
+func TestOperationResultStorageBurn(t *testing.T) {
+	// the storage fee burn is listed before the allocation burn here, so
+	// positional parsing would mix both up
+	res := OperationResult{
+		Status:              tezos.OpStatusApplied,
+		PaidStorageSizeDiff: 100,
+		Allocated:           true,
+		BalanceUpdates: BalanceUpdates{
+			{Kind: CONTRACT, Change: -89250, Origin: "block"},
+			{Kind: "burned", Category: "storage fees", Change: 64250, Origin: "block"},
+			{Kind: "burned", Category: "storage fees", Change: 25000, Origin: "block"},
+		},
+	}
+	if have, want := res.StorageBurn(nil), tezos.NewZ(25000); !have.Equal(want) {
+		t.Errorf("storage burn mismatch: have %s want %s", have, want)
+	}
+	p := tezos.DefaultParams.Clone()
+	p.CostPerByte = 100
+	if have, want := res.StorageBurn(p), tezos.NewZ(10000); !have.Equal(want) {
+		t.Errorf("storage burn mismatch: have %s want %s", have, want)
+	}
+}
+
+func TestDrainDelegateReceipt(t *testing.T) {
+	const data = `{
+		"protocol": "ProxfordYmVfjWnRcgjWH36fW6PArwqykTFzotUxRs6gmTcZDuH",
+		"chain_id": "NetXdQprcVkpaWU",
+		"branch": "BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm",
+		"contents": [{
+			"kind": "drain_delegate",
+			"consensus_key": "tz1ci1ARnm8JoYV16Hbe4FoxX17yFEAQVytg",
+			"delegate": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+			"destination": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw",
+			"metadata": {
+				"balance_updates": [
+					{"kind": "contract", "contract": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "change": "-64250", "origin": "block"},
+					{"kind": "burned", "category": "storage fees", "change": "64250", "origin": "block"},
+					{"kind": "contract", "contract": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "change": "-15525181565", "origin": "block"},
+					{"kind": "contract", "contract": "tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw", "change": "15525181565", "origin": "block"},
+					{"kind": "contract", "contract": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "change": "-156821026", "origin": "block"},
+					{"kind": "contract", "contract": "tz1eZUHkQDC1bBEbvrrUxkbWEagdZJXQyszc", "change": "156821026", "origin": "block"}
+				],
+				"allocated_destination_contract": true
+			}
+		}]
+	}`
+	buf := bytes.NewBuffer(nil)
+	if err := json.Compact(buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var op Operation
+	if err := json.Unmarshal(buf.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := op.Contents[0].(*DrainDelegate)
+	if !ok {
+		t.Fatalf("unexpected type %T", op.Contents[0])
+	}
+	if have, want := d.GetSource(), tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"); !have.Equal(want) {
+		t.Errorf("source mismatch: have %s want %s", have, want)
+	}
+	if have, want := d.ConsensusKey, tezos.MustParseAddress("tz1ci1ARnm8JoYV16Hbe4FoxX17yFEAQVytg"); !have.Equal(want) {
+		t.Errorf("consensus key mismatch: have %s want %s", have, want)
+	}
+	if have, want := d.Amount(), int64(15525181565); have != want {
+		t.Errorf("amount mismatch: have %d want %d", have, want)
+	}
+	if have, want := d.GetFee(), int64(156821026); have != want {
+		t.Errorf("fee mismatch: have %d want %d", have, want)
+	}
+	cost := d.Costs()
+	if cost.Fee != 156821026 || cost.AllocationBurn != 64250 || cost.Burn != 64250 {
+		t.Errorf("unexpected costs %#v", cost)
+	}
+
+	// without allocation nothing is burned
+	d.Metadata.AllocatedDestination = false
+	d.Metadata.BalanceUpdates = d.Metadata.BalanceUpdates[2:]
+	if cost := d.Costs(); cost.Fee != 156821026 || cost.Burn != 0 || cost.AllocationBurn != 0 {
+		t.Errorf("unexpected costs without allocation %#v", cost)
+	}
+}
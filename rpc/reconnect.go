@@ -0,0 +1,295 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = time.Minute
+)
+
+// ReconnectEvent is emitted before a monitor is re-established. Attempt counts
+// consecutive failures and is zero when the stream ended regularly, e.g. when
+// a mempool monitor is closed by the node on a new head. Err is the error that
+// terminated the previous connection or connection attempt.
+type ReconnectEvent struct {
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+// MonitorConnector creates a new monitor and connects it to a stream.
+type MonitorConnector func(context.Context) (Monitor, error)
+
+// MonitorReconnector wraps a monitor and transparently re-establishes its
+// stream when it ends or fails. Failed connections are retried with
+// exponential backoff between MinBackoff and MaxBackoff. When MaxRetries is
+// non-zero the last error is returned after as many consecutive failures.
+// OnReconnect, when set, is called before each reconnect.
+//
+// Use NewMempoolReconnector and NewBlockHeaderReconnector for typed access
+// to the most common monitors.
+type MonitorReconnector struct {
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	MaxRetries  int
+	OnReconnect func(ReconnectEvent)
+
+	connect MonitorConnector
+	mu      sync.Mutex
+	mon     Monitor
+	closed  chan struct{}
+	attempt int
+	err     error
+}
+
+// NewMonitorReconnector returns a reconnector that calls connect whenever a
+// new monitor is required.
+func NewMonitorReconnector(connect MonitorConnector) *MonitorReconnector {
+	return &MonitorReconnector{
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+		connect:    connect,
+		closed:     make(chan struct{}),
+	}
+}
+
+func (r *MonitorReconnector) WithBackoff(min, max time.Duration) *MonitorReconnector {
+	r.MinBackoff = min
+	r.MaxBackoff = max
+	return r
+}
+
+func (r *MonitorReconnector) WithMaxRetries(n int) *MonitorReconnector {
+	r.MaxRetries = n
+	return r
+}
+
+func (r *MonitorReconnector) WithCallback(fn func(ReconnectEvent)) *MonitorReconnector {
+	r.OnReconnect = fn
+	return r
+}
+
+// Monitor returns the current monitor and connects a new one when required.
+func (r *MonitorReconnector) Monitor(ctx context.Context) (Monitor, error) {
+	for {
+		r.mu.Lock()
+		mon, attempt, lastErr := r.mon, r.attempt, r.err
+		r.mu.Unlock()
+		if mon != nil {
+			return mon, nil
+		}
+		select {
+		case <-r.closed:
+			return nil, ErrMonitorClosed
+		default:
+		}
+
+		// reconnect after a previous connection has ended or failed
+		if lastErr != nil {
+			if r.MaxRetries > 0 && attempt > r.MaxRetries {
+				return nil, lastErr
+			}
+			delay := r.backoff(attempt)
+			if r.OnReconnect != nil {
+				r.OnReconnect(ReconnectEvent{
+					Attempt: attempt,
+					Delay:   delay,
+					Err:     lastErr,
+				})
+			}
+			if delay > 0 {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-r.closed:
+					return nil, ErrMonitorClosed
+				case <-time.After(delay):
+				}
+			}
+		}
+
+		mon, err := r.connect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.mu.Lock()
+			r.attempt++
+			r.err = err
+			r.mu.Unlock()
+			continue
+		}
+
+		r.mu.Lock()
+		select {
+		case <-r.closed:
+			r.mu.Unlock()
+			mon.Close()
+			return nil, ErrMonitorClosed
+		default:
+		}
+		r.mon = mon
+		r.mu.Unlock()
+	}
+}
+
+// Fail closes the current monitor after a receive error so that the next call
+// to Monitor reconnects. Regular stream ends (io.EOF) reconnect immediately,
+// other errors count as failed attempt and reconnect with backoff.
+func (r *MonitorReconnector) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mon != nil {
+		r.mon.Close()
+		r.mon = nil
+	}
+	r.err = err
+	if err != io.EOF {
+		r.attempt++
+	}
+}
+
+// Reset clears the failure counter after data was successfully received.
+func (r *MonitorReconnector) Reset() {
+	r.mu.Lock()
+	r.attempt = 0
+	r.mu.Unlock()
+}
+
+// Close closes the current monitor and stops reconnecting.
+func (r *MonitorReconnector) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.closed:
+		return
+	default:
+	}
+	close(r.closed)
+	if r.mon != nil {
+		r.mon.Close()
+		r.mon = nil
+	}
+}
+
+func (r *MonitorReconnector) backoff(attempt int) time.Duration {
+	if attempt <= 0 {
+		return 0
+	}
+	d := r.MinBackoff
+	for i := 1; i < attempt && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	return d
+}
+
+// recv receives the next message using fn and reconnects on error.
+func (r *MonitorReconnector) recv(ctx context.Context, fn func(Monitor) error) error {
+	for {
+		mon, err := r.Monitor(ctx)
+		if err != nil {
+			return err
+		}
+		err = fn(mon)
+		if err == nil {
+			r.Reset()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		select {
+		case <-r.closed:
+			return ErrMonitorClosed
+		default:
+		}
+		r.Fail(err)
+	}
+}
+
+// MempoolReconnector receives mempool operations and reconnects the mempool
+// monitor whenever the node closes the stream, e.g. on each new head.
+type MempoolReconnector struct {
+	*MonitorReconnector
+	cursor *SeenCursor
+}
+
+func NewMempoolReconnector(c *Client) *MempoolReconnector {
+	cursor := NewSeenCursor(0)
+	return &MempoolReconnector{
+		MonitorReconnector: NewMonitorReconnector(func(ctx context.Context) (Monitor, error) {
+			mon := NewMempoolMonitor().WithCursor(cursor)
+			if err := c.MonitorMempool(ctx, mon); err != nil {
+				mon.Close()
+				return nil, err
+			}
+			return mon, nil
+		}),
+		cursor: cursor,
+	}
+}
+
+// Cursor returns the cursor of delivered operations which is shared by all
+// monitors this reconnector creates.
+func (r *MempoolReconnector) Cursor() *SeenCursor {
+	return r.cursor
+}
+
+func (r *MempoolReconnector) Recv(ctx context.Context) ([]*Operation, error) {
+	var ops []*Operation
+	err := r.recv(ctx, func(mon Monitor) (err error) {
+		ops, err = mon.(*MempoolMonitor).Recv(ctx)
+		return
+	})
+	return ops, err
+}
+
+// RecvNew is like Recv, but skips operations that were already delivered
+// before, e.g. when the node re-sends pending operations after a reconnect.
+func (r *MempoolReconnector) RecvNew(ctx context.Context) ([]*Operation, error) {
+	var ops []*Operation
+	err := r.recv(ctx, func(mon Monitor) (err error) {
+		ops, err = mon.(*MempoolMonitor).RecvNew(ctx)
+		return
+	})
+	return ops, err
+}
+
+// BlockHeaderReconnector receives new block headers and reconnects the head
+// monitor when the stream ends or fails.
+type BlockHeaderReconnector struct {
+	*MonitorReconnector
+}
+
+func NewBlockHeaderReconnector(c *Client) *BlockHeaderReconnector {
+	return &BlockHeaderReconnector{
+		NewMonitorReconnector(func(ctx context.Context) (Monitor, error) {
+			mon := NewBlockHeaderMonitor()
+			if err := c.MonitorBlockHeader(ctx, mon); err != nil {
+				mon.Close()
+				return nil, err
+			}
+			return mon, nil
+		}),
+	}
+}
+
+func (r *BlockHeaderReconnector) Recv(ctx context.Context) (*BlockHeaderLogEntry, error) {
+	var head *BlockHeaderLogEntry
+	err := r.recv(ctx, func(mon Monitor) (err error) {
+		head, err = mon.(*BlockHeaderMonitor).Recv(ctx)
+		return
+	})
+	return head, err
+}
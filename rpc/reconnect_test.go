@@ -0,0 +1,175 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestBlockHeaderReconnector(t *testing.T) {
+	var calls int32
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/monitor/heads/main": func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&calls, 1)
+			if n == 1 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"hash":  testHash("head"),
+				"level": int64(n),
+			})
+			w.(http.Flusher).Flush()
+			if n > 2 {
+				// keep the last stream open until the client disconnects
+				<-r.Context().Done()
+			}
+		},
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []ReconnectEvent
+	r := NewBlockHeaderReconnector(c)
+	r.WithBackoff(time.Millisecond, 4*time.Millisecond).
+		WithCallback(func(e ReconnectEvent) { events = append(events, e) })
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, level := range []int64{2, 3} {
+		head, err := r.Recv(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.Level != level {
+			t.Errorf("expected level %d, got %d", level, head.Level)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("expected 3 monitor calls, got %d", n)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 reconnect events, got %d", len(events))
+	}
+	if e := events[0]; e.Attempt != 1 || e.Delay != time.Millisecond || e.Err == nil {
+		t.Errorf("unexpected first event %#v", e)
+	}
+	if e := events[1]; e.Attempt != 0 || e.Delay != 0 || e.Err != io.EOF {
+		t.Errorf("unexpected second event %#v", e)
+	}
+}
+
+func TestMonitorReconnectorMaxRetries(t *testing.T) {
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/*": nodetest.Response{Status: http.StatusServiceUnavailable, Body: "unavailable"},
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	r := NewMempoolReconnector(c)
+	r.WithBackoff(time.Millisecond, time.Millisecond).
+		WithMaxRetries(3).
+		WithCallback(func(ReconnectEvent) { events++ })
+	defer r.Close()
+
+	if _, err := r.Recv(context.Background()); err == nil {
+		t.Fatal("expected error after max retries")
+	}
+	if events != 3 {
+		t.Errorf("expected 3 reconnect events, got %d", events)
+	}
+}
+
+func TestMempoolReconnectorRecvNew(t *testing.T) {
+	op := func(name string) map[string]any {
+		return map[string]any{"hash": tezos.NewOpHash([]byte(name + strings.Repeat("_", 32-len(name))))}
+	}
+	var calls int32
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/mempool/monitor_operations": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			switch atomic.AddInt32(&calls, 1) {
+			case 1:
+				_ = enc.Encode([]any{op("a"), op("b")})
+			default:
+				// node re-sends pending ops after reconnect
+				_ = enc.Encode([]any{op("a"), op("b")})
+				_ = enc.Encode([]any{op("b"), op("c")})
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}
+		},
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewMempoolReconnector(c)
+	r.WithBackoff(time.Millisecond, time.Millisecond)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, want := range []string{"a,b", "c"} {
+		ops, err := r.RecvNew(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(ops))
+		for i, v := range ops {
+			names[i] = strings.TrimRight(string(v.Hash[:]), "_")
+		}
+		if have := strings.Join(names, ","); have != want {
+			t.Errorf("expected ops %s, got %s", want, have)
+		}
+	}
+	if n := r.Cursor().Len(); n != 3 {
+		t.Errorf("expected 3 seen ops, got %d", n)
+	}
+}
+
+func TestSeenCursor(t *testing.T) {
+	c := NewSeenCursor(2)
+	h := func(b byte) tezos.OpHash { return tezos.NewOpHash([]byte{b}) }
+	if !c.Add(h(1)) || !c.Add(h(2)) || c.Add(h(1)) {
+		t.Fatal("unexpected add result")
+	}
+	// evicts the oldest hash
+	c.Add(h(3))
+	if c.Seen(h(1)) || !c.Seen(h(2)) || !c.Seen(h(3)) || c.Len() != 2 {
+		t.Errorf("unexpected cursor state after eviction")
+	}
+	c.Reset()
+	if c.Len() != 0 || c.Seen(h(2)) {
+		t.Errorf("expected empty cursor after reset")
+	}
+}
+
+func TestMonitorReconnectorBackoff(t *testing.T) {
+	r := NewMonitorReconnector(nil).WithBackoff(time.Second, 10*time.Second)
+	for i, d := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		if got := r.backoff(i); got != d {
+			t.Errorf("attempt %d: expected %s, got %s", i, d, got)
+		}
+	}
+}
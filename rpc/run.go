@@ -7,7 +7,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"blockwatch.cc/tzgo/codec"
 	"blockwatch.cc/tzgo/micheline"
@@ -17,11 +20,20 @@ import (
 
 const ExtraSafetyMargin int64 = 100 // used to adjust gas and storage estimations
 
+// ErrSourceMismatch is returned by Send when an operation has an explicit
+// source that differs from the signer's address.
+var ErrSourceMismatch = errors.New("rpc: operation source does not match signer")
+
+// ErrUnknownBranch is returned by Validate when the node does not know an
+// operation's branch block, e.g. because it was pruned or is not part of
+// the node's chain.
+var ErrUnknownBranch = errors.New("rpc: unknown branch")
+
 var (
 	// for reveal
 	DefaultRevealLimits = tezos.Limits{
 		Fee:      1000,
-		GasLimit: 1000,
+		GasLimit: codec.DefaultRevealGasLimit,
 	}
 	// for transfers to tz1/2/3
 	DefaultTransferLimitsEOA = tezos.Limits{
@@ -52,16 +64,60 @@ var (
 )
 
 type CallOptions struct {
-	Confirmations     int64         // number of confirmations to wait after broadcast
-	MaxFee            int64         // max acceptable fee, optional (default = 0)
-	TTL               int64         // max lifetime for operations in blocks
-	IgnoreLimits      bool          // ignore simulated limits and use user-defined limits from op
-	ExtraGasMargin    int64         // safety margin in case simulation underestimates future usage
-	SimulationBlockID BlockID       // custom block id to simulate operation (default is head, use to select a past block)
-	SimulationOffset  int64         // custom block offset for future block simulations
-	Signer            signer.Signer // optional signer interface to use for signing the transaction
-	Sender            tezos.Address // optional address to sign for (use when signer manages multiple addresses)
-	Observer          *Observer     // optional custom block observer for waiting on confirmations
+	Confirmations       int64              // number of confirmations to wait after broadcast
+	MaxFee              int64              // max acceptable fee, optional (default = 0)
+	TTL                 int64              // max lifetime for operations in blocks
+	IgnoreLimits        bool               // ignore simulated limits and use user-defined limits from op
+	ExtraGasMargin      int64              // safety margin in case simulation underestimates future usage
+	SimulationBlockID   BlockID            // custom block id to simulate operation (default is head, use to select a past block)
+	SimulationOffset    int64              // custom block offset for future block simulations
+	Signer              signer.Signer      // optional signer interface to use for signing the transaction
+	Sender              tezos.Address      // optional address to sign for (use when signer manages multiple addresses)
+	Observer            *Observer          // optional custom block observer for waiting on confirmations
+	FeeEstimator        FeeEstimator       // optional custom fee policy applied after simulation (default = min fee)
+	OnStatus            func(StatusUpdate) // optional progress callback invoked by Send
+	PerCallTimeout      time.Duration      // optional timeout for each RPC request (zero = client default)
+	AllowSourceMismatch bool               // keep explicit op sources that differ from the signer address instead of failing
+	SimulateBalance     tezos.Z            // optional source balance to check simulated debits against (zero = use on-chain balance)
+}
+
+// SendStatus is a phase of sending an operation.
+type SendStatus byte
+
+const (
+	SendStatusInvalid   SendStatus = iota
+	SendStatusSimulated            // simulation succeeded, limits and fees are set
+	SendStatusSigned               // operation is signed
+	SendStatusBroadcast            // operation was injected, hash is known
+	SendStatusConfirmed            // operation was included or confirmed by another block
+)
+
+func (s SendStatus) String() string {
+	switch s {
+	case SendStatusSimulated:
+		return "simulated"
+	case SendStatusSigned:
+		return "signed"
+	case SendStatusBroadcast:
+		return "broadcast"
+	case SendStatusConfirmed:
+		return "confirmed"
+	default:
+		return ""
+	}
+}
+
+// StatusUpdate reports progress of Send to CallOptions.OnStatus. Hash is set
+// once the operation is signed. Block, Height and Confirmations are set for confirmation
+// updates where Confirmations counts blocks including the inclusion block and
+// Required is the number of confirmations Send waits for.
+type StatusUpdate struct {
+	Status        SendStatus
+	Hash          tezos.OpHash
+	Block         tezos.BlockHash
+	Height        int64
+	Confirmations int64
+	Required      int64
 }
 
 var DefaultOptions = CallOptions{
@@ -77,6 +133,30 @@ func NewCallOptions() *CallOptions {
 	return &o
 }
 
+// WithDefaults returns a copy of o where unset (zero) fields that have a
+// default value are filled from DefaultOptions. Note that zero is a valid
+// setting for some fields, e.g. Confirmations = 0 does not wait and
+// MaxFee = 0 disables the fee cap. Use WithDefaults only when such zero
+// values are not intended.
+func (o CallOptions) WithDefaults() CallOptions {
+	if o.Confirmations == 0 {
+		o.Confirmations = DefaultOptions.Confirmations
+	}
+	if o.MaxFee == 0 {
+		o.MaxFee = DefaultOptions.MaxFee
+	}
+	if o.TTL == 0 {
+		o.TTL = DefaultOptions.TTL
+	}
+	if o.ExtraGasMargin == 0 {
+		o.ExtraGasMargin = DefaultOptions.ExtraGasMargin
+	}
+	if o.SimulationOffset == 0 {
+		o.SimulationOffset = DefaultOptions.SimulationOffset
+	}
+	return o
+}
+
 type RunOperationRequest struct {
 	Operation *codec.Op         `json:"operation"`
 	ChainId   tezos.ChainIdHash `json:"chain_id"`
@@ -124,8 +204,22 @@ type RunCodeResponse struct {
 
 // Complete ensures an operation is compatible with the current source account's
 // on-chain state. Sets branch for TTL control, replay counters, and reveals
-// the sender's pubkey if not published yet.
+// the sender's pubkey if not published yet. Explicit operation sources must
+// match the key's address, use CompleteWithOptions to allow other sources.
 func (c *Client) Complete(ctx context.Context, o *codec.Op, key tezos.Key) error {
+	return c.CompleteWithOptions(ctx, o, key, nil)
+}
+
+// CompleteWithOptions works like Complete. When opts.AllowSourceMismatch is set
+// explicit sources other than the key's address are accepted. Counter and
+// reveal state are always taken from the key's account.
+func (c *Client) CompleteWithOptions(ctx context.Context, o *codec.Op, key tezos.Key, opts *CallOptions) error {
+	if opts == nil || !opts.AllowSourceMismatch {
+		if err := checkSource(o, key.Address()); err != nil {
+			return err
+		}
+	}
+
 	needBranch := !o.Branch.IsValid()
 	needCounter := o.NeedCounter()
 	mayNeedReveal := len(o.Contents) > 0 && o.Contents[0].Kind() != tezos.OpTypeReveal
@@ -180,9 +274,45 @@ func (c *Client) Complete(ctx context.Context, o *codec.Op, key tezos.Key) error
 	return nil
 }
 
+// fillSource sets addr as source on the operation and all contents that have
+// no explicit source.
+func fillSource(o *codec.Op, addr tezos.Address) {
+	if !o.Source.IsValid() {
+		o.Source = addr
+	}
+	for _, v := range o.Contents {
+		if src, ok := v.(interface{ GetSource() tezos.Address }); ok && src.GetSource().IsValid() {
+			continue
+		}
+		v.WithSource(addr)
+	}
+}
+
+// checkSource returns an error when the operation or any of its contents has
+// an explicit source other than addr.
+func checkSource(o *codec.Op, addr tezos.Address) error {
+	if o.Source.IsValid() && !o.Source.Equal(addr) {
+		return fmt.Errorf("%w: source %s, signer %s", ErrSourceMismatch, o.Source, addr)
+	}
+	for i, v := range o.Contents {
+		src, ok := v.(interface{ GetSource() tezos.Address })
+		if !ok {
+			continue
+		}
+		if a := src.GetSource(); a.IsValid() && !a.Equal(addr) {
+			return fmt.Errorf("%w: op %d source %s, signer %s", ErrSourceMismatch, i, a, addr)
+		}
+	}
+	return nil
+}
+
 // Simulate dry-runs the execution of the operation against the current state
 // of a Tezos node in order to estimate execution costs and fees (fee/burn/gas/storage).
+// When opts is nil, DefaultOptions are used.
 func (c *Client) Simulate(ctx context.Context, o *codec.Op, opts *CallOptions) (*Receipt, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
 	sim := &codec.Op{
 		Branch:    o.Branch,
 		Contents:  o.Contents,
@@ -192,10 +322,13 @@ func (c *Client) Simulate(ctx context.Context, o *codec.Op, opts *CallOptions) (
 	}
 
 	if opts == nil {
-		opts = &DefaultOptions
+		opts = NewCallOptions()
+	}
+	if opts.PerCallTimeout > 0 {
+		ctx = WithCallTimeout(ctx, opts.PerCallTimeout)
 	}
 
-	if sim.TTL == 0 && opts != nil {
+	if sim.TTL == 0 {
 		sim.TTL = opts.TTL
 	}
 
@@ -252,11 +385,64 @@ func (c *Client) Simulate(ctx context.Context, o *codec.Op, opts *CallOptions) (
 	if !rcpt.IsSuccess() {
 		return rcpt, rcpt.Error()
 	}
+
+	// nodes cannot override account balances, check debits locally
+	if !opts.SimulateBalance.IsZero() {
+		if err := checkSimulatedBalance(rcpt, opts.SimulateBalance.Int64()); err != nil {
+			return rcpt, err
+		}
+	}
 	return rcpt, nil
 }
 
+// checkSimulatedBalance returns a balance_too_low error when the operation
+// sources in a simulated receipt are debited more than balance in total. Since
+// Octez does not support balance overrides in run_operation and
+// simulate_operation, the check runs on the simulated balance updates after
+// the fact. It cannot detect contract logic that would behave differently
+// under a lower balance.
+func checkSimulatedBalance(rcpt *Receipt, balance int64) error {
+	sources := make(map[tezos.Address]struct{})
+	for _, v := range rcpt.Op.Contents {
+		if src := v.GetSource(); src.IsValid() {
+			sources[src] = struct{}{}
+		}
+	}
+	for src := range sources {
+		var change int64
+		sum := func(upd BalanceUpdates) {
+			for _, v := range upd {
+				if v.Kind == CONTRACT && v.Address().Equal(src) {
+					change += v.Change
+				}
+			}
+		}
+		for _, v := range rcpt.Op.Contents {
+			meta := v.Meta()
+			sum(meta.BalanceUpdates)
+			sum(meta.Result.BalanceUpdates)
+			for _, in := range meta.InternalResults {
+				sum(in.Result.BalanceUpdates)
+			}
+		}
+		if -change > balance {
+			addr := src.Clone()
+			return OperationError{
+				GenericError: GenericError{
+					ID:   "contract.balance_too_low",
+					Kind: "temporary",
+				},
+				Contract: &addr,
+			}
+		}
+	}
+	return nil
+}
+
 // Validate compares local serializiation against remote RPC serialization of the
-// operation and returns an error on mismatch.
+// operation and returns an error on mismatch. It also checks the age of the
+// operation's branch and returns a *BranchTooOldError when the branch is older
+// than the max operations TTL or ErrUnknownBranch when the node does not know it.
 func (c *Client) Validate(ctx context.Context, o *codec.Op) error {
 	op := &codec.Op{
 		Branch:   o.Branch,
@@ -271,6 +457,49 @@ func (c *Client) Validate(ctx context.Context, o *codec.Op) error {
 		return fmt.Errorf("tezos: mismatch between local and remote serialized operations:\n local=%s\n remote=%s",
 			hex.EncodeToString(local), hex.EncodeToString(remote))
 	}
+	return c.validateBranch(ctx, o.Branch)
+}
+
+// BranchTooOldError is returned by Validate when an operation's branch block
+// is outside the max operations TTL window and the operation would be refused
+// on injection.
+type BranchTooOldError struct {
+	Branch      tezos.BlockHash
+	BranchLevel int64
+	HeadLevel   int64
+	MaxTTL      int64
+}
+
+func (e *BranchTooOldError) Error() string {
+	return fmt.Sprintf("rpc: branch %s at level %d is %d blocks behind head %d, max operations ttl is %d",
+		e.Branch, e.BranchLevel, e.HeadLevel-e.BranchLevel, e.HeadLevel, e.MaxTTL)
+}
+
+// validateBranch checks that branch is recent enough to be accepted by the node.
+func (c *Client) validateBranch(ctx context.Context, branch tezos.BlockHash) error {
+	ttl := tezos.DefaultParams.MaxOperationsTTL
+	if c.Params != nil && c.Params.MaxOperationsTTL > 0 {
+		ttl = c.Params.MaxOperationsTTL
+	}
+	head, err := c.GetBlockHeader(ctx, Head)
+	if err != nil {
+		return err
+	}
+	bh, err := c.GetBlockHeader(ctx, branch)
+	if err != nil {
+		if ErrorStatus(err) == http.StatusNotFound {
+			return fmt.Errorf("%w %s", ErrUnknownBranch, branch)
+		}
+		return err
+	}
+	if head.Level-bh.Level >= ttl {
+		return &BranchTooOldError{
+			Branch:      branch,
+			BranchLevel: bh.Level,
+			HeadLevel:   head.Level,
+			MaxTTL:      ttl,
+		}
+	}
 	return nil
 }
 
@@ -283,9 +512,15 @@ func (c *Client) Broadcast(ctx context.Context, o *codec.Op) (tezos.OpHash, erro
 // Send is a convenience wrapper for sending operations. It auto-completes gas and storage limit,
 // ensures minimum fees are set, protects against fee overpayment, signs and broadcasts the final
 // operation and waits for a defined number of confirmations.
+//
+// When opts is nil, DefaultOptions are used. Explicit options are used as is,
+// call CallOptions.WithDefaults to fill unset fields from defaults.
 func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Receipt, error) {
 	if opts == nil {
-		opts = &DefaultOptions
+		opts = NewCallOptions()
+	}
+	if opts.PerCallTimeout > 0 {
+		ctx = WithCallTimeout(ctx, opts.PerCallTimeout)
 	}
 
 	signer := c.Signer
@@ -308,6 +543,13 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 		return nil, err
 	}
 
+	// explicit sources must match the signer unless mismatches are allowed
+	if !opts.AllowSourceMismatch {
+		if err := checkSource(op, key.Address()); err != nil {
+			return nil, err
+		}
+	}
+
 	// use custom observer when provided
 	mon := c.BlockObserver
 	if opts.Observer != nil {
@@ -317,11 +559,21 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 	// ensure block observer is running
 	mon.Listen(c)
 
-	// set source and params on all ops
-	op.WithSource(key.Address()).WithParams(c.Params)
+	// set source and params on all ops, keep explicit sources when allowed
+	if opts.AllowSourceMismatch {
+		fillSource(op, key.Address())
+	} else {
+		op.WithSource(key.Address())
+	}
+	op.WithParams(c.Params)
+
+	// catch malformed batches before talking to the node
+	if err := op.Validate(); err != nil {
+		return nil, err
+	}
 
 	// auto-complete op with branch/ttl, source counter, reveal
-	err = c.Complete(ctx, op, key)
+	err = c.CompleteWithOptions(ctx, op, key, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -342,6 +594,21 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 		op.WithLimits(sim.MinLimits(), opts.ExtraGasMargin)
 	}
 
+	// apply custom fee policy
+	if opts.FeeEstimator != nil {
+		if err := applyFees(ctx, opts.FeeEstimator, op, sim); err != nil {
+			return nil, err
+		}
+	}
+
+	notify := func(u StatusUpdate) {
+		if opts.OnStatus != nil {
+			u.Required = opts.Confirmations
+			opts.OnStatus(u)
+		}
+	}
+	notify(StatusUpdate{Status: SendStatusSimulated})
+
 	// log info about tx costs
 	c.logDebug(func() {
 		costs := sim.Costs()
@@ -371,6 +638,7 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 		return nil, err
 	}
 	op.WithSignature(sig)
+	notify(StatusUpdate{Status: SendStatusSigned, Hash: op.Hash()})
 
 	// trace what we'll broadcast
 	c.logTrace(func() {
@@ -384,8 +652,22 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 		return nil, err
 	}
 
+	notify(StatusUpdate{Status: SendStatusBroadcast, Hash: hash})
+
 	// wait for confirmations
 	res := NewResult(hash).WithTTL(op.TTL).WithConfirmations(opts.Confirmations)
+	if opts.OnStatus != nil {
+		res.WithCallback(func(r *Result) {
+			block, height := r.Block()
+			notify(StatusUpdate{
+				Status:        SendStatusConfirmed,
+				Hash:          hash,
+				Block:         block,
+				Height:        height,
+				Confirmations: r.Confirmations(),
+			})
+		})
+	}
 
 	// wait for confirmations
 	res.Listen(mon)
@@ -394,8 +676,14 @@ func (c *Client) Send(ctx context.Context, op *codec.Op, opts *CallOptions) (*Re
 		return nil, err
 	}
 
-	// return receipt
-	return res.GetReceipt(ctx)
+	// return receipt with simulated costs for later comparison
+	rcpt, err := res.GetReceipt(ctx)
+	if rcpt != nil {
+		estimate := sim.TotalCosts()
+		estimate.Fee = op.Limits().Fee
+		rcpt.Estimate = &estimate
+	}
+	return rcpt, err
 }
 
 // RunOperation simulates executing an operation without requiring a valid signature.
@@ -0,0 +1,189 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/codec"
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/signer"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestCallOptionsWithDefaults(t *testing.T) {
+	opts := CallOptions{Confirmations: 5, ExtraGasMargin: 10}
+	full := opts.WithDefaults()
+	if full.Confirmations != 5 || full.ExtraGasMargin != 10 {
+		t.Errorf("explicit values must be kept, got %#v", full)
+	}
+	if full.TTL != DefaultOptions.TTL || full.MaxFee != DefaultOptions.MaxFee || full.SimulationOffset != DefaultOptions.SimulationOffset {
+		t.Errorf("unset values must be filled from defaults, got %#v", full)
+	}
+	if opts.TTL != 0 || opts.MaxFee != 0 {
+		t.Errorf("caller options must not be modified, got %#v", opts)
+	}
+	if o := NewCallOptions(); o == &DefaultOptions || o.Confirmations != DefaultOptions.Confirmations {
+		t.Errorf("unexpected new call options %#v", o)
+	}
+}
+
+func TestSendSourceMismatch(t *testing.T) {
+	sk := tezos.MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	other := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	c, err := NewClient("http://127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := NewCallOptions()
+	opts.Signer = signer.NewFromKey(sk)
+
+	op := codec.NewOp().WithSource(other).WithTransfer(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"), 1000)
+	if _, err := c.Send(context.Background(), op, opts); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("expected source mismatch, got %v", err)
+	}
+	if err := c.Complete(context.Background(), op, sk.Public()); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("expected source mismatch on complete, got %v", err)
+	}
+
+	if err := checkSource(op, other); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := checkSource(codec.NewOp().WithTransfer(other, 1), sk.Address()); err != nil {
+		t.Errorf("unset sources must pass, got %v", err)
+	}
+}
+
+func TestSendAllowSourceMismatch(t *testing.T) {
+	sk := tezos.MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	other := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	dst := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	c, err := NewClient("http://127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := NewCallOptions()
+	opts.AllowSourceMismatch = true
+
+	op := codec.NewOp().WithSource(other).WithTransfer(dst, 1000)
+	if err := c.CompleteWithOptions(context.Background(), op, sk.Public(), opts); errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("unexpected source mismatch with opt-out")
+	}
+
+	// explicit sources are kept, unset sources are filled
+	op = codec.NewOp().WithTransfer(dst, 1000).WithTransfer(dst, 1)
+	op.Contents[0].(*codec.Transaction).Source = other
+	fillSource(op, sk.Address())
+	if !op.Source.Equal(sk.Address()) {
+		t.Errorf("unset op source not filled, got %s", op.Source)
+	}
+	if got := op.Contents[0].(*codec.Transaction).Source; !got.Equal(other) {
+		t.Errorf("explicit source overwritten, got %s", got)
+	}
+	if got := op.Contents[1].(*codec.Transaction).Source; !got.Equal(sk.Address()) {
+		t.Errorf("unset source not filled, got %s", got)
+	}
+}
+
+func TestValidateBranchTooOld(t *testing.T) {
+	var (
+		branch = tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")
+		head   int64
+		pruned bool
+	)
+	op := codec.NewOp().
+		WithBranch(branch).
+		WithTransfer(tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"), 1000)
+	op.WithSource(tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw"))
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/helpers/forge/operations": tezos.HexBytes(op.Bytes()),
+		"/chains/main/blocks/head/header": func(*http.Request) any {
+			return map[string]any{"level": head}
+		},
+		"/chains/main/blocks/" + branch.String() + "/header": func(*http.Request) any {
+			if pruned {
+				return nil
+			}
+			return map[string]any{"hash": branch, "level": 1000}
+		},
+	})
+
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head = 1000 + tezos.DefaultParams.MaxOperationsTTL - 1
+	if err := c.Validate(context.Background(), op); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	head++
+	err = c.Validate(context.Background(), op)
+	var e *BranchTooOldError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected branch too old error, got %v", err)
+	}
+	if e.BranchLevel != 1000 || e.HeadLevel != head || e.MaxTTL != tezos.DefaultParams.MaxOperationsTTL {
+		t.Errorf("unexpected error details %#v", e)
+	}
+
+	// branches the node no longer knows
+	pruned = true
+	if err := c.Validate(context.Background(), op); !errors.Is(err, ErrUnknownBranch) {
+		t.Errorf("expected unknown branch error, got %v", err)
+	}
+}
+
+func TestSimulateBalance(t *testing.T) {
+	src := tezos.MustParseAddress("tz1UBZUkXpKGhYsP5KtzDNqLLchwF4uHrGjw")
+	dst := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/helpers/scripts/simulate_operation": `{"contents":[{"kind":"transaction",` +
+			`"source":"` + src.String() + `","fee":"404","counter":"1","gas_limit":"1101","storage_limit":"0","amount":"1000","destination":"` + dst.String() + `",` +
+			`"metadata":{"balance_updates":[{"kind":"contract","contract":"` + src.String() + `","change":"-404","origin":"block"}],` +
+			`"operation_result":{"status":"applied","consumed_milligas":"1000000","balance_updates":[` +
+			`{"kind":"contract","contract":"` + src.String() + `","change":"-1000","origin":"block"},` +
+			`{"kind":"contract","contract":"` + dst.String() + `","change":"1000","origin":"block"}]}}}]}`,
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := codec.NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithSource(src).
+		WithTransfer(dst, 1000)
+
+	for _, v := range []struct {
+		balance int64
+		fail    bool
+	}{
+		{0, false}, // on-chain balance
+		{1404, false},
+		{1403, true},
+	} {
+		opts := NewCallOptions()
+		opts.SimulateBalance.SetInt64(v.balance)
+		rcpt, err := c.Simulate(context.Background(), op, opts)
+		if rcpt == nil {
+			t.Fatalf("balance %d: missing receipt, err=%v", v.balance, err)
+		}
+		if !v.fail {
+			if err != nil {
+				t.Errorf("balance %d: unexpected error %v", v.balance, err)
+			}
+			continue
+		}
+		var oe OperationError
+		if !errors.As(err, &oe) || oe.ShortID() != "contract.balance_too_low" {
+			t.Errorf("balance %d: expected balance_too_low, got %v", v.balance, err)
+			continue
+		}
+		if oe.Contract == nil || !oe.Contract.Equal(src) {
+			t.Errorf("balance %d: unexpected contract %v", v.balance, oe.Contract)
+		}
+	}
+}
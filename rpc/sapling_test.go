@@ -0,0 +1,74 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/internal/nodetest"
+)
+
+func TestGetSaplingState(t *testing.T) {
+	const diff = `{"root":"0a0b","commitments_and_ciphertexts":[` +
+		`["01",{"cv":"c1","epk":"e1","payload_enc":"b1","nonce_enc":"d1","payload_out":"a1","nonce_out":"f1"}],` +
+		`["02",{"cv":"c2","epk":"e2","payload_enc":"b2","nonce_enc":"d2","payload_out":"a2","nonce_out":"f2"}]` +
+		`],"nullifiers":["aa","bb","cc"]}`
+	var query string
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/chains/main/blocks/head/context/sapling/7/get_diff": func(r *http.Request) any {
+			query = r.URL.RawQuery
+			return diff
+		},
+	})
+	c, err := NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := c.GetSaplingState(context.Background(), 7, Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "offset_commitment=0&offset_nullifier=0" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if state.Id != 7 || state.NumCommitments != 2 || len(state.Nullifiers) != 3 || !bytes.Equal(state.Root, []byte{0x0a, 0x0b}) {
+		t.Errorf("unexpected state %#v", state)
+	}
+
+	d, err := c.GetSaplingDiffExt(context.Background(), 7, Head, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "offset_commitment=1&offset_nullifier=2" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if cm := d.Commitments[1]; !bytes.Equal(cm.Commitment, []byte{2}) || !bytes.Equal(cm.Ciphertext.NonceOut, []byte{0xf2}) {
+		t.Errorf("unexpected commitment %#v", cm)
+	}
+
+	// commitments round-trip as tuples
+	buf, err := json.Marshal(d.Commitments[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cm SaplingCommitment
+	if err := json.Unmarshal(buf, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cm.Commitment, []byte{1}) || !bytes.Equal(cm.Ciphertext.Cv, []byte{0xc1}) {
+		t.Errorf("unexpected commitment after round-trip %#v", cm)
+	}
+	if err := json.Unmarshal([]byte(`["01"]`), &cm); err == nil {
+		t.Errorf("expected error for invalid tuple")
+	}
+
+	if _, err := c.GetSaplingState(context.Background(), 8, Head); err == nil {
+		t.Errorf("expected error for unknown sapling state")
+	}
+}
@@ -7,16 +7,42 @@ import (
 	"blockwatch.cc/tzgo/tezos"
 )
 
-// Ensure SeedNonce implements the TypedOperation interface.
-var _ TypedOperation = (*SeedNonce)(nil)
+// Ensure SeedNonceRevelation implements the TypedOperation interface.
+var _ TypedOperation = (*SeedNonceRevelation)(nil)
 
-// SeedNonce represents a seed_nonce_revelation operation
-type SeedNonce struct {
+// SeedNonceRevelation represents a seed_nonce_revelation operation
+type SeedNonceRevelation struct {
 	Generic
 	Level int64          `json:"level"`
 	Nonce tezos.HexBytes `json:"nonce"`
 }
 
+// SeedNonce is the former name of SeedNonceRevelation.
+//
+// Deprecated: use SeedNonceRevelation instead.
+type SeedNonce = SeedNonceRevelation
+
+// Reward returns the revelation tip credited to the block producer.
+func (s SeedNonceRevelation) Reward() int64 {
+	var reward int64
+	for _, v := range s.Metadata.BalanceUpdates {
+		if v.Kind == CONTRACT && v.Change > 0 {
+			reward += v.Change
+		}
+	}
+	return reward
+}
+
+// Baker returns the address that received the revelation tip.
+func (s SeedNonceRevelation) Baker() tezos.Address {
+	for _, v := range s.Metadata.BalanceUpdates {
+		if v.Kind == CONTRACT && v.Change > 0 {
+			return v.Address()
+		}
+	}
+	return tezos.InvalidAddress
+}
+
 // Ensure VdfRevelation implements the TypedOperation interface.
 var _ TypedOperation = (*VdfRevelation)(nil)
 
@@ -25,3 +51,19 @@ type VdfRevelation struct {
 	Generic
 	Solution []tezos.HexBytes `json:"solution"`
 }
+
+// Output returns the VDF result, the first element of the revealed solution.
+func (v VdfRevelation) Output() tezos.HexBytes {
+	if len(v.Solution) > 0 {
+		return v.Solution[0]
+	}
+	return nil
+}
+
+// Proof returns the VDF proof, the second element of the revealed solution.
+func (v VdfRevelation) Proof() tezos.HexBytes {
+	if len(v.Solution) > 1 {
+		return v.Solution[1]
+	}
+	return nil
+}
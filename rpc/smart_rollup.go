@@ -52,6 +52,18 @@ type SmartRollupCement struct {
 	Commitment *tezos.SmartRollupCommitHash `json:"commitment,omitempty"` // deprecated in v17
 }
 
+// CementedCommitment returns the hash of the commitment cemented by this
+// operation. Since v017 it is only available from the operation receipt.
+func (o SmartRollupCement) CementedCommitment() (tezos.SmartRollupCommitHash, bool) {
+	if c := o.Metadata.Result.Commitment; c != nil {
+		return *c, true
+	}
+	if o.Commitment != nil {
+		return *o.Commitment, true
+	}
+	return tezos.SmartRollupCommitHash{}, false
+}
+
 type SmartRollupCommitment struct {
 	CompressedState tezos.SmartRollupStateHash  `json:"compressed_state"`
 	InboxLevel      int64                       `json:"inbox_level"`
@@ -98,6 +110,15 @@ type SmartRollupProof struct {
 	InputProof *SmartRollupInputProof `json:"input_proof,omitempty"`
 }
 
+// Kind returns the kind of input proof (inbox_proof, reveal_proof or
+// first_input) or an empty string when the proof is a PVM step only.
+func (p SmartRollupProof) Kind() string {
+	if p.InputProof == nil {
+		return ""
+	}
+	return p.InputProof.Kind
+}
+
 func (s *SmartRollupRefuteStep) UnmarshalJSON(buf []byte) error {
 	if len(buf) == 0 {
 		return nil
@@ -130,10 +151,17 @@ type SmartRollupTick struct {
 }
 
 type SmartRollupInputProof struct {
-	Kind    string         `json:"input_proof_kind"`
-	Level   int64          `json:"level"`
-	Counter tezos.Z        `json:"message_counter"`
-	Proof   tezos.HexBytes `json:"serialized_proof"`
+	Kind        string                  `json:"input_proof_kind"`
+	Level       int64                   `json:"level,omitempty"`            // inbox_proof
+	Counter     tezos.Z                 `json:"message_counter"`            // inbox_proof
+	Proof       tezos.HexBytes          `json:"serialized_proof,omitempty"` // inbox_proof
+	RevealProof *SmartRollupRevealProof `json:"reveal_proof,omitempty"`     // reveal_proof
+}
+
+// SmartRollupRevealProof proves data revealed to a rollup's PVM.
+type SmartRollupRevealProof struct {
+	Kind    string         `json:"reveal_proof_kind"`
+	RawData tezos.HexBytes `json:"raw_data,omitempty"` // raw_data_proof
 }
 
 type SmartRollupTimeout struct {
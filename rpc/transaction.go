@@ -32,7 +32,7 @@ func (t Transaction) Costs() tezos.Costs {
 	}
 	var i int
 	for _, v := range res.BalanceUpdates {
-		if v.Kind != CONTRACT {
+		if v.Kind != CONTRACT || v.IsSimulation() {
 			continue
 		}
 		if t.Amount > 0 && v.AmountAbs() == t.Amount {
@@ -75,6 +75,75 @@ type InternalResult struct {
 	TicketUpdates []TicketUpdate        `json:"ticket_receipt"`        // v015
 }
 
+// AsTransaction promotes an internal transaction into the shape of a top-level
+// transaction so that it can be analyzed or re-simulated like one. Source,
+// destination, amount, parameters and the operation result are preserved.
+// Internal operations pay no fees and use no counter, so fee, counter and
+// limits are zero. Returns nil when r is not a transaction.
+func (r InternalResult) AsTransaction() *Transaction {
+	if r.Kind != tezos.OpTypeTransaction || r.Destination == nil {
+		return nil
+	}
+	return &Transaction{
+		Manager: Manager{
+			Generic: Generic{
+				OpKind: tezos.OpTypeTransaction,
+				Metadata: OperationMetadata{
+					Result: r.Result,
+				},
+			},
+			Source: r.Source,
+		},
+		Destination: *r.Destination,
+		Amount:      r.Amount,
+		Parameters:  r.Parameters,
+	}
+}
+
+// InternalOperation wraps an internal operation result so that it can be
+// handled as TypedOperation. Use AsTransaction to access internal
+// transactions like top-level transactions.
+type InternalOperation struct {
+	Generic
+	Internal *InternalResult
+}
+
+// NewInternalOperation wraps internal result r.
+func NewInternalOperation(r *InternalResult) *InternalOperation {
+	return &InternalOperation{
+		Generic: Generic{
+			OpKind:   r.Kind,
+			Metadata: OperationMetadata{Result: r.Result},
+		},
+		Internal: r,
+	}
+}
+
+// GetSource returns the contract that emitted the internal operation.
+func (o InternalOperation) GetSource() tezos.Address {
+	return o.Internal.Source
+}
+
+// Costs returns internal operation costs to implement TypedOperation interface.
+func (o InternalOperation) Costs() tezos.Costs {
+	return o.Internal.Costs()
+}
+
+// isRelated returns true when addr is source, destination, delegate or
+// originated contract of the internal operation or appears in its balance
+// updates.
+func (r InternalResult) isRelated(addr tezos.Address) bool {
+	switch {
+	case r.Source.Equal(addr):
+		return true
+	case r.Destination != nil && r.Destination.Equal(addr):
+		return true
+	case r.Delegate != nil && r.Delegate.Equal(addr):
+		return true
+	}
+	return r.Result.isRelated(addr)
+}
+
 func (r InternalResult) Costs() tezos.Costs {
 	cost := tezos.Costs{
 		GasUsed:     r.Result.Gas(),
@@ -82,7 +151,7 @@ func (r InternalResult) Costs() tezos.Costs {
 	}
 	var i int
 	for _, v := range r.Result.BalanceUpdates {
-		if v.Kind != CONTRACT {
+		if v.Kind != CONTRACT || v.IsSimulation() {
 			continue
 		}
 		if r.Amount > 0 && v.AmountAbs() == r.Amount {
@@ -124,10 +193,7 @@ type ImplicitResult struct {
 }
 
 func (r ImplicitResult) Gas() int64 {
-	if r.ConsumedMilliGas > 0 {
-		return r.ConsumedMilliGas / 1000
-	}
-	return r.ConsumedGas
+	return milliGasToGas(r.ConsumedMilliGas, r.ConsumedGas)
 }
 
 func (r ImplicitResult) MilliGas() int64 {
@@ -32,7 +32,7 @@ func (t TransferTicket) Costs() tezos.Costs {
 		return cost
 	}
 	for _, v := range res.BalanceUpdates {
-		if v.Kind != CONTRACT {
+		if v.Kind != CONTRACT || v.IsSimulation() {
 			continue
 		}
 		burn := v.Amount()
@@ -10,12 +10,23 @@ import (
 // Ensure UpdateConsensusKey implements the TypedOperation interface.
 var _ TypedOperation = (*UpdateConsensusKey)(nil)
 
-// UpdateConsensusKey represents a transaction operation
+// UpdateConsensusKey represents an update_consensus_key operation
 type UpdateConsensusKey struct {
 	Manager
 	Pk tezos.Key `json:"pk"`
 }
 
+// ConsensusKey returns the address of the new consensus key.
+func (t UpdateConsensusKey) ConsensusKey() tezos.Address {
+	return t.Pk.Address()
+}
+
+// ActivationCycle returns the first cycle in which the new consensus key is
+// active when the operation was included in block height.
+func (t UpdateConsensusKey) ActivationCycle(p *tezos.Params, height int64) int64 {
+	return p.CycleFromHeight(height) + p.PreservedCycles + 1
+}
+
 // Costs returns operation cost to implement TypedOperation interface.
 func (t UpdateConsensusKey) Costs() tezos.Costs {
 	return tezos.Costs{
@@ -40,6 +40,13 @@ type BlockAlias string
 const (
 	Genesis BlockAlias = "genesis"
 	Head    BlockAlias = "head"
+
+	// Node storage aliases. Caboose is the oldest block a node keeps,
+	// Savepoint the oldest block with metadata and Checkpoint the last
+	// block the node considers final. See Client.GetCheckpoint.
+	CabooseBlock    BlockAlias = "caboose"
+	SavepointBlock  BlockAlias = "savepoint"
+	CheckpointBlock BlockAlias = "checkpoint"
 )
 
 func (b BlockAlias) String() string {
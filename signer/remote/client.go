@@ -4,8 +4,11 @@
 package remote
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"blockwatch.cc/tzgo/codec"
 	"blockwatch.cc/tzgo/rpc"
@@ -15,6 +18,9 @@ import (
 
 var _ signer.Signer = (*RemoteSigner)(nil)
 
+// authWatermark prefixes authenticated signing requests.
+const authWatermark byte = 0x04
+
 type RemoteSigner struct {
 	c     *rpc.Client
 	addrs []tezos.Address
@@ -92,21 +98,75 @@ func (s RemoteSigner) SignMessage(ctx context.Context, address tezos.Address, ms
 // Note that most remote signers for Tezos do not support signing of operation kinds other
 // than baking related operations.
 func (s RemoteSigner) SignOperation(ctx context.Context, address tezos.Address, op *codec.Op) (tezos.Signature, error) {
-	type response struct {
-		Sig tezos.Signature `json:"signature"`
+	return s.sign(ctx, address, op.WatermarkedBytes())
+}
+
+// SignOperationWithWatermark signs operation op for address after prefixing it with
+// an explicit watermark byte. Tenderbake consensus watermarks (pre-endorsements and
+// endorsements) are followed by the chain id which op must contain. Use this when
+// the watermark cannot be derived from operation contents or params.
+func (s RemoteSigner) SignOperationWithWatermark(ctx context.Context, address tezos.Address, op *codec.Op, watermark byte) (tezos.Signature, error) {
+	if len(op.Contents) == 0 || !op.Branch.IsValid() {
+		return tezos.InvalidSignature, fmt.Errorf("remote: incomplete operation")
 	}
-	var resp response
-	err := s.c.Post(ctx, "/keys/"+address.String(), tezos.HexBytes(op.WatermarkedBytes()), &resp)
-	return resp.Sig, err
+	p := op.Params
+	if p == nil {
+		p = tezos.DefaultParams
+	}
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(watermark)
+	switch watermark {
+	case codec.TenderbakeBlockWatermark,
+		codec.TenderbakePreendorsementWatermark,
+		codec.TenderbakeEndorsementWatermark:
+		if op.ChainId == nil {
+			return tezos.InvalidSignature, fmt.Errorf("remote: watermark 0x%02x requires chain id", watermark)
+		}
+		buf.Write(op.ChainId.Bytes())
+	}
+	buf.Write(op.Branch.Bytes())
+	for _, v := range op.Contents {
+		if err := v.EncodeBuffer(buf, p); err != nil {
+			return tezos.InvalidSignature, err
+		}
+	}
+	return s.sign(ctx, address, buf.Bytes())
 }
 
-// SignOperation signs a block header for address using the configured remote signer's
+// SignBlock signs a block header for address using the configured remote signer's
 // REST API. This call requires branch_id to be present.
 func (s RemoteSigner) SignBlock(ctx context.Context, address tezos.Address, head *codec.BlockHeader) (tezos.Signature, error) {
+	return s.sign(ctx, address, head.WatermarkedBytes())
+}
+
+// sign posts watermarked data to the remote signer. When an authentication key
+// is configured the request is signed as expected by signers running with
+// authentication enabled (octez-signer --require-authentication).
+func (s RemoteSigner) sign(ctx context.Context, address tezos.Address, data []byte) (tezos.Signature, error) {
 	type response struct {
 		Sig tezos.Signature `json:"signature"`
 	}
+	u := "/keys/" + address.String()
+	if s.auth.IsValid() {
+		sig, err := s.auth.Sign(authDigest(address, data))
+		if err != nil {
+			return tezos.InvalidSignature, err
+		}
+		u += "?" + url.Values{"authentication": []string{sig.String()}}.Encode()
+	}
 	var resp response
-	err := s.c.Post(ctx, "/keys/"+address.String(), tezos.HexBytes(head.WatermarkedBytes()), &resp)
+	err := s.c.Post(ctx, u, tezos.HexBytes(data), &resp)
 	return resp.Sig, err
 }
+
+// authDigest returns the hash an authentication key signs for a request to sign
+// data with the key for address. The pre-image is a 0x04 magic byte followed by
+// the binary public key hash and the data itself.
+func authDigest(address tezos.Address, data []byte) []byte {
+	buf := make([]byte, 0, 22+len(data))
+	buf = append(buf, authWatermark)
+	buf = append(buf, address.Encode()...)
+	buf = append(buf, data...)
+	d := tezos.Digest(buf)
+	return d[:]
+}
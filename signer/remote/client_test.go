@@ -0,0 +1,100 @@
+// Copyright (c) 2020-2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"blockwatch.cc/tzgo/codec"
+	"blockwatch.cc/tzgo/internal/nodetest"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestSignAuthenticated(t *testing.T) {
+	sk, err := tezos.GenerateKey(tezos.KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := tezos.GenerateKey(tezos.KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := sk.Address()
+	op := codec.NewOp().
+		WithBranch(tezos.MustParseBlockHash("BMJpBGs6rDpEGki8vLVd6VAcLrnEnAhxAwpGjExRcT8qDCmwQQm")).
+		WithContents(&codec.FailingNoop{Arbitrary: "hello"})
+	op.WithChainId(tezos.Mainnet)
+
+	var data tezos.HexBytes
+	srv := nodetest.NewServer(t, nodetest.Routes{
+		"/keys/" + addr.String(): func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			sig, err := tezos.ParseSignature(r.URL.Query().Get("authentication"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if err := auth.Public().Verify(authDigest(addr, data), sig); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			d := tezos.Digest(data)
+			s, _ := sk.Sign(d[:])
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"signature": s})
+		},
+	})
+
+	rs, err := New(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.WithAuthKey(auth)
+	ctx := context.Background()
+
+	if _, err := rs.SignOperation(ctx, addr, op); err != nil {
+		t.Fatalf("sign operation: %v", err)
+	}
+	if data[0] != codec.OperationWatermark {
+		t.Errorf("expected operation watermark, got 0x%02x", data[0])
+	}
+
+	if _, err := rs.SignOperationWithWatermark(ctx, addr, op, codec.TenderbakeEndorsementWatermark); err != nil {
+		t.Fatalf("sign with watermark: %v", err)
+	}
+	if data[0] != codec.TenderbakeEndorsementWatermark {
+		t.Errorf("expected endorsement watermark, got 0x%02x", data[0])
+	}
+	if have, want := len(data), len(op.WatermarkedBytes())+len(tezos.Mainnet.Bytes()); have != want {
+		t.Errorf("expected chain id after watermark, have len %d want %d", have, want)
+	}
+
+	rs.WithAuthKey(tezos.PrivateKey{})
+	if _, err := rs.SignOperation(ctx, addr, op); err == nil {
+		t.Errorf("expected unauthenticated request to fail")
+	}
+}
+
+func TestAuthDigest(t *testing.T) {
+	// Octez signs the authentication pre-image 0x04 || pkh || data where pkh
+	// uses the binary public key hash encoding (tag byte and 20 byte hash)
+	addr := tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	data := []byte{0x03, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11}
+	pre, _ := hex.DecodeString("04" + "00" + "02298c03ed7d454a101eb7022bc95f7e5f41ac78" + "031111111111111111")
+	want, _ := hex.DecodeString("d90833c0851f557fdfce94752a781b3f1ca5177338f02b5e1cfdaa4e5e4a7850")
+	if d := tezos.Digest(pre); !bytes.Equal(d[:], want) {
+		t.Fatalf("pre-image digest mismatch: %x", d)
+	}
+	if have := authDigest(addr, data); !bytes.Equal(have, want) {
+		t.Errorf("digest mismatch\nhave %x\nwant %x", have, want)
+	}
+}
@@ -4,12 +4,14 @@
 package tezos
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	"blockwatch.cc/tzgo/base58"
+	"golang.org/x/crypto/blake2b"
 )
 
 var (
@@ -177,6 +179,46 @@ func NewAddress(typ AddressType, hash []byte) (a Address) {
 	return
 }
 
+// AddressFromHash creates an address of type typ from a raw address hash.
+// Since hashes of all address types share the same length, the type cannot
+// be inferred and must be provided by the caller.
+func AddressFromHash(hash []byte, typ AddressType) (Address, error) {
+	if !typ.IsValid() || int(typ) >= len(addressTypes) {
+		return InvalidAddress, ErrUnknownAddressType
+	}
+	if l := typ.HashType().Len; l != len(hash) {
+		return InvalidAddress, fmt.Errorf("tezos: invalid %s address hash length %d, expected %d", typ, len(hash), l)
+	}
+	return NewAddress(typ, hash), nil
+}
+
+// AddressFromKeyHashBytes decodes an implicit account address from its 21 byte
+// binary public key hash encoding where the leading byte is the curve tag
+// (0 = tz1, 1 = tz2, 2 = tz3, 4 = tz4).
+func AddressFromKeyHashBytes(buf []byte) (Address, error) {
+	if len(buf) != 21 {
+		return InvalidAddress, fmt.Errorf("tezos: invalid key hash length %d", len(buf))
+	}
+	typ := AddressType(parseAddressTag(buf[0]))
+	if !typ.IsValid() || typ.KeyType() == KeyTypeInvalid {
+		return InvalidAddress, fmt.Errorf("tezos: invalid key hash tag %x", buf[0])
+	}
+	return NewAddress(typ, buf[1:]), nil
+}
+
+// ComputeContractAddress derives the KT1 address of the index-th contract
+// originated by the operation with hash oh. Following protocol rules the
+// origination nonce is the operation hash followed by the 32bit big endian
+// origination index which starts at zero for each operation group.
+func ComputeContractAddress(oh OpHash, index int) Address {
+	var nonce [36]byte
+	copy(nonce[:], oh[:])
+	binary.BigEndian.PutUint32(nonce[32:], uint32(index))
+	h, _ := blake2b.New(20, nil)
+	h.Write(nonce[:])
+	return NewAddress(AddressTypeContract, h.Sum(nil))
+}
+
 func (a Address) Type() AddressType {
 	return AddressType(a[0])
 }
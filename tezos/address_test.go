@@ -233,3 +233,61 @@ func BenchmarkAddressEncode(b *testing.B) {
 		_ = a.String()
 	}
 }
+
+func TestAddressFromHash(t *testing.T) {
+	for _, s := range []string{
+		"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+		"tz2BFTyPeYRzxd5aiBchbXN3WCZhx7BqbMBq",
+		"tz3hFR7NZtjT2QtzgMQnWb4xMuD6yt2YzXUt",
+		"KT1GyeRktoGPEKsWpchWguyy8FAf3aNHkw2T",
+	} {
+		want := MustParseAddress(s)
+		addr, err := AddressFromHash(want.Hash(), want.Type())
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if !addr.Equal(want) {
+			t.Errorf("%s: have %s", s, addr)
+		}
+		if want.Type().KeyType() == KeyTypeInvalid {
+			continue
+		}
+		addr, err = AddressFromKeyHashBytes(want.Encode())
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if !addr.Equal(want) {
+			t.Errorf("%s: key hash bytes decode to %s", s, addr)
+		}
+	}
+	if _, err := AddressFromHash(make([]byte, 19), AddressTypeEd25519); err == nil {
+		t.Errorf("expected error for short hash")
+	}
+	if _, err := AddressFromHash(make([]byte, 20), AddressTypeInvalid); err == nil {
+		t.Errorf("expected error for invalid type")
+	}
+	if _, err := AddressFromKeyHashBytes(append([]byte{3}, make([]byte, 20)...)); err == nil {
+		t.Errorf("expected error for blinded tag")
+	}
+	if _, err := AddressFromKeyHashBytes(make([]byte, 20)); err == nil {
+		t.Errorf("expected error for short key hash")
+	}
+}
+
+func TestComputeContractAddress(t *testing.T) {
+	// expected addresses are computed independently as
+	// base58check(KT1, blake2b-160(op_hash || uint32be(index)))
+	oh := MustParseOpHash("ooxcyrwLVfC7kcJvLvYTGXKsAvdrotzKci95au8tBwdjhMMjFTU")
+	for _, v := range []struct {
+		index int
+		want  string
+	}{
+		{0, "KT1B4peDuv3jBUaY9chXaFjPtPkztwNX7aeq"},
+		{1, "KT1LAUVFoYWoz1FbxF8T9dMKM1Gu89piKALp"},
+		{3, "KT19wYwoGpkf5QQMgM6a7d7orWnaxzefHScg"},
+	} {
+		if got := ComputeContractAddress(oh, v.index); got.String() != v.want {
+			t.Errorf("index %d: want %s, got %s", v.index, v.want, got)
+		}
+	}
+}
@@ -52,6 +52,19 @@ func MatchBlindedAddress(a, b Address, secret []byte) bool {
 	return bytes.Equal(bh, b[1:])
 }
 
+// VerifyBlindedAddress checks that blinded is a blinded address commitment
+// for fundraiser address a and activation secret. Unlike MatchBlindedAddress
+// it also validates address types and secret length.
+func VerifyBlindedAddress(blinded, a Address, secret []byte) bool {
+	if blinded.Type() != AddressTypeBlinded || a.Type() != AddressTypeEd25519 {
+		return false
+	}
+	if len(secret) != 20 {
+		return false
+	}
+	return MatchBlindedAddress(a, blinded, secret)
+}
+
 func DecodeBlindedAddress(addr string) (a Address, err error) {
 	ibuf := bufPool32.Get()
 	dec, ver, err2 := base58.CheckDecode(addr, 4, ibuf.([]byte))
@@ -45,6 +45,12 @@ func ecNormalizeSignature(r, s *big.Int, c elliptic.Curve) (*big.Int, *big.Int)
 	return r, s
 }
 
+// ecIsLowS returns true when s is in the lower half of the curve order.
+func ecIsLowS(s *big.Int, c elliptic.Curve) bool {
+	quo := new(big.Int).Quo(c.Params().N, new(big.Int).SetInt64(2))
+	return s.Sign() > 0 && s.Cmp(quo) <= 0
+}
+
 func ecSign(sk *ecdsa.PrivateKey, hash []byte) ([]byte, error) {
 	r, s, err := ecdsa.Sign(rand.Reader, sk, hash)
 	if err != nil {
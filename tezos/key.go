@@ -572,6 +572,38 @@ func GenerateKey(typ KeyType) (PrivateKey, error) {
 	return key, nil
 }
 
+// GenerateKeyFromSeed deterministically derives a private key of type typ
+// from seed. Ed25519 keys use seed as RFC 8032 private key seed, ECDSA keys
+// use seed as secret scalar. Seeds must be 32 bytes long. Intended for
+// reproducible tests and fixtures, use GenerateKey for real accounts.
+func GenerateKeyFromSeed(typ KeyType, seed []byte) (PrivateKey, error) {
+	key := PrivateKey{
+		Type: typ,
+	}
+	switch typ {
+	case KeyTypeEd25519:
+		if len(seed) != ed25519.SeedSize {
+			return key, fmt.Errorf("tezos: invalid %s seed length %d", typ, len(seed))
+		}
+		key.Data = []byte(ed25519.NewKeyFromSeed(seed))
+	case KeyTypeSecp256k1, KeyTypeP256:
+		if len(seed) != typ.SkHashType().Len {
+			return key, fmt.Errorf("tezos: invalid %s seed length %d", typ, len(seed))
+		}
+		if bytes.Equal(seed, make([]byte, len(seed))) {
+			return key, fmt.Errorf("tezos: invalid zero %s seed", typ)
+		}
+		if _, err := ecPrivateKeyFromBytes(seed, typ.Curve()); err != nil {
+			return key, err
+		}
+		key.Data = make([]byte, len(seed))
+		copy(key.Data, seed)
+	default:
+		return key, ErrUnknownKeyType
+	}
+	return key, nil
+}
+
 // Public returns the public key associated with the private key.
 func (k PrivateKey) Public() Key {
 	pk := Key{
@@ -4,6 +4,7 @@
 package tezos
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -152,3 +153,45 @@ func TestSign(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	for _, typ := range []KeyType{KeyTypeEd25519, KeyTypeSecp256k1, KeyTypeP256} {
+		k1, err := GenerateKeyFromSeed(typ, seed)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		k2, err := GenerateKeyFromSeed(typ, seed)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if !k1.IsValid() {
+			t.Errorf("%s: invalid key", typ)
+		}
+		if k1.String() != k2.String() || !k1.Address().Equal(k2.Address()) {
+			t.Errorf("%s: keys from same seed differ", typ)
+		}
+		// generated keys must sign and verify
+		digest := Digest([]byte("hello"))
+		sig, err := k1.Sign(digest[:])
+		if err != nil {
+			t.Fatalf("%s: sign: %v", typ, err)
+		}
+		if err := k1.Public().Verify(digest[:], sig); err != nil {
+			t.Errorf("%s: verify: %v", typ, err)
+		}
+		if _, err := GenerateKeyFromSeed(typ, seed[:31]); err == nil {
+			t.Errorf("%s: expected error for short seed", typ)
+		}
+		if _, err := GenerateKeyFromSeed(typ, make([]byte, 32)); typ != KeyTypeEd25519 && err == nil {
+			t.Errorf("%s: expected error for zero seed", typ)
+		}
+	}
+	if _, err := GenerateKeyFromSeed(KeyTypeBls12_381, seed); err == nil {
+		t.Errorf("expected error for unsupported key type")
+	}
+	// secp256k1 scalar must be below the curve order
+	if _, err := GenerateKeyFromSeed(KeyTypeSecp256k1, bytes.Repeat([]byte{0xff}, 32)); err == nil {
+		t.Errorf("expected error for out of range seed")
+	}
+}
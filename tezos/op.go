@@ -365,7 +365,7 @@ var (
 		158: 26 + 8 + 22 + 1 + 22 + 4, // OpTypeTransferTicket // v013
 		200: 26 + 13,                  // OpTypeSmartRollupOriginate // v016
 		201: 26 + 4,                   // OpTypeSmartRollupAddMessages // v016
-		202: 26 + 52,                  // OpTypeSmartRollupCement // v016
+		202: 26 + 20,                  // OpTypeSmartRollupCement // v017 (v016: +32)
 		203: 26 + 96,                  // OpTypeSmartRollupPublish // v016
 		204: 26 + 41,                  // OpTypeSmartRollupRefute // v016
 		205: 26 + 62,                  // OpTypeSmartRollupTimeout // v016
@@ -774,3 +774,81 @@ var paramBlocks = []BlockMetadata{
 		},
 	},
 }
+
+func TestParamsDefaultFees(t *testing.T) {
+	f := tezos.DefaultParams.DefaultFees()
+	if f.MinimalFees != tezos.DefaultMinimalFees ||
+		f.NanotezPerGasUnit != tezos.DefaultMinimalNanotezPerGasUnit ||
+		f.NanotezPerByte != tezos.DefaultMinimalNanotezPerByte {
+		t.Errorf("unexpected mempool defaults %#v", f)
+	}
+	if f.CostPerByte != tezos.DefaultParams.CostPerByte {
+		t.Errorf("cost per byte mismatch: have=%d want=%d", f.CostPerByte, tezos.DefaultParams.CostPerByte)
+	}
+	if f.GasLimit != tezos.DefaultParams.HardGasLimitPerOperation {
+		t.Errorf("gas limit mismatch: have=%d want=%d", f.GasLimit, tezos.DefaultParams.HardGasLimitPerOperation)
+	}
+	// 100 mutez + 200 byte * 1 mutez + 1001 gas * 0.1 mutez, rounded up
+	if have, want := f.MinFee(200, 1001), int64(401); have != want {
+		t.Errorf("min fee mismatch: have=%d want=%d", have, want)
+	}
+
+	p := tezos.DefaultParams.Clone()
+	p.MinimalFees = 200
+	p.MinimalNanotezPerGasUnit = 1000
+	if have, want := p.DefaultFees().MinFee(0, 10), int64(210); have != want {
+		t.Errorf("custom min fee mismatch: have=%d want=%d", have, want)
+	}
+}
+
+func TestParamsForNetwork(t *testing.T) {
+	for _, v := range []struct {
+		name string
+		want tezos.ChainIdHash
+	}{
+		{"mainnet", tezos.Mainnet},
+		{"Ghostnet", tezos.Ghostnet},
+		{"OXFORDNET", tezos.Oxfordnet},
+		{"NetXyuzvDo2Ugzb", tezos.Nairobinet},
+	} {
+		p, err := tezos.ParamsForNetwork(v.name)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+		if !p.ChainId.Equal(v.want) {
+			t.Errorf("%s: chain id mismatch: have=%s want=%s", v.name, p.ChainId, v.want)
+		}
+		if !p.Protocol.IsValid() || p.MinimalBlockDelay == 0 {
+			t.Errorf("%s: incomplete params %#v", v.name, p)
+		}
+	}
+
+	// presets are copies
+	p, _ := tezos.ParamsForNetwork("mainnet")
+	p.CostPerByte = 1
+	if tezos.MainnetParams.CostPerByte == 1 || tezos.DefaultParams.CostPerByte == 1 {
+		t.Errorf("preset was modified")
+	}
+
+	if _, err := tezos.ParamsForNetwork("foonet"); err == nil {
+		t.Errorf("expected error for unknown network")
+	}
+}
+
+func TestStorageBurnCost(t *testing.T) {
+	p := tezos.DefaultParams
+	for _, test := range []struct {
+		bytes int
+		want  int64
+	}{
+		{0, 0},
+		{1, 250},
+		// allocation of a new account (origination_size 257) burns 0.06425 tez
+		{257, 64250},
+		{112, 28000},
+	} {
+		if have := p.StorageBurnCost(test.bytes); have.Int64() != test.want {
+			t.Errorf("%d bytes: have %d want %d", test.bytes, have.Int64(), test.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@
 package tezos
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,7 +14,10 @@ var (
 	// protocol. It is used to generate compliant transaction encodings. To change,
 	// either overwrite this default or set custom params per operation using
 	// op.WithParams().
-	DefaultParams = (&Params{
+	DefaultParams = MainnetParams.Clone()
+
+	// MainnetParams defines the blockchain configuration for Mainnet.
+	MainnetParams = (&Params{
 		MinimalBlockDelay:            15 * time.Second,
 		CostPerByte:                  250,
 		OriginationSize:              257,
@@ -88,6 +93,11 @@ type Params struct {
 	CostPerByte     int64 `json:"cost_per_byte"`
 	OriginationSize int64 `json:"origination_size"`
 
+	// mempool fee filter, zero values use the Octez defaults
+	MinimalFees              int64 `json:"minimal_fees,omitempty"`                 // mutez
+	MinimalNanotezPerGasUnit int64 `json:"minimal_nanotez_per_gas_unit,omitempty"` // nanotez
+	MinimalNanotezPerByte    int64 `json:"minimal_nanotez_per_byte,omitempty"`     // nanotez
+
 	// limits
 	BlocksPerCycle               int64 `json:"blocks_per_cycle"`
 	PreservedCycles              int64 `json:"preserved_cycles"`
@@ -106,6 +116,35 @@ type Params struct {
 	StartCycle           int64 `json:"start_cycle"`                      // correction cycle length
 }
 
+// ParamsForNetwork returns a copy of the preset configuration for a known
+// network. Name is either a network name like mainnet or ghostnet (case is
+// ignored) or a chain id. Use it to build operations offline without
+// fetching constants from a node.
+func ParamsForNetwork(name string) (*Params, error) {
+	id, err := ParseChainIdHash(name)
+	if err != nil {
+		for k, v := range Networks {
+			if strings.EqualFold(v, name) {
+				id, err = k, nil
+				break
+			}
+		}
+	}
+	if err == nil {
+		switch id {
+		case Mainnet:
+			return MainnetParams.Clone(), nil
+		case Ghostnet:
+			return GhostnetParams.Clone(), nil
+		case Nairobinet:
+			return NairobinetParams.Clone(), nil
+		case Oxfordnet:
+			return OxfordnetParams.Clone(), nil
+		}
+	}
+	return nil, fmt.Errorf("tezos: unknown network %q", name)
+}
+
 func NewParams() *Params {
 	return &Params{
 		Network:     "unknown",
@@ -121,15 +160,8 @@ func (p Params) Clone() *Params {
 func (p *Params) WithChainId(id ChainIdHash) *Params {
 	p.ChainId = id
 	if p.Network == "unknown" || p.Network == "" {
-		switch id {
-		case Mainnet:
-			p.Network = "Mainnet"
-		case Ghostnet:
-			p.Network = "Ghostnet"
-		case Nairobinet:
-			p.Network = "Nairobinet"
-		case Oxfordnet:
-			p.Network = "Oxfordnet"
+		if n := id.Network(); n != "" {
+			p.Network = n
 		}
 	}
 	return p
@@ -211,6 +243,59 @@ func (p Params) SnapshotBaseCycle(cycle int64) int64 {
 	return cycle - (p.PreservedCycles + offset)
 }
 
+// StorageBurnCost returns the mutez burned for allocating n bytes of storage.
+func (p Params) StorageBurnCost(n int) Z {
+	return NewZ(int64(n) * p.CostPerByte)
+}
+
+// Octez mempool fee filter defaults.
+const (
+	DefaultMinimalFees              int64 = 100   // mutez
+	DefaultMinimalNanotezPerGasUnit int64 = 100   // nanotez
+	DefaultMinimalNanotezPerByte    int64 = 1_000 // nanotez
+)
+
+// FeeDefaults contains the fee and limit settings used to fill operation
+// fees and limits under a specific protocol and mempool configuration.
+type FeeDefaults struct {
+	MinimalFees       int64 // mutez
+	NanotezPerGasUnit int64 // nanotez
+	NanotezPerByte    int64 // nanotez
+	CostPerByte       int64 // mutez burned per byte of storage
+	GasLimit          int64 // max gas per operation
+	StorageLimit      int64 // max storage per operation
+}
+
+// DefaultFees returns fee defaults for this protocol. Mempool filter settings
+// that are unset use Octez defaults.
+func (p Params) DefaultFees() FeeDefaults {
+	f := FeeDefaults{
+		MinimalFees:       p.MinimalFees,
+		NanotezPerGasUnit: p.MinimalNanotezPerGasUnit,
+		NanotezPerByte:    p.MinimalNanotezPerByte,
+		CostPerByte:       p.CostPerByte,
+		GasLimit:          p.HardGasLimitPerOperation,
+		StorageLimit:      p.HardStorageLimitPerOperation,
+	}
+	if f.MinimalFees <= 0 {
+		f.MinimalFees = DefaultMinimalFees
+	}
+	if f.NanotezPerGasUnit <= 0 {
+		f.NanotezPerGasUnit = DefaultMinimalNanotezPerGasUnit
+	}
+	if f.NanotezPerByte <= 0 {
+		f.NanotezPerByte = DefaultMinimalNanotezPerByte
+	}
+	return f
+}
+
+// MinFee returns the minimal fee in mutez for an operation of size bytes
+// that uses gas units.
+func (f FeeDefaults) MinFee(size, gas int64) int64 {
+	fee := f.MinimalFees*1000 + size*f.NanotezPerByte + gas*f.NanotezPerGasUnit
+	return (fee + 999) / 1000 // nano -> micro, round up
+}
+
 func (p Params) IsMainnet() bool {
 	return p.ChainId.Equal(Mainnet)
 }
@@ -48,6 +48,13 @@ var (
 	Nairobinet = MustParseChainIdHash("NetXyuzvDo2Ugzb")
 	Oxfordnet  = MustParseChainIdHash("NetXxWsskGahzQB")
 
+	Networks = map[ChainIdHash]string{
+		Mainnet:    "Mainnet",
+		Ghostnet:   "Ghostnet",
+		Nairobinet: "Nairobinet",
+		Oxfordnet:  "Oxfordnet",
+	}
+
 	Versions = map[ProtocolHash]int{
 		ProtoGenesis:   0,
 		ProtoBootstrap: 0,
@@ -122,6 +129,11 @@ var (
 	}
 )
 
+// Network returns the name of a known network or an empty string.
+func (h ChainIdHash) Network() string {
+	return Networks[h]
+}
+
 type Deployment struct {
 	Protocol          ProtocolHash
 	StartOffset       int64
@@ -5,11 +5,16 @@ package tezos
 
 import (
 	"bytes"
+	"crypto/elliptic"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"blockwatch.cc/tzgo/base58"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 )
 
 var (
@@ -20,6 +25,10 @@ var (
 	// ErrSignature is returned when signature verification fails
 	ErrSignature = errors.New("signature mismatch")
 
+	// ErrRecoveryUnsupported is returned when a public key cannot be
+	// recovered from a signature of this type.
+	ErrRecoveryUnsupported = errors.New("tezos: public key recovery unsupported")
+
 	// InvalidSignature represents an empty invalid signature
 	InvalidSignature = Signature{Type: SignatureTypeInvalid, Data: nil}
 
@@ -105,6 +114,18 @@ func (t SignatureType) String() string {
 	return t.Prefix()
 }
 
+// Curve returns the elliptic curve for ECDSA signature types or nil.
+func (t SignatureType) Curve() elliptic.Curve {
+	switch t {
+	case SignatureTypeSecp256k1:
+		return secp256k1.S256()
+	case SignatureTypeP256:
+		return elliptic.P256()
+	default:
+		return nil
+	}
+}
+
 func (t SignatureType) Tag() byte {
 	switch t {
 	case SignatureTypeEd25519:
@@ -208,6 +229,86 @@ func (s Signature) Clone() Signature {
 	}
 }
 
+// IsCanonical returns true when a secp256k1 or P256 signature uses the low-S
+// form that strict verifiers require. Ed25519 and BLS signatures are not
+// malleable in this way and generic signatures cannot be checked, so any
+// valid signature of these types is reported as canonical.
+func (s Signature) IsCanonical() bool {
+	if !s.IsValid() {
+		return false
+	}
+	curve := s.Type.Curve()
+	if curve == nil {
+		return true
+	}
+	return ecIsLowS(new(big.Int).SetBytes(s.Data[32:]), curve)
+}
+
+// Normalize returns a copy of a secp256k1 or P256 signature in canonical
+// low-S form. Other signatures are returned unchanged.
+func (s Signature) Normalize() Signature {
+	curve := s.Type.Curve()
+	if !s.IsValid() || curve == nil {
+		return s
+	}
+	r, ss := ecNormalizeSignature(
+		new(big.Int).SetBytes(s.Data[:32]),
+		new(big.Int).SetBytes(s.Data[32:]),
+		curve,
+	)
+	buf := make([]byte, 64)
+	r.FillBytes(buf[:32])
+	ss.FillBytes(buf[32:])
+	return Signature{Type: s.Type, Data: buf}
+}
+
+// RecoverPublicKey recovers the public key of signer addr from a secp256k1
+// (tz2) signature over digest. Tezos signatures carry no recovery id, so a
+// signature is valid for up to four public keys. Only the candidate that
+// matches addr is returned, ErrSignature is returned when none matches.
+// Ed25519 (tz1), P256 (tz3) and BLS (tz4) signatures do not support recovery
+// and return ErrRecoveryUnsupported.
+func (s Signature) RecoverPublicKey(digest []byte, addr Address) (Key, error) {
+	keys, err := s.RecoverPublicKeys(digest)
+	if err != nil {
+		return InvalidKey, err
+	}
+	for _, k := range keys {
+		if k.Address().Equal(addr) {
+			return k, nil
+		}
+	}
+	return InvalidKey, ErrSignature
+}
+
+// RecoverPublicKeys returns all candidate public keys for which a secp256k1
+// (tz2) signature over digest is valid. Callers must not trust any single
+// candidate without checking it against a known signer.
+func (s Signature) RecoverPublicKeys(digest []byte) ([]Key, error) {
+	if s.Type != SignatureTypeSecp256k1 {
+		return nil, ErrRecoveryUnsupported
+	}
+	if !s.IsValid() || len(s.Data) != 64 {
+		return nil, ErrSignature
+	}
+	compact := make([]byte, 65)
+	copy(compact[1:], s.Data)
+	keys := make([]Key, 0, 2)
+	for code := byte(0); code < 4; code++ {
+		// compact recovery code for compressed keys
+		compact[0] = 27 + 4 + code
+		pk, _, err := ecdsa.RecoverCompact(compact, digest)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, NewKey(KeyTypeSecp256k1, pk.SerializeCompressed()))
+	}
+	if len(keys) == 0 {
+		return nil, ErrSignature
+	}
+	return keys, nil
+}
+
 // Signature converts a typed Tezos signature into a generic signature string.
 func (s Signature) Generic() string {
 	if !s.IsValid() {
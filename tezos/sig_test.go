@@ -5,6 +5,7 @@ package tezos
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 )
 
@@ -150,3 +151,100 @@ func TestInvalidSig(t *testing.T) {
 	//     t.Errorf("Expected unmarshal error from invalid buffer")
 	// }
 }
+
+func TestSigCanonical(t *testing.T) {
+	digest := Digest([]byte("hello"))
+	for _, v := range []string{
+		"spsk2oTAhiaSywh9ctt8yZLRxL3bo8Mayd3hKFi5iBaoqj2R8bx7ow",
+		"p2sk35q9MJHLN1SBHNhKq7oho1vnZL28bYfsSKDUrDn2e4XVcp6ohZ",
+	} {
+		sk := MustParsePrivateKey(v)
+		for i := 0; i < 16; i++ {
+			sig, err := sk.Sign(digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sig.IsCanonical() {
+				t.Fatalf("%s: high-S signature %s", sk.Type, sig)
+			}
+
+			// flip S to its high-S twin which is still a valid ECDSA signature
+			n := sig.Type.Curve().Params().N
+			s := new(big.Int).SetBytes(sig.Data[32:])
+			high := sig.Clone()
+			new(big.Int).Sub(n, s).FillBytes(high.Data[32:])
+			if high.IsCanonical() {
+				t.Errorf("%s: high-S signature reported canonical", sk.Type)
+			}
+			if err := sk.Public().Verify(digest[:], high); err != nil {
+				t.Errorf("%s: high-S verify failed: %v", sk.Type, err)
+			}
+			if norm := high.Normalize(); !norm.Equal(sig) {
+				t.Errorf("%s: normalize mismatch: have %s want %s", sk.Type, norm, sig)
+			}
+		}
+	}
+
+	// ed25519 signatures are always canonical
+	sk := MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	sig, err := sk.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.IsCanonical() || !sig.Normalize().Equal(sig) {
+		t.Errorf("ed25519 signature not canonical")
+	}
+	if InvalidSignature.IsCanonical() {
+		t.Errorf("invalid signature reported canonical")
+	}
+}
+
+func TestSigRecoverPublicKey(t *testing.T) {
+	digest := Digest([]byte("hello"))
+	sk := MustParsePrivateKey("spsk2oTAhiaSywh9ctt8yZLRxL3bo8Mayd3hKFi5iBaoqj2R8bx7ow")
+	sig, err := sk.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := sig.RecoverPublicKeys(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, k := range keys {
+		if err := k.Verify(digest[:], sig); err != nil {
+			t.Errorf("recovered key %s does not verify: %v", k, err)
+		}
+		found = found || k.IsEqual(sk.Public())
+	}
+	if !found {
+		t.Errorf("signing key %s not recovered", sk.Public())
+	}
+	if k, err := sig.RecoverPublicKey(digest[:], sk.Address()); err != nil || !k.IsEqual(sk.Public()) {
+		t.Errorf("unexpected recovered key %s: %v", k, err)
+	}
+	other := MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	if _, err := sig.RecoverPublicKey(digest[:], other); err != ErrSignature {
+		t.Errorf("expected signature error for foreign address, got %v", err)
+	}
+
+	// invalid secp256k1 signature
+	bad := Signature{Type: SignatureTypeSecp256k1, Data: sig.Data[:32]}
+	if _, err := bad.RecoverPublicKeys(digest[:]); err != ErrSignature {
+		t.Errorf("expected signature error for invalid signature, got %v", err)
+	}
+	bad = Signature{Type: SignatureTypeSecp256k1, Data: make([]byte, 64)}
+	if _, err := bad.RecoverPublicKeys(digest[:]); err != ErrSignature {
+		t.Errorf("expected signature error for zero signature, got %v", err)
+	}
+
+	// ed25519 does not support recovery
+	sk = MustParsePrivateKey("edsk4FTF78Qf1m2rykGpHqostAiq5gYW4YZEoGUSWBTJr2njsDHSnd")
+	sig, err = sk.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sig.RecoverPublicKey(digest[:], sk.Address()); err != ErrRecoveryUnsupported {
+		t.Errorf("expected unsupported error, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ package tezos
 
 import (
 	"fmt"
+	"strings"
 )
 
 // VotingPeriodKind represents a named voting period in Tezos.
@@ -157,8 +158,10 @@ func (v *BallotVote) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// ParseBallotVote parses a ballot vote from its case-insensitive name
+// `yay`, `nay` or `pass`.
 func ParseBallotVote(s string) BallotVote {
-	switch s {
+	switch strings.ToLower(s) {
 	case YAY:
 		return BallotVoteYay
 	case NAY: